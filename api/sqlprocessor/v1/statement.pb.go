@@ -0,0 +1,330 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: api/sqlprocessor/v1/statement.proto
+
+package sqlprocessorv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// File is a SQL file watched by the processor.
+type File struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path is the path of the file as given to the processor, so it may be relative.
+	// Statements read from the standard input have the path "<stdin>".
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Type is the SQL dialect of the file, such as "postgres", "mysql" or "sqlite".
+	Type          string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *File) Reset() {
+	*x = File{}
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*File) ProtoMessage() {}
+
+func (x *File) ProtoReflect() protoreflect.Message {
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use File.ProtoReflect.Descriptor instead.
+func (*File) Descriptor() ([]byte, []int) {
+	return file_api_sqlprocessor_v1_statement_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *File) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *File) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+// Statement is a single SQL statement parsed from a file.
+type Statement struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// File is the file the statement was parsed from.
+	File *File `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	// Content is the statement without comments and the terminating semicolon.
+	Content string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	// LineNum is the line the statement starts on.
+	LineNum       int64 `protobuf:"varint,3,opt,name=line_num,json=lineNum,proto3" json:"line_num,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Statement) Reset() {
+	*x = Statement{}
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Statement) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Statement) ProtoMessage() {}
+
+func (x *Statement) ProtoReflect() protoreflect.Message {
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Statement.ProtoReflect.Descriptor instead.
+func (*Statement) Descriptor() ([]byte, []int) {
+	return file_api_sqlprocessor_v1_statement_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Statement) GetFile() *File {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+func (x *Statement) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Statement) GetLineNum() int64 {
+	if x != nil {
+		return x.LineNum
+	}
+	return 0
+}
+
+// ExportRequest carries a batch of statements.
+type ExportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID identifies the batch in the acknowledging ExportResponse.
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Statements are the exported statements in the order they were processed.
+	Statements    []*Statement `protobuf:"bytes,2,rep,name=statements,proto3" json:"statements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_api_sqlprocessor_v1_statement_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExportRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetStatements() []*Statement {
+	if x != nil {
+		return x.Statements
+	}
+	return nil
+}
+
+// ExportResponse acknowledges a batch of statements.
+type ExportResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID is the ID of the acknowledged ExportRequest.
+	Id uint64 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Error describes why the batch was rejected. It is empty when the batch was accepted.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_sqlprocessor_v1_statement_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_api_sqlprocessor_v1_statement_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ExportResponse) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ExportResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_api_sqlprocessor_v1_statement_proto protoreflect.FileDescriptor
+
+const file_api_sqlprocessor_v1_statement_proto_rawDesc = "" +
+	"\n" +
+	"#api/sqlprocessor/v1/statement.proto\x12\x0fsqlprocessor.v1\".\n" +
+	"\x04File\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\"k\n" +
+	"\tStatement\x12)\n" +
+	"\x04file\x18\x01 \x01(\v2\x15.sqlprocessor.v1.FileR\x04file\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x19\n" +
+	"\bline_num\x18\x03 \x01(\x03R\alineNum\"[\n" +
+	"\rExportRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12:\n" +
+	"\n" +
+	"statements\x18\x02 \x03(\v2\x1a.sqlprocessor.v1.StatementR\n" +
+	"statements\"6\n" +
+	"\x0eExportResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error2a\n" +
+	"\x10StatementService\x12M\n" +
+	"\x06Export\x12\x1e.sqlprocessor.v1.ExportRequest\x1a\x1f.sqlprocessor.v1.ExportResponse(\x010\x01BGZEgithub.com/course-go/sql-processor/api/sqlprocessor/v1;sqlprocessorv1b\x06proto3"
+
+var (
+	file_api_sqlprocessor_v1_statement_proto_rawDescOnce sync.Once
+	file_api_sqlprocessor_v1_statement_proto_rawDescData []byte
+)
+
+func file_api_sqlprocessor_v1_statement_proto_rawDescGZIP() []byte {
+	file_api_sqlprocessor_v1_statement_proto_rawDescOnce.Do(func() {
+		file_api_sqlprocessor_v1_statement_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_sqlprocessor_v1_statement_proto_rawDesc), len(file_api_sqlprocessor_v1_statement_proto_rawDesc)))
+	})
+	return file_api_sqlprocessor_v1_statement_proto_rawDescData
+}
+
+var file_api_sqlprocessor_v1_statement_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_api_sqlprocessor_v1_statement_proto_goTypes = []any{
+	(*File)(nil),           // 0: sqlprocessor.v1.File
+	(*Statement)(nil),      // 1: sqlprocessor.v1.Statement
+	(*ExportRequest)(nil),  // 2: sqlprocessor.v1.ExportRequest
+	(*ExportResponse)(nil), // 3: sqlprocessor.v1.ExportResponse
+}
+var file_api_sqlprocessor_v1_statement_proto_depIdxs = []int32{
+	0, // 0: sqlprocessor.v1.Statement.file:type_name -> sqlprocessor.v1.File
+	1, // 1: sqlprocessor.v1.ExportRequest.statements:type_name -> sqlprocessor.v1.Statement
+	2, // 2: sqlprocessor.v1.StatementService.Export:input_type -> sqlprocessor.v1.ExportRequest
+	3, // 3: sqlprocessor.v1.StatementService.Export:output_type -> sqlprocessor.v1.ExportResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_api_sqlprocessor_v1_statement_proto_init() }
+func file_api_sqlprocessor_v1_statement_proto_init() {
+	if File_api_sqlprocessor_v1_statement_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_sqlprocessor_v1_statement_proto_rawDesc), len(file_api_sqlprocessor_v1_statement_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_sqlprocessor_v1_statement_proto_goTypes,
+		DependencyIndexes: file_api_sqlprocessor_v1_statement_proto_depIdxs,
+		MessageInfos:      file_api_sqlprocessor_v1_statement_proto_msgTypes,
+	}.Build()
+	File_api_sqlprocessor_v1_statement_proto = out.File
+	file_api_sqlprocessor_v1_statement_proto_goTypes = nil
+	file_api_sqlprocessor_v1_statement_proto_depIdxs = nil
+}
@@ -2,20 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/course-go/sql-processor/internal/cmd"
-	"github.com/course-go/sql-processor/internal/exporter"
-	"github.com/course-go/sql-processor/internal/exporter/stdout"
 )
 
 func main() {
-	exporters := []exporter.Exporter{stdout.NewExporter()}
-
-	err := cmd.Run(context.Background(), os.Args, exporters)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed running sql processor: %v", err)
-		os.Exit(1)
+	err := cmd.Run(context.Background(), os.Args, nil)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(os.Stderr, "failed running sql processor: %v\n", err)
 	}
+
+	os.Exit(cmd.ExitCode(err))
 }
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	shutdownTimeout   = 5 * time.Second
+	readHeaderTimeout = 5 * time.Second
+)
+
+// Option configures the [Server].
+type Option func(s *Server)
+
+// WithHandler serves the handler on the pattern besides the built-in endpoints,
+// such as "/loglevel" or "/metrics".
+func WithHandler(pattern string, handler http.Handler) Option {
+	return func(s *Server) {
+		s.mux.Handle(pattern, handler)
+	}
+}
+
+// Server is the admin HTTP server exposing the health, readiness and status of the pipeline.
+//
+// It serves the following endpoints:
+//
+//   - /healthz responds 200 while the process is running.
+//   - /readyz responds 200 when the [Status] is ready and 503 otherwise.
+//   - /status responds with the [Status] JSON document.
+type Server struct {
+	logger   *slog.Logger
+	status   func() Status
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+}
+
+// Listen creates a new [Server] listening on the address. The status function is called on each request.
+func Listen(
+	ctx context.Context,
+	logger *slog.Logger,
+	address string,
+	status func() Status,
+	opts ...Option,
+) (*Server, error) {
+	var config net.ListenConfig
+	listener, err := config.Listen(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed listening: %w", err)
+	}
+
+	s := &Server{
+		logger:   logger.With("component", "admin"),
+		status:   status,
+		mux:      http.NewServeMux(),
+		listener: listener,
+	}
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /readyz", s.handleReady)
+	s.mux.HandleFunc("GET /status", s.handleStatus)
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return s, nil
+}
+
+// Addr returns the address the [Server] listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve serves the requests until the context is done.
+func (s *Server) Serve(ctx context.Context) {
+	s.logger.Info("serving admin endpoints", "address", s.Addr().String())
+
+	stop := context.AfterFunc(ctx, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+
+		err := s.server.Shutdown(shutdownCtx)
+		if err != nil {
+			s.logger.Error("failed shutting down admin server", "error", err)
+		}
+	})
+	defer stop()
+
+	err := s.server.Serve(s.listener)
+	if !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("failed serving admin endpoints", "error", err)
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	status := s.currentStatus()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = io.WriteString(w, "not ready: "+status.Reason+"\n")
+		return
+	}
+
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(s.currentStatus())
+}
+
+func (s *Server) currentStatus() Status {
+	status := s.status()
+	status.evaluate()
+	return status
+}
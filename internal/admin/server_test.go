@@ -0,0 +1,134 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/admin"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/test/testlogger"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		status admin.Status
+	)
+	setStatus := func(s admin.Status) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		status = s
+	}
+
+	logger, _ := testlogger.NewTestErrorLogger()
+	extra := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "extra")
+	})
+	server, err := admin.Listen(t.Context(), logger, "127.0.0.1:0", func() admin.Status {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return status
+	}, admin.WithHandler("/extra", extra))
+	if err != nil {
+		t.Fatalf("failed listening: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		server.Serve(ctx)
+	}()
+
+	url := "http://" + server.Addr().String()
+	exporters := admin.NewExporterStatuses([]exporter.ExporterStats{
+		{Name: "stdout", ExportedCount: 3, CircuitOpen: true},
+		{Name: "jsonl", FailedCount: 1, LastError: errors.New("disk full"), LastErrorTime: time.Now()},
+	})
+
+	setStatus(admin.Status{Directories: []string{"/var/sql"}, Exporters: exporters})
+	assertResponse(t, url+"/readyz", http.StatusServiceUnavailable, "not ready: not started\n")
+
+	setStatus(admin.Status{Started: true, Directories: []string{"/var/sql"}, Exporters: exporters})
+	assertResponse(t, url+"/healthz", http.StatusOK, "ok\n")
+	assertResponse(t, url+"/readyz", http.StatusOK, "ok\n")
+	assertResponse(t, url+"/extra", http.StatusOK, "extra")
+
+	var got admin.Status
+	err = json.Unmarshal([]byte(get(t, url+"/status", http.StatusOK)), &got)
+	if err != nil {
+		t.Fatalf("failed decoding status: %v", err)
+	}
+
+	if !got.Ready || len(got.Exporters) != 2 || got.Exporters[1].LastError != "disk full" ||
+		got.Directories[0] != "/var/sql" {
+		t.Fatalf("unexpected status: got = %+v", got)
+	}
+
+	setStatus(admin.Status{Started: true, Draining: true, Exporters: exporters})
+	assertResponse(t, url+"/readyz", http.StatusServiceUnavailable, "not ready: draining\n")
+
+	setStatus(admin.Status{Started: true})
+	assertResponse(t, url+"/readyz", http.StatusServiceUnavailable, "not ready: no exporters\n")
+
+	// Exporters without circuit breakers keep their circuits closed while failing.
+	exporters[1].Failing = true
+	setStatus(admin.Status{Started: true, Exporters: exporters})
+	assertResponse(t, url+"/readyz", http.StatusServiceUnavailable, "not ready: all exporters are failing\n")
+
+	exporters[1].CircuitOpen = true
+	setStatus(admin.Status{Started: true, Exporters: exporters})
+	assertResponse(t, url+"/readyz", http.StatusServiceUnavailable, "not ready: all exporter circuits are open\n")
+
+	cancel()
+	<-done
+}
+
+func assertResponse(t *testing.T, url string, expectedCode int, expectedBody string) {
+	t.Helper()
+
+	body := get(t, url, expectedCode)
+	if body != expectedBody {
+		t.Fatalf("%s: expected = %q, got = %q", url, expectedBody, body)
+	}
+}
+
+func get(t *testing.T, url string, expectedCode int) string {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed creating request: %v", err)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("failed requesting %s: %v", url, err)
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("failed reading %s: %v", url, err)
+	}
+
+	if response.StatusCode != expectedCode {
+		t.Fatalf("%s: expected = %v, got = %v", url, expectedCode, response.StatusCode)
+	}
+
+	return string(body)
+}
@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/processor"
+)
+
+// Status represents the state of the processing pipeline.
+type Status struct {
+	// Ready reports whether the pipeline accepts work. It is set by the [Server].
+	Ready bool `json:"ready"`
+	// Reason explains why the pipeline is not ready.
+	Reason string `json:"reason,omitempty"`
+	// Started reports whether the exporter manager was started. The pipeline is not ready before.
+	Started bool `json:"started"`
+	// Draining reports whether the pipeline is shutting down.
+	Draining bool `json:"draining"`
+	// Directories are the paths of the watched directories.
+	Directories []string `json:"directories"`
+	// InFlight are the files and batches being handled by each component.
+	InFlight InFlight `json:"in_flight"`
+	// Channels are the channels between the components.
+	Channels []Channel `json:"channels"`
+	// Processor are the processor counters.
+	Processor ProcessorStatus `json:"processor"`
+	// Exporters are the per-exporter counters.
+	Exporters []ExporterStatus `json:"exporters"`
+}
+
+// InFlight represents the work being handled by each component.
+type InFlight struct {
+	// ObserverFiles are the new files waiting to stop being written to.
+	ObserverFiles int `json:"observer_files"`
+	// ProcessorFiles are the files being processed.
+	ProcessorFiles int64 `json:"processor_files"`
+	// ManagerBatches are the batches waiting in the exporter queues.
+	ManagerBatches int `json:"manager_batches"`
+}
+
+// Channel represents a channel between two components.
+type Channel struct {
+	Name     string `json:"name"`
+	Depth    int    `json:"depth"`
+	Capacity int    `json:"capacity"`
+}
+
+// ProcessorStatus represents the processor counters.
+type ProcessorStatus struct {
+	Processed  uint64 `json:"processed"`
+	Failed     uint64 `json:"failed"`
+	Statements uint64 `json:"statements"`
+}
+
+// ExporterStatus represents the counters of a single exporter.
+type ExporterStatus struct {
+	Name           string     `json:"name"`
+	Exported       uint64     `json:"exported"`
+	Failed         uint64     `json:"failed"`
+	Dropped        uint64     `json:"dropped"`
+	DeadLetters    uint64     `json:"dead_letters"`
+	QueueDepth     int        `json:"queue_depth"`
+	QueueCapacity  int        `json:"queue_capacity"`
+	SpilledBatches int        `json:"spilled_batches"`
+	CircuitOpen    bool       `json:"circuit_open"`
+	Failing        bool       `json:"failing"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorTime  *time.Time `json:"last_error_time,omitempty"`
+}
+
+// NewProcessorStatus creates the [ProcessorStatus] of the processor statistics.
+func NewProcessorStatus(stats processor.Stats) ProcessorStatus {
+	return ProcessorStatus{
+		Processed:  stats.ProcessedCount,
+		Failed:     stats.FailedCount,
+		Statements: stats.StatementCount,
+	}
+}
+
+// NewExporterStatuses creates the [ExporterStatus]es of the exporter statistics.
+func NewExporterStatuses(stats []exporter.ExporterStats) []ExporterStatus {
+	statuses := make([]ExporterStatus, 0, len(stats))
+	for _, s := range stats {
+		status := ExporterStatus{
+			Name:           s.Name,
+			Exported:       s.ExportedCount,
+			Failed:         s.FailedCount,
+			Dropped:        s.DroppedCount,
+			DeadLetters:    s.DeadLetterCount,
+			QueueDepth:     s.QueueDepth,
+			QueueCapacity:  s.QueueCapacity,
+			SpilledBatches: s.SpilledBatches,
+			CircuitOpen:    s.CircuitOpen,
+			Failing:        s.Failing,
+		}
+		if s.LastError != nil {
+			status.LastError = s.LastError.Error()
+			status.LastErrorTime = &s.LastErrorTime
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// evaluate sets the readiness. The pipeline is not ready while draining, before it is started,
+// without exporters or when no exporter can export, either as its circuit is open or its last export failed.
+func (s *Status) evaluate() {
+	s.Ready, s.Reason = false, ""
+	switch {
+	case s.Draining:
+		s.Reason = "draining"
+		return
+	case !s.Started:
+		s.Reason = "not started"
+		return
+	case len(s.Exporters) == 0:
+		s.Reason = "no exporters"
+		return
+	}
+
+	open := 0
+	for _, e := range s.Exporters {
+		switch {
+		case e.CircuitOpen:
+			open++
+		case !e.Failing:
+			s.Ready = true
+			return
+		}
+	}
+
+	s.Reason = "all exporters are failing"
+	if open == len(s.Exporters) {
+		s.Reason = "all exporter circuits are open"
+	}
+}
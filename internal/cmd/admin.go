@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/admin"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/processor"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// pipelineMetrics are the Prometheus metrics of all pipeline stages served on the admin /metrics endpoint.
+type pipelineMetrics struct {
+	registry  *prometheus.Registry
+	observer  *observer.Metrics
+	processor *processor.Metrics
+	exporter  *exporter.Metrics
+}
+
+func newPipelineMetrics() *pipelineMetrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &pipelineMetrics{
+		registry:  registry,
+		observer:  observer.NewMetrics(registry),
+		processor: processor.NewMetrics(registry),
+		exporter:  exporter.NewMetrics(registry),
+	}
+}
+
+// pipelineStatus describes the running pipeline for the admin server.
+type pipelineStatus struct {
+	directories []observer.Directory
+	// observer is nil when the files are not observed.
+	observer    *observer.Observer
+	processor   *processor.Processor
+	manager     *exporter.Manager
+	fileCh      chan sql.File
+	statementCh chan sql.Statement
+	shutdown    *shutdown
+	metrics     *pipelineMetrics
+}
+
+func (s *pipelineStatus) status() admin.Status {
+	processorStats, exporterStats := s.processor.Stats(), s.manager.Stats()
+	inFlight := admin.InFlight{ProcessorFiles: processorStats.InFlightCount}
+	if s.observer != nil {
+		inFlight.ObserverFiles = s.observer.Pending()
+	}
+
+	for _, stats := range exporterStats {
+		inFlight.ManagerBatches += stats.QueueDepth
+	}
+
+	return admin.Status{
+		Started:     s.manager.Started(),
+		Draining:    s.shutdown.intake.Err() != nil,
+		Directories: directoryPaths(s.directories),
+		InFlight:    inFlight,
+		Channels: []admin.Channel{
+			{Name: "observer-processor", Depth: len(s.fileCh), Capacity: cap(s.fileCh)},
+			{Name: "processor-manager", Depth: len(s.statementCh), Capacity: cap(s.statementCh)},
+		},
+		Processor: admin.NewProcessorStatus(processorStats),
+		Exporters: admin.NewExporterStatuses(exporterStats),
+	}
+}
+
+// serveAdmin serves the admin endpoints of the pipeline until the returned function is called.
+// Nothing is served when the pipeline has no admin address.
+func serveAdmin(ctx context.Context, p *pipeline, status *pipelineStatus) (stop func(), err error) {
+	if p.adminAddress == "" {
+		return func() {}, nil
+	}
+
+	server, err := admin.Listen(ctx, p.logger, p.adminAddress, status.status,
+		admin.WithHandler("/loglevel", p.log.Levels),
+		admin.WithHandler("GET /metrics", promhttp.HandlerFor(status.metrics.registry, promhttp.HandlerOpts{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed starting admin server: %w", err)
+	}
+
+	// The endpoints are served during the shutdown as well.
+	serveCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	var wg sync.WaitGroup
+	wg.Go(func() { server.Serve(serveCtx) })
+	return func() {
+		cancel()
+		wg.Wait()
+	}, nil
+}
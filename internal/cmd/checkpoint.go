@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	checkpointDirectoryPermissions = 0o700
+	checkpointFilePermissions      = 0o600
+)
+
+var ErrCheckpointMismatch = errors.New("checkpoint of different directories")
+
+// checkpoint collects the files left unprocessed by a shutdown, so the next watch run can resume them.
+// Checkpointing is disabled when the path is empty.
+//
+// The files are stored in a JSON lines file. The first line records the observed directories
+// and each following line holds one [sql.File]. Only a run observing the same directories resumes the files.
+type checkpoint struct {
+	path        string
+	directories []string
+
+	mu    sync.Mutex
+	files []sql.File
+}
+
+// checkpointHeader is the first line of the checkpoint file.
+type checkpointHeader struct {
+	// Directories are the observed directories in the "PATH:DIALECT" form with absolute paths.
+	Directories []string `json:"directories"`
+}
+
+func newCheckpoint(path string, directories []observer.Directory) *checkpoint {
+	c := &checkpoint{path: path}
+	for _, directory := range directories {
+		path, err := filepath.Abs(directory.Path)
+		if err != nil {
+			path = directory.Path
+		}
+
+		c.directories = append(c.directories, path+":"+string(directory.Type))
+	}
+
+	slices.Sort(c.directories)
+	return c
+}
+
+// add records the unprocessed file. It is safe for concurrent use.
+func (c *checkpoint) add(files ...sql.File) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.files = append(c.files, files...)
+}
+
+// len returns the number of recorded files.
+func (c *checkpoint) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.files)
+}
+
+// write appends the recorded files to the checkpoint file. Nothing is written when there are none.
+func (c *checkpoint) write() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.path == "" || len(c.files) == 0 {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(c.path), checkpointDirectoryPermissions)
+	if err != nil {
+		return fmt.Errorf("failed creating checkpoint directory: %w", err)
+	}
+
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, checkpointFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed opening checkpoint: %w", err)
+	}
+
+	encoder := json.NewEncoder(f)
+	info, err := f.Stat()
+	if err == nil && info.Size() == 0 {
+		err = encoder.Encode(checkpointHeader{Directories: c.directories})
+	}
+
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed writing checkpoint: %w", err)
+	}
+
+	for _, file := range c.files {
+		err = encoder.Encode(file)
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed writing checkpoint: %w", err)
+		}
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed closing checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// take reads the files of the checkpoint file and removes it. A missing file has no files.
+// Files which no longer exist are skipped. A checkpoint of different directories is left
+// as it is and [ErrCheckpointMismatch] is returned.
+func (c *checkpoint) take() (files []sql.File, err error) {
+	if c.path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed opening checkpoint: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	if scanner.Scan() {
+		var header checkpointHeader
+		err = json.Unmarshal(scanner.Bytes(), &header)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding checkpoint: %w", err)
+		}
+
+		if !slices.Equal(header.Directories, c.directories) {
+			return nil, fmt.Errorf("%w: %s was written for %v", ErrCheckpointMismatch, c.path, header.Directories)
+		}
+	}
+
+	for scanner.Scan() {
+		var file sql.File
+		err = json.Unmarshal(scanner.Bytes(), &file)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding checkpoint: %w", err)
+		}
+
+		_, err = os.Stat(file.Path)
+		if err == nil {
+			files = append(files, file)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading checkpoint: %w", err)
+	}
+
+	err = os.Remove(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed removing checkpoint: %w", err)
+	}
+
+	return files, nil
+}
@@ -2,14 +2,171 @@ package cmd
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
 
 	"github.com/course-go/sql-processor/internal/exporter"
 )
 
+const (
+	appName           = "sql-processor"
+	channelBufferSize = 64
+	stdinInput        = "-"
+)
+
+const (
+	// ExitSuccess is the exit code of a successful run.
+	ExitSuccess = 0
+	// ExitFailure is the exit code of a run that failed at runtime.
+	ExitFailure = 1
+	// ExitUsage is the exit code of invalid arguments, flags or configuration.
+	ExitUsage = 2
+	// ExitDrainTimeout is the exit code of a shutdown which did not finish the work within the drain timeout.
+	ExitDrainTimeout = 3
+	// ExitForced is the exit code of a shutdown forced by a second signal.
+	ExitForced = 130
+)
+
+const (
+	watchCommand    = "watch"
+	processCommand  = "process"
+	validateCommand = "validate"
+	versionCommand  = "version"
+	helpCommand     = "help"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrUsage          = errors.New("usage error")
+)
+
+const usage = `Usage:
+  sql-processor [COMMAND] [FLAGS] [ARGUMENTS]
+
+Commands:
+  watch      watch directories and process new SQL files (default)
+  process    process existing SQL files and directories and exit
+  validate   validate arguments and exporters without running
+  config     validate a configuration file with "config validate FILE"
+  version    print version information
+  dlq        replay dead letters
+
+Run "sql-processor COMMAND -h" to see the flags of a command.
+`
+
 // Run runs the SQL processor.
-// It creates all application components and wires them together.
+// It parses the command from the arguments, creates all application components and wires them together.
+//
+// The exporters are used unless replaced by -exporter flags or the exporters of the -config file.
+// Arguments not starting with a known command run the watch command,
+// so "sql-processor DIRECTIVE..." keeps working.
 func Run(ctx context.Context, args []string, exporters []exporter.Exporter) error {
-	// TODO: Implement.
+	if len(args) < 2 { //nolint: mnd
+		return fmt.Errorf("%w: %w: no directives provided", ErrUsage, ErrUnknownCommand)
+	}
+
+	command, commandArgs := args[1], args[2:]
+	switch command {
+	case watchCommand:
+		return runWatch(ctx, newRegistry(), commandArgs, exporters)
+	case processCommand:
+		return runProcess(ctx, commandArgs, exporters)
+	case validateCommand:
+		return runValidate(os.Stdout, commandArgs, exporters)
+	case configCommand:
+		return runConfig(os.Stdout, commandArgs)
+	case versionCommand:
+		return runVersion(os.Stdout, commandArgs)
+	case deadLetterCommand:
+		return runDeadLetters(ctx, commandArgs, exporters)
+	case helpCommand, "-h", "-help", "--help":
+		printUsage(os.Stdout)
+		return nil
+	default:
+		return runWatch(ctx, newRegistry(), args[1:], exporters)
+	}
+}
+
+// ExitCode returns the process exit code for the error returned by [Run].
+func ExitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitSuccess
+	case errors.Is(err, ErrUsage):
+		return ExitUsage
+	case errors.Is(err, ErrDrainTimeout):
+		return ExitDrainTimeout
+	default:
+		return ExitFailure
+	}
+}
+
+func printUsage(w io.Writer) {
+	_, _ = io.WriteString(w, usage)
+}
+
+// newFlagSet creates a flag set of the command printing the command usage on errors.
+func newFlagSet(command, arguments, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(appName+" "+command, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage:\n  %s %s [FLAGS] %s\n\n%s\n\nFlags:\n",
+			appName, command, arguments, description)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parseFlags parses the flags and returns the positional arguments.
+// Unlike [flag.FlagSet.Parse], flags may follow positional arguments. Arguments after "--" are positional.
+// Standard input directives such as "-:postgres" are positional even though they start with a dash.
+func parseFlags(flags *flag.FlagSet, args []string) (positional []string, err error) {
+	for len(args) > 0 {
+		if isStdinDirective(args[0]) {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+
+		end := slices.IndexFunc(args, isStdinDirective)
+		if end < 0 {
+			end = len(args)
+		}
+
+		err = flags.Parse(args[:end])
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+		}
+
+		rest := flags.Args()
+		consumed := end - len(rest)
+		if consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, args[consumed:]...)
+			break
+		}
+
+		if len(rest) == 0 {
+			args = args[end:]
+			continue
+		}
+
+		positional = append(positional, rest[0])
+		args = args[consumed+1:]
+	}
+
+	return positional, nil
+}
 
-	return nil
+// isStdinDirective reports whether the argument is a "-:DIALECT" directive reading the standard input.
+func isStdinDirective(arg string) bool {
+	return strings.HasPrefix(arg, stdinInput+":")
 }
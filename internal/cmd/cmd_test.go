@@ -1,12 +1,25 @@
 package cmd_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/course-go/sql-processor/internal/admin"
 	"github.com/course-go/sql-processor/internal/cmd"
+	"github.com/course-go/sql-processor/internal/config"
 	"github.com/course-go/sql-processor/internal/exporter"
 	"github.com/course-go/sql-processor/internal/sql"
 	"github.com/course-go/sql-processor/internal/test/testexporter"
@@ -44,6 +57,17 @@ func TestRun(t *testing.T) { //nolint: cyclop, gocognit
 		}
 	})
 
+	t.Run("InvalidExporterSpecs", func(t *testing.T) {
+		t.Parallel()
+
+		directive := t.TempDir() + ":" + string(sql.PostgresType)
+		args := []string{"sql-processor", "-exporter", "carrier-pigeon", "-exporter", "jsonl:colour=red", directive}
+		err := cmd.Run(t.Context(), args, nil)
+		if !errors.Is(err, exporter.ErrUnknownExporterType) || !errors.Is(err, exporter.ErrUnknownParam) {
+			t.Fatalf("expected both spec errors: got = %v", err)
+		}
+	})
+
 	t.Run("SingleDirectoryDirective", func(t *testing.T) {
 		t.Parallel()
 
@@ -177,6 +201,677 @@ func TestRun(t *testing.T) { //nolint: cyclop, gocognit
 	})
 }
 
+func TestCommands(t *testing.T) {
+	t.Parallel()
+
+	postgresDirective := filepath.Join("testdata", "postgres") + ":" + string(sql.PostgresType)
+	mysqlDirective := filepath.Join("testdata", "mysql") + ":" + string(sql.MySQL)
+	postgresStatementCount, mysqlStatementCount := 11, 11
+
+	t.Run("UsageErrors", func(t *testing.T) {
+		t.Parallel()
+
+		tests := map[string][]string{
+			"UnknownFlag":       {"sql-processor", "process", "-colour", postgresDirective},
+			"InvalidDirective":  {"sql-processor", "watch", "testdata"},
+			"InvalidWorkers":    {"sql-processor", "process", "-workers", "0", postgresDirective},
+			"InvalidLogFormat":  {"sql-processor", "validate", "-log-format", "xml", postgresDirective},
+			"MissingInput":      {"sql-processor", "process", filepath.Join(t.TempDir(), "missing") + ":postgres"},
+			"VersionArguments":  {"sql-processor", "version", "extra"},
+			"UnknownDLQCommand": {"sql-processor", "dlq", "purge"},
+			"DuplicateStdin":    {"sql-processor", "process", "-:postgres", "-:mysql"},
+			"InvalidLogLevel":   {"sql-processor", "process", "-log-component", "observer", postgresDirective},
+			"InvalidEndpoint":   {"sql-processor", "process", "-otlp-endpoint", "localhost:4317", postgresDirective},
+			"InvalidQueueSize":  {"sql-processor", "process", "-queue-size", "0", postgresDirective},
+			"InvalidOverflow":   {"sql-processor", "process", "-overflow", "explode", postgresDirective},
+			"InvalidRetries":    {"sql-processor", "process", "-retry-attempts", "0", postgresDirective},
+		}
+		for name, args := range tests {
+			err := cmd.Run(t.Context(), args, nil)
+			if cmd.ExitCode(err) != cmd.ExitUsage {
+				t.Errorf("%s: expected = %v, got = %v (%v)", name, cmd.ExitUsage, cmd.ExitCode(err), err)
+			}
+		}
+	})
+
+	t.Run("ExitCode", func(t *testing.T) {
+		t.Parallel()
+
+		codes := map[error]int{
+			nil:                        cmd.ExitSuccess,
+			flag.ErrHelp:               cmd.ExitSuccess,
+			cmd.ErrUsage:               cmd.ExitUsage,
+			cmd.ErrDrainTimeout:        cmd.ExitDrainTimeout,
+			errors.New("disk on fire"): cmd.ExitFailure,
+		}
+		for err, expected := range codes {
+			if got := cmd.ExitCode(err); got != expected {
+				t.Errorf("%v: expected = %v, got = %v", err, expected, got)
+			}
+		}
+	})
+
+	t.Run("Help", func(t *testing.T) {
+		t.Parallel()
+
+		err := cmd.Run(t.Context(), []string{"sql-processor", "process", "-h"}, nil)
+		if !errors.Is(err, flag.ErrHelp) {
+			t.Fatalf("expected = %v, got = %v", flag.ErrHelp, err)
+		}
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "statements.jsonl")
+		args := []string{"sql-processor", "validate", "-exporter", "jsonl:path=" + path, postgresDirective}
+		err := cmd.Run(t.Context(), args, nil)
+		if err != nil {
+			t.Fatalf("failed validating: %v", err)
+		}
+
+		_, err = os.Stat(path)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected validation without side effects: got = %v", err)
+		}
+	})
+
+	t.Run("ValidateStdin", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"sql-processor", "validate", "-:postgres", "-log-level", "debug", mysqlDirective}
+		err := cmd.Run(t.Context(), args, nil)
+		if err != nil {
+			t.Fatalf("failed validating: %v", err)
+		}
+	})
+
+	t.Run("Process", func(t *testing.T) {
+		t.Parallel()
+
+		e := testexporter.New()
+		args := []string{
+			"sql-processor", "process", "-workers", "2", "-dead-letter-dir", t.TempDir(),
+			postgresDirective, mysqlDirective,
+		}
+		err := cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if err != nil {
+			t.Fatalf("failed processing: %v", err)
+		}
+
+		expected := postgresStatementCount + mysqlStatementCount
+		if len(e.Statements()) != expected {
+			t.Fatalf("expected = %v, got = %v", expected, len(e.Statements()))
+		}
+	})
+
+	t.Run("ProcessLogFile", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "sql-processor.log")
+		args := []string{
+			"sql-processor", "process", "-log-file", path, "-log-level", "error",
+			"-log-component", "processor=debug", "-dead-letter-dir", t.TempDir(), postgresDirective,
+		}
+		err := cmd.Run(t.Context(), args, []exporter.Exporter{testexporter.New()})
+		if err != nil {
+			t.Fatalf("failed processing: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed reading log file: %v", err)
+		}
+
+		if !strings.Contains(string(data), "component=processor") ||
+			strings.Contains(string(data), "processing files") {
+			t.Fatalf("expected only processor logs: got = %s", data)
+		}
+	})
+
+	t.Run("ProcessFailedExports", func(t *testing.T) {
+		t.Parallel()
+
+		e := testexporter.New()
+		e.Fail(errors.New("sink unavailable"))
+		deadLetterDirectory := t.TempDir()
+		args := []string{"sql-processor", "process", "-dead-letter-dir", deadLetterDirectory, postgresDirective}
+		err := cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if !errors.Is(err, cmd.ErrProcessingFailed) || cmd.ExitCode(err) != cmd.ExitFailure {
+			t.Fatalf("expected = %v, got = %v", cmd.ErrProcessingFailed, err)
+		}
+
+		deadLetters, err := exporter.NewDeadLetterQueue(deadLetterDirectory).Read(exporter.Name(e))
+		if err != nil {
+			t.Fatalf("failed reading dead letters: %v", err)
+		}
+
+		if len(deadLetters) == 0 {
+			t.Fatalf("expected dead letters of the failed exports")
+		}
+	})
+
+	t.Run("ProcessReport", func(t *testing.T) {
+		t.Parallel()
+
+		// The line of the file is longer than the processor accepts.
+		directory := t.TempDir()
+		longFile := filepath.Join(directory, "long.sql")
+		err := os.WriteFile(longFile, bytes.Repeat([]byte("a"), 16<<20+1), filePermissions)
+		if err != nil {
+			t.Fatalf("failed writing file: %v", err)
+		}
+
+		e := testexporter.New()
+		e.Fail(errors.New("sink unavailable"))
+		reportPath := filepath.Join(directory, "report.json")
+		err = os.WriteFile(reportPath, []byte("stale"), filePermissions)
+		if err != nil {
+			t.Fatalf("failed writing report: %v", err)
+		}
+
+		args := []string{
+			"sql-processor", "process", "-dead-letter-dir", t.TempDir(), "-report", reportPath,
+			postgresDirective, longFile + ":" + string(sql.MySQL),
+		}
+		err = cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if !errors.Is(err, cmd.ErrProcessingFailed) {
+			t.Fatalf("expected = %v, got = %v", cmd.ErrProcessingFailed, err)
+		}
+
+		var report struct {
+			Files struct {
+				Total     int `json:"total"`
+				Processed int `json:"processed"`
+				Failed    int `json:"failed"`
+			} `json:"files"`
+			Statements struct {
+				Total int              `json:"total"`
+				Kinds map[sql.Kind]int `json:"kinds"`
+			} `json:"statements"`
+			Directories []struct {
+				Directory string   `json:"directory"`
+				Dialect   sql.Type `json:"dialect"`
+				Files     int      `json:"files"`
+			} `json:"directories"`
+			Errors []struct {
+				Cause string   `json:"cause"`
+				Files []string `json:"files"`
+			} `json:"errors"`
+			Slowest   []json.RawMessage `json:"slowest"`
+			Exporters []struct {
+				Failed    int    `json:"failed"`
+				LastError string `json:"last_error"`
+			} `json:"exporters"`
+		}
+		content, err := os.ReadFile(reportPath)
+		if err != nil {
+			t.Fatalf("failed reading report: %v", err)
+		}
+
+		err = json.Unmarshal(content, &report)
+		if err != nil {
+			t.Fatalf("failed decoding report: %v", err)
+		}
+
+		if report.Files.Total != 4 || report.Files.Processed != 4 || report.Files.Failed != 1 ||
+			report.Statements.Total != postgresStatementCount || len(report.Statements.Kinds) == 0 {
+			t.Fatalf("unexpected report counts: got = %s", content)
+		}
+
+		postgresDirectory := filepath.Join("testdata", "postgres")
+		if len(report.Directories) != 2 || report.Directories[1].Directory != postgresDirectory ||
+			report.Directories[1].Dialect != sql.PostgresType || report.Directories[1].Files != 3 {
+			t.Fatalf("unexpected report directories: got = %s", content)
+		}
+
+		if len(report.Errors) != 1 || report.Errors[0].Cause != "bufio.Scanner: token too long" ||
+			report.Errors[0].Files[0] != longFile {
+			t.Fatalf("unexpected report errors: got = %s", content)
+		}
+
+		if len(report.Slowest) != 4 || len(report.Exporters) != 1 ||
+			report.Exporters[0].Failed != postgresStatementCount ||
+			report.Exporters[0].LastError != "sink unavailable" {
+			t.Fatalf("unexpected report: got = %s", content)
+		}
+
+		// The report replaces the stale one without leaving temporary files behind.
+		entries, err := os.ReadDir(directory)
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("expected = 2 files, got = %v (%v)", entries, err)
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Validate", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		path := writeConfig(t, directory, "directories:\n  - path: "+directory+"\n    dialect: postgres\n"+
+			"exporters:\n  - type: jsonl\n    settings:\n      path: "+filepath.Join(directory, "out.jsonl")+"\n")
+		err := cmd.Run(t.Context(), []string{"sql-processor", "config", "validate", path}, nil)
+		if err != nil {
+			t.Fatalf("failed validating config: %v", err)
+		}
+
+		path = writeConfig(t, directory, "directories:\n  - path: missing\n    dialect: postgres\n"+
+			"exporters:\n  - type: jsonl\n    settings:\n      colour: red\n")
+		err = cmd.Run(t.Context(), []string{"sql-processor", "config", "validate", path}, nil)
+		if cmd.ExitCode(err) != cmd.ExitUsage || !errors.Is(err, config.ErrDirectoryNotExists) ||
+			!errors.Is(err, exporter.ErrUnknownParam) {
+			t.Fatalf("expected both config errors: got = %v", err)
+		}
+
+		if !strings.Contains(err.Error(), path+":2:5: directories[0].path") {
+			t.Fatalf("expected position of the error: got = %v", err)
+		}
+	})
+
+	t.Run("Process", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		inputs := filepath.Join(directory, "inputs")
+		nested := filepath.Join(inputs, "nested")
+		createDirectory(t, nested)
+		copyFiles(t, inputs, []string{filepath.Join("testdata", "postgres", "test-select.sql")})
+		copyFiles(t, nested, []string{filepath.Join("testdata", "postgres", "test-update.sql")})
+
+		output := filepath.Join(directory, "statements.jsonl")
+		path := writeConfig(t, directory, `
+processor:
+  workers: 3
+dead_letter_dir: dead-letters
+batch:
+  max_count: 1
+  split_on_file: true
+queue:
+  overflow: spill
+  spill_dir: spill
+directories:
+  - path: inputs
+    dialect: postgres
+    disposition: move:processed
+exporters:
+  - name: archive
+    type: jsonl
+    settings:
+      path: `+output+`
+    route:
+      kinds: query
+`)
+		args := []string{"sql-processor", "process", "-config", path, "-workers", "2"}
+		err := cmd.Run(t.Context(), args, nil)
+		if err != nil {
+			t.Fatalf("failed processing: %v", err)
+		}
+
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("failed reading output: %v", err)
+		}
+
+		if lines := strings.Count(string(data), "\n"); lines == 0 {
+			t.Fatalf("expected exported queries: got = %v", lines)
+		}
+
+		if strings.Contains(string(data), "UPDATE") || strings.Contains(string(data), "nested") {
+			t.Fatalf("expected only queries of the non-recursive directory: got = %s", data)
+		}
+
+		for path, exists := range map[string]bool{
+			filepath.Join(directory, "processed", "test-select.sql"): true,
+			filepath.Join(inputs, "test-select.sql"):                 false,
+			filepath.Join(nested, "test-update.sql"):                 true,
+		} {
+			_, err := os.Stat(path)
+			if (err == nil) != exists {
+				t.Errorf("%s: expected = %v, got = %v", path, exists, err == nil)
+			}
+		}
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	postgresDirective := filepath.Join("testdata", "postgres") + ":" + string(sql.PostgresType)
+
+	t.Run("DrainTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		e := &stuckExporter{exportingCh: make(chan struct{})}
+		deadLetterDirectory := t.TempDir()
+		args := []string{
+			"sql-processor", "process", "-drain-timeout", "50ms", "-dead-letter-dir", deadLetterDirectory,
+			postgresDirective,
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- cmd.Run(ctx, args, []exporter.Exporter{e})
+		}()
+
+		<-e.exportingCh
+		cancel()
+
+		err := <-errCh
+		if !errors.Is(err, cmd.ErrDrainTimeout) || cmd.ExitCode(err) != cmd.ExitDrainTimeout {
+			t.Fatalf("expected = %v, got = %v", cmd.ErrDrainTimeout, err)
+		}
+
+		deadLetters, err := exporter.NewDeadLetterQueue(deadLetterDirectory).Read("")
+		if err != nil {
+			t.Fatalf("failed reading dead letters: %v", err)
+		}
+
+		if len(deadLetters) == 0 {
+			t.Fatalf("expected dead letters of the aborted exports")
+		}
+	})
+
+	t.Run("WatchDrainTimeout", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		copyFiles(t, directory, []string{filepath.Join("testdata", "postgres", "test-select.sql")})
+		watched := t.TempDir() + ":" + string(sql.PostgresType)
+		checkpoint := writeCheckpoint(t, directory, watched, filepath.Join(directory, "test-select.sql"))
+
+		e := &closingExporter{stuckExporter: &stuckExporter{exportingCh: make(chan struct{})}}
+		registry := exporter.NewRegistry()
+		exporter.Register(registry, "closing", func(struct{}) (exporter.Exporter, error) {
+			return e, nil
+		})
+		args := []string{
+			"-exporter", "closing", "-drain-timeout", "50ms", "-checkpoint", checkpoint,
+			"-dead-letter-dir", t.TempDir(), watched,
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- cmd.RunWatch(ctx, registry, args)
+		}()
+
+		<-e.exportingCh
+		cancel()
+
+		err := <-errCh
+		if !errors.Is(err, cmd.ErrDrainTimeout) {
+			t.Fatalf("expected = %v, got = %v", cmd.ErrDrainTimeout, err)
+		}
+
+		if closed := e.closed.Load(); closed != 1 {
+			t.Fatalf("expected = %d closes, got = %d", 1, closed)
+		}
+	})
+
+	t.Run("ResumeCheckpoint", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		watched := filepath.Join(directory, "watched")
+		createDirectory(t, watched)
+		copyFiles(t, directory, []string{filepath.Join("testdata", "postgres", "test-select.sql")})
+
+		file := filepath.Join(directory, "test-select.sql")
+		directive := watched + ":" + string(sql.PostgresType)
+		checkpoint := writeCheckpoint(t, directory, directive, file, filepath.Join(directory, "deleted.sql"))
+
+		e := testexporter.New()
+		args := []string{
+			"sql-processor", "watch", "-checkpoint", checkpoint, "-dead-letter-dir", t.TempDir(), directive,
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- cmd.Run(ctx, args, []exporter.Exporter{e})
+		}()
+
+		deadline := time.Now().Add(3 * time.Second)
+		for len(e.Statements()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		cancel()
+		err := <-errCh
+		if err != nil {
+			t.Fatalf("failed watching: %v", err)
+		}
+
+		statements := e.Statements()
+		if len(statements) == 0 || statements[0].File.Path != file {
+			t.Fatalf("expected statements of the checkpointed file: got = %v", statements)
+		}
+
+		_, err = os.Stat(checkpoint)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected the checkpoint to be removed: got = %v", err)
+		}
+	})
+
+	t.Run("CheckpointOfOtherDirectories", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		copyFiles(t, directory, []string{filepath.Join("testdata", "postgres", "test-select.sql")})
+		other := t.TempDir() + ":" + string(sql.PostgresType)
+		checkpoint := writeCheckpoint(t, directory, other, filepath.Join(directory, "test-select.sql"))
+
+		e := testexporter.New()
+		args := []string{
+			"sql-processor", "watch", "-checkpoint", checkpoint, "-dead-letter-dir", t.TempDir(),
+			t.TempDir() + ":" + string(sql.PostgresType),
+		}
+		err := cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if !errors.Is(err, cmd.ErrCheckpointMismatch) {
+			t.Fatalf("expected = %v, got = %v", cmd.ErrCheckpointMismatch, err)
+		}
+
+		_, err = os.Stat(checkpoint)
+		if err != nil || len(e.Statements()) != 0 {
+			t.Fatalf("expected the checkpoint to be left unresumed: got = %v, %v", err, e.Statements())
+		}
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	address := freeAddress(t)
+	args := []string{
+		"sql-processor", "watch", "-admin-addr", address, "-checkpoint", filepath.Join(directory, "checkpoint.jsonl"),
+		"-dead-letter-dir", t.TempDir(), directory + ":" + string(sql.PostgresType),
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cmd.Run(ctx, args, []exporter.Exporter{testexporter.New()})
+	}()
+
+	var (
+		status   admin.Status
+		response *http.Response
+		err      error
+	)
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		var request *http.Request
+		request, err = http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+address+"/status", nil)
+		if err != nil {
+			t.Fatalf("failed creating request: %v", err)
+		}
+
+		response, err = http.DefaultClient.Do(request)
+		if err == nil {
+			err = json.NewDecoder(response.Body).Decode(&status)
+			_ = response.Body.Close()
+		}
+
+		// The pipeline gets ready once the exporter manager starts.
+		if err == nil && status.Ready {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatalf("failed requesting status: %v", err)
+	}
+
+	if !status.Ready || len(status.Directories) != 1 || len(status.Channels) != 2 || len(status.Exporters) != 1 {
+		t.Fatalf("unexpected status: got = %+v", status)
+	}
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodPut, "http://"+address+"/loglevel",
+		strings.NewReader(`{"component": "observer", "level": "debug"}`))
+	if err != nil {
+		t.Fatalf("failed creating request: %v", err)
+	}
+
+	response, err = http.DefaultClient.Do(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("failed changing log level: %v", err)
+	}
+
+	_ = response.Body.Close()
+	request, err = http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+address+"/metrics", nil)
+	if err != nil {
+		t.Fatalf("failed creating request: %v", err)
+	}
+
+	response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("failed requesting metrics: %v", err)
+	}
+
+	metrics, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil || !strings.Contains(string(metrics), "sql_processor_observer_pending_files 0") {
+		t.Fatalf("unexpected metrics: got = %s (%v)", metrics, err)
+	}
+
+	cancel()
+	err = <-errCh
+	if err != nil {
+		t.Fatalf("failed watching: %v", err)
+	}
+}
+
+// freeAddress returns a local address with a free port.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	var config net.ListenConfig
+	listener, err := config.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed listening: %v", err)
+	}
+
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	return listener.Addr().String()
+}
+
+// stuckExporter blocks exports until they get cancelled.
+type stuckExporter struct {
+	exportingCh chan struct{}
+	once        sync.Once
+}
+
+func (e *stuckExporter) Export(statement sql.Statement) error {
+	return e.ExportContext(context.Background(), statement)
+}
+
+func (e *stuckExporter) ExportBatch(statements []sql.Statement) error {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+func (e *stuckExporter) ExportContext(ctx context.Context, statement sql.Statement) error {
+	return e.ExportBatchContext(ctx, []sql.Statement{statement})
+}
+
+func (e *stuckExporter) ExportBatchContext(ctx context.Context, _ []sql.Statement) error {
+	e.once.Do(func() {
+		close(e.exportingCh)
+	})
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+var errClosedTwice = errors.New("closed twice")
+
+// closingExporter is a [stuckExporter] counting its closes. Closing it again fails.
+type closingExporter struct {
+	*stuckExporter
+
+	closed atomic.Int32
+}
+
+func (e *closingExporter) Close() error {
+	if e.closed.Add(1) > 1 {
+		return errClosedTwice
+	}
+
+	return nil
+}
+
+// writeCheckpoint writes a checkpoint of the postgres files written by a run watching the directive.
+func writeCheckpoint(t *testing.T, directory string, directive string, files ...string) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string][]string{"directories": {directive}})
+	if err != nil {
+		t.Fatalf("failed encoding checkpoint header: %v", err)
+	}
+
+	content := string(header) + "\n"
+	for _, file := range files {
+		content += `{"path":"` + file + `","type":"postgres"}` + "\n"
+	}
+
+	path := filepath.Join(directory, "checkpoint.jsonl")
+	err = os.WriteFile(path, []byte(content), filePermissions)
+	if err != nil {
+		t.Fatalf("failed writing checkpoint: %v", err)
+	}
+
+	return path
+}
+
+func writeConfig(t *testing.T, directory string, content string) string {
+	t.Helper()
+
+	file, err := os.CreateTemp(directory, "config-*.yaml")
+	if err != nil {
+		t.Fatalf("failed creating config: %v", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = file.WriteString(content)
+	if err != nil {
+		t.Fatalf("failed writing config: %v", err)
+	}
+
+	return file.Name()
+}
+
 func createDirectory(t *testing.T, path string) {
 	t.Helper()
 
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+)
+
+const (
+	deadLetterCommand       = "dlq"
+	deadLetterReplayCommand = "replay"
+)
+
+var ErrUnknownExporter = errors.New("unknown exporter")
+
+// runDeadLetters runs the dead letter queue commands.
+//
+// The only command is "replay" which re-sends dead letters to one of the exporters:
+//
+//	sql-processor dlq replay [-config FILE] [-dir DIRECTORY] [-from EXPORTER] [-to EXPORTER] [-exporter SPEC]...
+//
+// The exporters and the dead letter directory of the -config file are used unless given by flags,
+// so the exporters can be chosen by the names their dead letters are stored under.
+func runDeadLetters(ctx context.Context, args []string, exporters []exporter.Exporter) error {
+	if len(args) == 0 || args[0] != deadLetterReplayCommand {
+		return fmt.Errorf("%w: %w: expected %s %s",
+			ErrUsage, ErrUnknownCommand, deadLetterCommand, deadLetterReplayCommand)
+	}
+
+	flags := newFlagSet(deadLetterCommand+" "+deadLetterReplayCommand, "",
+		"Replays dead letters to an exporter and removes them once replayed.")
+	logFlags := addLogFlags(flags)
+	configPath := flags.String("config", "", "configuration file whose exporters and dead letter directory are used")
+	directory := flags.String("dir", defaultDeadLetterDirectory(), "dead letter directory")
+	from := flags.String("from", "", "replay only dead letters of the named exporter")
+	to := flags.String("to", "", "name of the exporter to replay to, required with multiple exporters")
+	registry := newRegistry()
+	specs := exporterSpecsFlag(flags, registry)
+
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 0 {
+		return fmt.Errorf("%w: unexpected arguments: %v", ErrUsage, positional)
+	}
+
+	c, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	dirSet := false
+	flags.Visit(func(flag *flag.Flag) {
+		if flag.Name == "dir" {
+			dirSet = true
+		}
+	})
+
+	if !dirSet && c.DeadLetterDirectory != "" {
+		*directory = c.DeadLetterDirectory
+	}
+
+	logger, err := logFlags.logger()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = logger.Close()
+	}()
+
+	var names []string
+	switch {
+	case len(*specs) == 0 && len(c.Exporters) > 0:
+		exporters, _, err = c.CreateExporters(registry)
+		if err == nil {
+			defer closeExporters(exporters)
+		}
+
+		for _, e := range c.Exporters {
+			names = append(names, e.Name)
+		}
+	default:
+		exporters, err = createExporters(registry, *specs, exporters)
+		if err == nil && len(*specs) > 0 {
+			defer closeExporters(exporters)
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: invalid exporters: %w", ErrUsage, err)
+	}
+
+	target, err := findExporter(exporters, names, *to)
+	if err != nil {
+		return err
+	}
+
+	queue := exporter.NewDeadLetterQueue(*directory)
+	replayed, err := queue.Replay(ctx, *from, target)
+	logger.Info("replayed dead letters",
+		"statements", replayed,
+		"exporter", exporter.Name(target),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed replaying dead letters: %w", err)
+	}
+
+	return nil
+}
+
+// findExporter finds the exporter of the name. The exporters are named by names, if any, or by [exporter.Name].
+func findExporter(exporters []exporter.Exporter, names []string, name string) (exporter.Exporter, error) {
+	if name == "" {
+		if len(exporters) != 1 {
+			return nil, fmt.Errorf("%w: %w: choose one of %d exporters by name",
+				ErrUsage, ErrUnknownExporter, len(exporters))
+		}
+
+		return exporters[0], nil
+	}
+
+	for i, e := range exporters {
+		if (len(names) > 0 && names[i] == name) || (len(names) == 0 && exporter.Name(e) == name) {
+			return e, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %w: %s", ErrUsage, ErrUnknownExporter, name)
+}
@@ -0,0 +1,178 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/cmd"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+)
+
+func TestDeadLetterReplay(t *testing.T) {
+	t.Parallel()
+
+	statements := []sql.Statement{
+		{
+			File:    sql.File{Path: "test.sql", Type: sql.MySQL},
+			Content: "SELECT * FROM users",
+			LineNum: 1,
+		},
+	}
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		t.Parallel()
+
+		err := cmd.Run(t.Context(), []string{"sql-processor", "dlq", "unknown"}, nil)
+		if !errors.Is(err, cmd.ErrUnknownCommand) {
+			t.Fatalf("expected unknown command error: got = %v", err)
+		}
+	})
+
+	t.Run("UnknownExporter", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"sql-processor", "dlq", "replay", "-dir", t.TempDir(), "-to", "unknown"}
+		err := cmd.Run(t.Context(), args, []exporter.Exporter{testexporter.New()})
+		if !errors.Is(err, cmd.ErrUnknownExporter) {
+			t.Fatalf("expected unknown exporter error: got = %v", err)
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		err := exporter.NewDeadLetterQueue(directory).Write("stdout", statements, errors.New("broken pipe"))
+		if err != nil {
+			t.Fatalf("failed writing dead letters: %v", err)
+		}
+
+		e := testexporter.New()
+		args := []string{"sql-processor", "dlq", "replay", "-dir", directory, "-from", "stdout"}
+		err = cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if err != nil {
+			t.Fatalf("failed replaying dead letters: %v", err)
+		}
+
+		if len(e.Statements()) != len(statements) || e.Statements()[0] != statements[0] {
+			t.Fatalf("replayed statements do not match: expected = %v, got = %v", statements, e.Statements())
+		}
+	})
+
+	t.Run("ReplayToExporterSpec", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		err := exporter.NewDeadLetterQueue(directory).Write("stdout", statements, errors.New("broken pipe"))
+		if err != nil {
+			t.Fatalf("failed writing dead letters: %v", err)
+		}
+
+		path := filepath.Join(t.TempDir(), "replayed.jsonl")
+		args := []string{"sql-processor", "dlq", "replay", "-dir", directory, "-exporter", "jsonl:path=" + path}
+		err = cmd.Run(t.Context(), args, nil)
+		if err != nil {
+			t.Fatalf("failed replaying dead letters: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed reading replayed statements: %v", err)
+		}
+
+		var got sql.Statement
+		err = json.Unmarshal(data, &got)
+		if err != nil || got != statements[0] {
+			t.Fatalf("replayed statements do not match: expected = %v, got = %s", statements, data)
+		}
+	})
+
+	t.Run("ReplayToConfigExporter", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		deadLetters := filepath.Join(directory, "dead-letters")
+		err := exporter.NewDeadLetterQueue(deadLetters).Write("archive", statements, errors.New("disk full"))
+		if err != nil {
+			t.Fatalf("failed writing dead letters: %v", err)
+		}
+
+		archive := filepath.Join(directory, "archive.jsonl")
+		path := filepath.Join(directory, "audit.jsonl")
+		config := writeConfig(t, directory, "dead_letter_dir: "+deadLetters+"\n"+
+			"exporters:\n"+
+			"  - name: archive\n    type: jsonl\n    settings:\n      path: "+archive+"\n"+
+			"  - name: audit\n    type: jsonl\n    settings:\n      path: "+path+"\n")
+		args := []string{"sql-processor", "dlq", "replay", "-config", config, "-from", "archive", "-to", "audit"}
+		err = cmd.Run(t.Context(), args, nil)
+		if err != nil {
+			t.Fatalf("failed replaying dead letters: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed reading replayed statements: %v", err)
+		}
+
+		var got sql.Statement
+		err = json.Unmarshal(data, &got)
+		if err != nil || got != statements[0] {
+			t.Fatalf("replayed statements do not match: expected = %v, got = %s", statements, data)
+		}
+	})
+
+	t.Run("ReplayToStartedExporter", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		err := exporter.NewDeadLetterQueue(directory).Write("stdout", statements, errors.New("broken pipe"))
+		if err != nil {
+			t.Fatalf("failed writing dead letters: %v", err)
+		}
+
+		e := &startedExporter{Exporter: testexporter.New()}
+		args := []string{"sql-processor", "dlq", "replay", "-dir", directory}
+		err = cmd.Run(t.Context(), args, []exporter.Exporter{e})
+		if err != nil {
+			t.Fatalf("failed replaying dead letters: %v", err)
+		}
+
+		if len(e.Statements()) != len(statements) || !e.flushed {
+			t.Fatalf("expected statements to be exported and flushed: got = %v, %v", e.Statements(), e.flushed)
+		}
+	})
+}
+
+var errNotStarted = errors.New("not started")
+
+// startedExporter rejects statements until it is started.
+type startedExporter struct {
+	*testexporter.Exporter
+
+	started bool
+	flushed bool
+}
+
+func (e *startedExporter) Start(_ context.Context) error {
+	e.started = true
+	return nil
+}
+
+func (e *startedExporter) ExportBatch(statements []sql.Statement) error {
+	if !e.started {
+		return errNotStarted
+	}
+
+	return e.Exporter.ExportBatch(statements)
+}
+
+func (e *startedExporter) Flush(_ context.Context) error {
+	e.flushed = true
+	return nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"log/slog"
+
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/processor"
+)
+
+// disposer applies the dispositions of the directories to their successfully processed files.
+type disposer struct {
+	logger    *slog.Logger
+	directory func(path string) (observer.Directory, bool)
+}
+
+func newDisposer(logger *slog.Logger, directory func(path string) (observer.Directory, bool)) *disposer {
+	return &disposer{
+		logger:    logger,
+		directory: directory,
+	}
+}
+
+// dispose is a [processor.WithResultHandler] handler. Failed files are kept in place.
+func (d *disposer) dispose(result processor.Result) {
+	directory, ok := d.directory(result.File.Path)
+	if result.Err != nil || !ok {
+		return
+	}
+
+	err := directory.Disposition.Apply(result.File.Path)
+	if err != nil {
+		d.logger.Error("failed applying file disposition",
+			"path", result.File.Path,
+			"disposition", directory.Disposition,
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,12 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+)
+
+// RunWatch runs the watch command with exporters created from the given registry only.
+func RunWatch(ctx context.Context, registry *exporter.Registry, args []string) error {
+	return runWatch(ctx, registry, args, nil)
+}
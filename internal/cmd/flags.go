@@ -0,0 +1,340 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/config"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/retry"
+	"github.com/course-go/sql-processor/internal/logging"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/tracing"
+)
+
+// logFlags are the logging flags shared by all commands.
+type logFlags struct {
+	level      slog.Level
+	format     string
+	file       string
+	components map[string]slog.Level
+}
+
+func addLogFlags(flags *flag.FlagSet) *logFlags {
+	f := &logFlags{components: make(map[string]slog.Level)}
+	flags.TextVar(&f.level, "log-level", slog.LevelInfo, "minimum log level: debug, info, warn or error")
+	flags.StringVar(&f.format, "log-format", config.TextLogFormat, "log format: text or json")
+	flags.StringVar(&f.file, "log-file", "", "file logs are appended to instead of stderr")
+	flags.Func("log-component",
+		"minimum log level of a component COMPONENT=LEVEL, such as observer=debug, may be repeated",
+		func(value string) error {
+			component, level, err := logging.ParseComponentLevel(value)
+			if err != nil {
+				return err
+			}
+
+			f.components[component] = level
+			return nil
+		},
+	)
+
+	return f
+}
+
+// logger creates the logger of the flags. It has to be closed.
+func (f *logFlags) logger() (*logging.Logger, error) {
+	return newLogger(logging.Config{
+		Level:      f.level,
+		Components: f.components,
+		Format:     f.format,
+		File:       f.file,
+	})
+}
+
+func newLogger(config logging.Config) (*logging.Logger, error) {
+	logger, err := logging.New(config)
+	if errors.Is(err, logging.ErrUnknownFormat) {
+		return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed creating logger: %w", err)
+	}
+
+	return logger, nil
+}
+
+// pipelineFlags are the flags of the commands running the processing pipeline.
+type pipelineFlags struct {
+	*logFlags
+
+	flags               *flag.FlagSet
+	configPath          string
+	workers             int
+	exporters           *[]string
+	deadLetterDirectory string
+	drainTimeout        time.Duration
+	checkpointPath      string
+	adminAddress        string
+	otlpEndpoint        string
+	reportPath          string
+	queueSize           int
+	overflow            string
+	spillDirectory      string
+	retryAttempts       int
+}
+
+func addPipelineFlags(flags *flag.FlagSet, registry *exporter.Registry) *pipelineFlags {
+	f := &pipelineFlags{
+		logFlags:  addLogFlags(flags),
+		flags:     flags,
+		exporters: exporterSpecsFlag(flags, registry),
+	}
+	flags.StringVar(&f.configPath, "config", "", "configuration file; flags and arguments override its values")
+	flags.IntVar(&f.workers, "workers", 1, "number of files processed concurrently")
+	flags.StringVar(&f.deadLetterDirectory, "dead-letter-dir", defaultDeadLetterDirectory(),
+		"directory statements that failed to export are written to")
+	flags.DurationVar(&f.drainTimeout, "drain-timeout", defaultDrainTimeout,
+		"time given to finish the accepted work on SIGINT or SIGTERM")
+	flags.StringVar(&f.checkpointPath, "checkpoint", "",
+		"file the files left unprocessed by a shutdown are written to and resumed from by the watch command "+
+			"of the same directories; disabled when empty")
+	flags.StringVar(&f.adminAddress, "admin-addr", "",
+		"address of the admin HTTP server with /healthz, /readyz, /status, /loglevel and /metrics, such as :9090")
+	flags.StringVar(&f.otlpEndpoint, "otlp-endpoint", "",
+		"URL of the OTLP/gRPC endpoint the traces are exported to, such as http://localhost:4317")
+	flags.StringVar(&f.reportPath, "report", "",
+		"JSON file the summary of the run is written to on exit and on SIGUSR1")
+	flags.IntVar(&f.queueSize, "queue-size", exporter.DefaultQueueConfig().Size,
+		"capacity of each exporter queue in batches")
+	flags.StringVar(&f.overflow, "overflow", string(exporter.OverflowBlock),
+		"what happens to a batch when an exporter queue is full: block, drop-oldest, drop-newest or spill")
+	flags.StringVar(&f.spillDirectory, "spill-dir", defaultSpillDirectory(),
+		"directory batches are spilled to by -overflow spill, each exporter to its own subdirectory")
+	flags.IntVar(&f.retryAttempts, "retry-attempts", 1,
+		"attempts of each export of the -exporter exporters; more than 1 retries failed exports with backoff")
+	return f
+}
+
+// pipeline is the configuration of the processing pipeline merged from the configuration file and flags.
+type pipeline struct {
+	logger              *slog.Logger
+	log                 *logging.Logger
+	directories         []observer.Directory
+	exporters           []exporter.Exporter
+	managerOptions      []exporter.Option
+	workers             int
+	deadLetterDirectory string
+	drainTimeout        time.Duration
+	checkpointPath      string
+	adminAddress        string
+	otlpEndpoint        string
+	reportPath          string
+	// owned reports whether the exporters were created by the command rather than passed to [Run].
+	owned bool
+}
+
+// setup merges the configuration file with the flags and creates the logger and the exporters.
+//
+// Flags set on the command line and non-empty directories override the file values.
+// Exporters given by flags replace the ones of the file, which replace the defaults.
+func (f *pipelineFlags) setup(
+	registry *exporter.Registry,
+	defaults []exporter.Exporter,
+	directories []observer.Directory,
+) (*pipeline, error) {
+	c, err := loadConfig(f.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	f.flags.Visit(func(flag *flag.Flag) {
+		set[flag.Name] = true
+	})
+
+	p := &pipeline{
+		directories:         directories,
+		workers:             f.workers,
+		deadLetterDirectory: f.deadLetterDirectory,
+		drainTimeout:        f.drainTimeout,
+		checkpointPath:      f.checkpointPath,
+		adminAddress:        f.adminAddress,
+		otlpEndpoint:        f.otlpEndpoint,
+		reportPath:          f.reportPath,
+	}
+	if len(p.directories) == 0 {
+		p.directories = c.Directories
+	}
+
+	if !set["workers"] && c.Processor.Workers > 0 {
+		p.workers = c.Processor.Workers
+	}
+
+	if !set["dead-letter-dir"] && c.DeadLetterDirectory != "" {
+		p.deadLetterDirectory = c.DeadLetterDirectory
+	}
+
+	if !set["drain-timeout"] && c.Shutdown.DrainTimeout > 0 {
+		p.drainTimeout = c.Shutdown.DrainTimeout
+	}
+
+	if !set["checkpoint"] && c.Shutdown.Checkpoint != "" {
+		p.checkpointPath = c.Shutdown.Checkpoint
+	}
+
+	if !set["admin-addr"] && c.Admin.Address != "" {
+		p.adminAddress = c.Admin.Address
+	}
+
+	if !set["otlp-endpoint"] && c.Tracing.Endpoint != "" {
+		p.otlpEndpoint = c.Tracing.Endpoint
+	}
+
+	if !set["report"] && c.Report != "" {
+		p.reportPath = c.Report
+	}
+
+	batch := exporter.DefaultBatchConfig()
+	if c.Batch.MaxCount > 0 {
+		batch.MaxCount = c.Batch.MaxCount
+	}
+
+	if c.Batch.MaxBytes > 0 {
+		batch.MaxBytes = c.Batch.MaxBytes
+	}
+
+	batch.MaxLinger = c.Batch.MaxLinger
+	batch.SplitOnFile = c.Batch.SplitOnFile
+
+	queue := exporter.QueueConfig{
+		Size:           f.queueSize,
+		Overflow:       exporter.OverflowPolicy(f.overflow),
+		SpillDirectory: f.spillDirectory,
+	}
+	if !set["queue-size"] && c.Queue.Size > 0 {
+		queue.Size = c.Queue.Size
+	}
+
+	if !set["overflow"] && c.Queue.Overflow != "" {
+		queue.Overflow = c.Queue.Overflow
+	}
+
+	if !set["spill-dir"] && c.Queue.SpillDirectory != "" {
+		queue.SpillDirectory = c.Queue.SpillDirectory
+	}
+
+	log := logging.Config{Level: f.level, Format: f.format, File: f.file, Components: c.Log.Components}
+	if !set["log-level"] && c.Log.Level != nil {
+		log.Level = *c.Log.Level
+	}
+
+	if !set["log-format"] && c.Log.Format != "" {
+		log.Format = c.Log.Format
+	}
+
+	if !set["log-file"] && c.Log.File != "" {
+		log.File = c.Log.File
+	}
+
+	if len(f.components) > 0 {
+		log.Components = maps.Clone(c.Log.Components)
+		if log.Components == nil {
+			log.Components = make(map[string]slog.Level, len(f.components))
+		}
+
+		maps.Copy(log.Components, f.components)
+	}
+
+	if p.workers < 1 {
+		return nil, fmt.Errorf("%w: workers must be positive, got %d", ErrUsage, p.workers)
+	}
+
+	if p.drainTimeout <= 0 {
+		return nil, fmt.Errorf("%w: drain timeout must be positive, got %s", ErrUsage, p.drainTimeout)
+	}
+
+	if f.retryAttempts < 1 {
+		return nil, fmt.Errorf("%w: retry attempts must be positive, got %d", ErrUsage, f.retryAttempts)
+	}
+
+	if queue.Size < 1 {
+		return nil, fmt.Errorf("%w: queue size must be positive, got %d", ErrUsage, queue.Size)
+	}
+
+	_, err = exporter.ParseOverflowPolicy(string(queue.Overflow))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	if p.otlpEndpoint != "" {
+		_, err := tracing.ParseEndpoint(p.otlpEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUsage, err)
+		}
+	}
+
+	p.log, err = newLogger(log)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger = p.log.Logger
+
+	switch {
+	case len(*f.exporters) == 0 && len(c.Exporters) > 0:
+		p.exporters, p.managerOptions, err = c.CreateExporters(registry)
+		p.owned = true
+	default:
+		p.exporters, err = createExporters(registry, *f.exporters, defaults)
+		p.owned = len(*f.exporters) > 0 || len(defaults) == 0
+		if err == nil && len(*f.exporters) > 0 && f.retryAttempts > 1 {
+			config := retry.DefaultConfig()
+			config.MaxAttempts = f.retryAttempts
+			for i, e := range p.exporters {
+				p.exporters[i] = retry.NewExporter(e, config)
+			}
+		}
+	}
+
+	if err != nil {
+		_ = p.log.Close()
+		return nil, fmt.Errorf("%w: invalid exporters: %w", ErrUsage, err)
+	}
+
+	p.managerOptions = append(p.managerOptions,
+		exporter.WithBatchConfig(batch),
+		exporter.WithDefaultQueueConfig(queue),
+	)
+	return p, nil
+}
+
+// loadConfig loads the configuration file of the path. It returns an empty configuration when the path is empty.
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		return &config.Config{}, nil
+	}
+
+	c, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid config: %w", ErrUsage, err)
+	}
+
+	return c, nil
+}
+
+// close closes the exporters owned by the command. It is used when the pipeline fails to start.
+func (p *pipeline) close() {
+	if p.owned {
+		closeExporters(p.exporters)
+	}
+}
+
+// closeLog closes the log file, if any. It is called once the command finishes.
+func (p *pipeline) closeLog() {
+	_ = p.log.Close()
+}
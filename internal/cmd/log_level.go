@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/logging"
+)
+
+// watchLevelSignal toggles the default log level between debug and the configured level
+// on each of the [levelSignals] until the returned function is called.
+// Per-component overrides are kept.
+func watchLevelSignal(logger *slog.Logger, levels *logging.Levels) (stop func()) {
+	if len(levelSignals) == 0 {
+		return func() {}
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(signalCh, levelSignals...)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		configured := levels.Level("")
+		for {
+			select {
+			case <-signalCh:
+				level := slog.LevelDebug
+				if levels.Level("") == slog.LevelDebug {
+					level = configured
+				}
+
+				levels.Set("", level)
+				logger.Info("changed log level", "level", level)
+			case <-stopCh:
+				return
+			}
+		}
+	})
+
+	return func() {
+		signal.Stop(signalCh)
+		close(stopCh)
+		wg.Wait()
+	}
+}
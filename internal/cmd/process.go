@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/processor"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const sqlExtension = ".sql"
+
+var (
+	ErrNoInputs         = errors.New("no inputs provided")
+	ErrDuplicateStdin   = errors.New("standard input given more than once")
+	ErrProcessingFailed = errors.New("processing failed")
+)
+
+// runProcess runs the process command:
+//
+//	sql-processor process [FLAGS] INPUT...
+func runProcess(ctx context.Context, args []string, exporters []exporter.Exporter) error {
+	registry := newRegistry()
+	flags := newFlagSet(processCommand, "[INPUT...]",
+		"Processes the files given by PATH:DIALECT inputs and exits once all statements are exported.\n"+
+			"Directories are walked recursively for *.sql files. The -:DIALECT input reads the standard input.\n"+
+			"Without inputs, the directories of the -config file are processed.\n"+
+			"A summary is printed to stderr on exit and on SIGUSR1, and written to the -report file, if any.\n"+
+			"The exit code is non-zero when any file failed to process or export.")
+	pipelineFlags := addPipelineFlags(flags, registry)
+	inputs, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	directories, err := parseDirectories(inputs, true)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	p, err := pipelineFlags.setup(registry, exporters, directories)
+	if err != nil {
+		return err
+	}
+
+	defer p.closeLog()
+
+	files, fileDirectories, err := collectFiles(p.directories)
+	if err != nil {
+		p.close()
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	disposer := newDisposer(p.logger, func(path string) (observer.Directory, bool) {
+		directory, ok := fileDirectories[path]
+		return directory, ok
+	})
+
+	return process(ctx, p, os.Stderr, files, disposer)
+}
+
+// process passes the files through the pipeline and returns once all their statements are exported.
+// It writes the summary of the run to w, and to the report file if any,
+// and fails when any file was not processed or exported.
+//
+// Once shut down, no more files are passed and the files being processed are finished
+// and their statements exported within the drain timeout.
+func process(ctx context.Context, p *pipeline, w io.Writer, files []sql.File, disposer *disposer) error {
+	fileCh := make(chan sql.File, channelBufferSize)
+	statementCh := make(chan sql.Statement, channelBufferSize)
+
+	tracerProvider, stopTracing, err := startTracing(ctx, p)
+	if err != nil {
+		p.close()
+		return err
+	}
+
+	defer stopTracing()
+
+	metrics := newPipelineMetrics()
+	s := newSummary(disposer.directory, len(files))
+	pr := processor.New(p.logger, fileCh, statementCh,
+		processor.WithWorkers(p.workers),
+		processor.WithMetrics(metrics.processor),
+		processor.WithTracerProvider(tracerProvider),
+		processor.WithResultHandler(func(result processor.Result) {
+			s.add(result)
+			disposer.dispose(result)
+		}),
+	)
+
+	deadLetterQueue := exporter.NewDeadLetterQueue(p.deadLetterDirectory)
+	opts := append([]exporter.Option{
+		exporter.WithDeadLetterQueue(deadLetterQueue),
+		exporter.WithMetrics(metrics.exporter),
+		exporter.WithTracerProvider(tracerProvider),
+	}, p.managerOptions...)
+	m, err := exporter.NewManager(p.logger, statementCh, p.exporters, opts...)
+	if err != nil {
+		p.close()
+		return fmt.Errorf("failed creating exporter manager: %w", err)
+	}
+
+	p.logger.Info("processing files", "files", len(files), "dead-letters", deadLetterQueue.Directory())
+
+	sh := startShutdown(ctx, p.logger, p.drainTimeout)
+	defer sh.stop()
+
+	stopAdmin, err := serveAdmin(ctx, p, &pipelineStatus{
+		directories: p.directories,
+		processor:   &pr,
+		manager:     &m,
+		fileCh:      fileCh,
+		statementCh: statementCh,
+		shutdown:    sh,
+		metrics:     metrics,
+	})
+	if err != nil {
+		p.close()
+		return err
+	}
+
+	defer stopAdmin()
+
+	stopLevelSignal := watchLevelSignal(p.logger, p.log.Levels)
+	defer stopLevelSignal()
+
+	r := &reporter{logger: p.logger, summary: s, processor: &pr, manager: &m, w: w, path: p.reportPath}
+	stopReportSignal := watchReportSignal(r)
+	defer stopReportSignal()
+
+	stopProcessor := context.AfterFunc(sh.intake, pr.Stop)
+	defer stopProcessor()
+
+	abortExports := context.AfterFunc(sh.drain, m.Abort)
+	defer abortExports()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		defer close(fileCh)
+
+		for _, file := range files {
+			select {
+			case fileCh <- file:
+			case <-sh.intake.Done():
+				return
+			}
+		}
+	})
+	wg.Go(func() {
+		defer close(statementCh)
+
+		pr.Run(sh.drain)
+	})
+	// The manager exports statements until the processor is done.
+	wg.Go(func() { m.Run(context.WithoutCancel(ctx)) })
+	wg.Wait()
+
+	stopReportSignal()
+	rep := r.report()
+	if sh.timedOut() {
+		return errors.Join(fmt.Errorf("%w: %s", ErrDrainTimeout, p.drainTimeout), rep.err())
+	}
+
+	return rep.err()
+}
+
+// collectFiles resolves the directories to files in a stable order and maps each file to its directory.
+//
+// Directory paths may also point to files, which are collected regardless of the directory patterns.
+// Directories are walked recursively when configured so and only their files matching
+// the directory patterns are collected, by default the ones with the .sql extension.
+// The "-" path stands for the standard input and resolves to a file with [sql.StdinPath].
+func collectFiles(directories []observer.Directory) ([]sql.File, map[string]observer.Directory, error) {
+	if len(directories) == 0 {
+		return nil, nil, ErrNoInputs
+	}
+
+	var (
+		files []sql.File
+		stdin bool
+	)
+	fileDirectories := make(map[string]observer.Directory)
+	for _, directory := range directories {
+		if directory.Path == stdinInput {
+			if stdin {
+				return nil, nil, ErrDuplicateStdin
+			}
+
+			stdin = true
+			files = append(files, sql.File{Path: sql.StdinPath, Type: directory.Type})
+			continue
+		}
+
+		info, err := os.Stat(directory.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid input %s: %w", directory.Path, err)
+		}
+
+		if !info.IsDir() {
+			files = append(files, sql.File{Path: directory.Path, Type: directory.Type})
+			fileDirectories[directory.Path] = directory
+			continue
+		}
+
+		err = filepath.WalkDir(directory.Path, func(path string, entry fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				return err
+			case entry.IsDir() && path != directory.Path && !directory.Recursive:
+				return filepath.SkipDir
+			case !entry.Type().IsRegular() || !directory.Matches(path):
+				return nil
+			case len(directory.Include) == 0 && filepath.Ext(path) != sqlExtension:
+				return nil
+			}
+
+			files = append(files, sql.File{Path: path, Type: directory.Type})
+			fileDirectories[path] = directory
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed walking input %s: %w", directory.Path, err)
+		}
+	}
+
+	return files, fileDirectories, nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"strings"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/broker"
+	"github.com/course-go/sql-processor/internal/exporter/database"
+	"github.com/course-go/sql-processor/internal/exporter/grpc"
+	"github.com/course-go/sql-processor/internal/exporter/journald"
+	"github.com/course-go/sql-processor/internal/exporter/jsonl"
+	"github.com/course-go/sql-processor/internal/exporter/live"
+	"github.com/course-go/sql-processor/internal/exporter/plugin"
+	"github.com/course-go/sql-processor/internal/exporter/stdout"
+	"github.com/course-go/sql-processor/internal/exporter/syslog"
+)
+
+const defaultExporterSpec = "stdout"
+
+// newRegistry creates a registry of all exporter types built into the processor.
+func newRegistry() *exporter.Registry {
+	r := exporter.NewRegistry()
+	broker.Register(r)
+	database.Register(r)
+	grpc.Register(r)
+	journald.Register(r)
+	jsonl.Register(r)
+	live.Register(r)
+	plugin.Register(r)
+	stdout.Register(r)
+	syslog.Register(r)
+	return r
+}
+
+// exporterSpecsFlag registers the repeatable -exporter flag and returns the collected specs.
+func exporterSpecsFlag(flags *flag.FlagSet, registry *exporter.Registry) *[]string {
+	var specs []string
+	flags.Func("exporter",
+		"exporter spec TYPE[:KEY=VALUE,...] with commas in values escaped as \\,, may be repeated; types: "+
+			strings.Join(registry.Names(), ", "),
+		func(spec string) error {
+			specs = append(specs, spec)
+			return nil
+		},
+	)
+
+	return &specs
+}
+
+// createExporters creates exporters from the specs. It returns the defaults when there are no specs
+// and falls back to the stdout exporter when there are no defaults either.
+// All specs are validated and all errors are reported at once.
+func createExporters(
+	registry *exporter.Registry,
+	specs []string,
+	defaults []exporter.Exporter,
+) ([]exporter.Exporter, error) {
+	if len(specs) == 0 && len(defaults) > 0 {
+		return defaults, nil
+	}
+
+	if len(specs) == 0 {
+		specs = []string{defaultExporterSpec}
+	}
+
+	exporters := make([]exporter.Exporter, 0, len(specs))
+	var errs error
+	for _, spec := range specs {
+		e, err := registry.CreateFromSpec(spec)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		exporters = append(exporters, e)
+	}
+
+	if errs != nil {
+		closeExporters(exporters)
+		return nil, errs
+	}
+
+	return exporters, nil
+}
+
+// closeExporters closes exporters which were created but never handed over to the manager.
+func closeExporters(exporters []exporter.Exporter) {
+	for _, e := range exporters {
+		if closer, ok := e.(exporter.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
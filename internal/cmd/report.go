@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/processor"
+)
+
+// reporter reports the [summary] of a running pipeline.
+type reporter struct {
+	logger    *slog.Logger
+	summary   *summary
+	processor *processor.Processor
+	manager   *exporter.Manager
+	w         io.Writer
+	// path is the JSON report file. Empty when no file is written.
+	path string
+}
+
+// report writes the report of the run so far to w and to the JSON report file, if any, and returns it.
+func (r *reporter) report() report {
+	rep := r.summary.report(r.processor.Stats(), r.manager.Stats())
+	rep.write(r.w)
+	if r.path == "" {
+		return rep
+	}
+
+	err := rep.writeFile(r.path)
+	if err != nil {
+		r.logger.Error("failed writing report file", "path", r.path, "error", err)
+	}
+
+	return rep
+}
+
+// watchReportSignal reports the run on each of the [reportSignals] until the returned function is called.
+// The function may be called more than once.
+func watchReportSignal(r *reporter) (stop func()) {
+	if len(reportSignals) == 0 {
+		return func() {}
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	stopCh := make(chan struct{})
+	signal.Notify(signalCh, reportSignals...)
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		for {
+			select {
+			case <-signalCh:
+				r.report()
+			case <-stopCh:
+				return
+			}
+		}
+	})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(signalCh)
+			close(stopCh)
+			wg.Wait()
+		})
+	}
+}
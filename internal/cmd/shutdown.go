@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultDrainTimeout = 30 * time.Second
+
+var ErrDrainTimeout = errors.New("drain timeout elapsed")
+
+// shutdown coordinates the graceful shutdown of the pipeline.
+//
+// The first SIGINT or SIGTERM, or the parent context being done, starts the shutdown
+// by cancelling the intake context, so no new files are accepted. The work already
+// accepted then gets the drain timeout to finish before the drain context is cancelled.
+// A second signal exits the process immediately with [ExitForced].
+type shutdown struct {
+	logger  *slog.Logger
+	timeout time.Duration
+	exit    func(code int)
+
+	intake       context.Context
+	cancelIntake context.CancelFunc
+	drain        context.Context
+	cancelDrain  context.CancelCauseFunc
+
+	signalCh chan os.Signal
+	stopCh   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// startShutdown starts listening for the shutdown signals. The returned shutdown has to be stopped.
+func startShutdown(ctx context.Context, logger *slog.Logger, timeout time.Duration) *shutdown {
+	s := &shutdown{
+		logger:   logger,
+		timeout:  timeout,
+		exit:     os.Exit,
+		signalCh: make(chan os.Signal, 1),
+		stopCh:   make(chan struct{}),
+	}
+	s.intake, s.cancelIntake = context.WithCancel(ctx)
+	s.drain, s.cancelDrain = context.WithCancelCause(context.WithoutCancel(ctx))
+
+	signal.Notify(s.signalCh, os.Interrupt, syscall.SIGTERM)
+	s.wg.Go(func() { s.run(ctx) })
+	return s
+}
+
+func (s *shutdown) run(ctx context.Context) {
+	select {
+	case sig := <-s.signalCh:
+		s.logger.Info("shutting down", "signal", sig, "drain-timeout", s.timeout)
+	case <-ctx.Done():
+		s.logger.Info("shutting down", "drain-timeout", s.timeout)
+	case <-s.stopCh:
+		return
+	}
+
+	s.cancelIntake()
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case sig := <-s.signalCh:
+			s.logger.Warn("forcing exit", "signal", sig)
+			s.exit(ExitForced)
+			return
+		case <-timer.C:
+			s.logger.Warn("drain timeout elapsed, aborting remaining work")
+			s.cancelDrain(ErrDrainTimeout)
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// timedOut reports whether the drain timeout elapsed before the shutdown was stopped.
+func (s *shutdown) timedOut() bool {
+	return errors.Is(context.Cause(s.drain), ErrDrainTimeout)
+}
+
+// stop stops listening for the signals and cancels both contexts.
+func (s *shutdown) stop() {
+	signal.Stop(s.signalCh)
+	close(s.stopCh)
+	s.wg.Wait()
+	s.cancelIntake()
+	s.cancelDrain(context.Canceled)
+}
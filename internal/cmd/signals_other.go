@@ -0,0 +1,13 @@
+//go:build !unix
+
+package cmd
+
+import "os"
+
+// There are no such signals on this platform.
+var (
+	// levelSignals toggle the debug log level.
+	levelSignals []os.Signal
+	// reportSignals report the run so far.
+	reportSignals []os.Signal
+)
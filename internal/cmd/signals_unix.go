@@ -0,0 +1,15 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	// levelSignals toggle the debug log level.
+	levelSignals = []os.Signal{syscall.SIGUSR2}
+	// reportSignals report the run so far.
+	reportSignals = []os.Signal{syscall.SIGUSR1}
+)
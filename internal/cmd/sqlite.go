@@ -0,0 +1,9 @@
+//go:build cgo
+
+package cmd
+
+import (
+	// Registers the "sqlite3" driver of the database exporter. The driver requires cgo,
+	// so builds with CGO_ENABLED=0 have no SQLite driver rather than one failing at runtime.
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/processor"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	// slowestFileCount is the number of the slowest files kept in the summary.
+	slowestFileCount = 5
+	// causeFileCount is the number of example files kept for each error cause.
+	causeFileCount = 5
+
+	reportFilePermissions = 0o644
+)
+
+var kindOrder = []sql.Kind{sql.QueryKind, sql.DMLKind, sql.DDLKind, sql.DCLKind, sql.TCLKind, sql.OtherKind}
+
+// summary collects the outcome of a run.
+//
+// The results are aggregated as they come so the summary stays small for long-running watches.
+type summary struct {
+	directory func(path string) (observer.Directory, bool)
+	// files is the number of files passed to the pipeline. Zero when not known upfront.
+	files int
+	start time.Time
+
+	mu          sync.Mutex
+	directories map[directoryKey]*directoryReport
+	kinds       map[sql.Kind]uint64
+	causes      map[string]*errorReport
+	slowest     []fileReport
+}
+
+type directoryKey struct {
+	path    string
+	dialect sql.Type
+}
+
+func newSummary(directory func(path string) (observer.Directory, bool), files int) *summary {
+	return &summary{
+		directory:   directory,
+		files:       files,
+		start:       time.Now(),
+		directories: make(map[directoryKey]*directoryReport),
+		kinds:       make(map[sql.Kind]uint64),
+		causes:      make(map[string]*errorReport),
+	}
+}
+
+// add records the result of a processed file. It is safe for concurrent use.
+func (s *summary) add(result processor.Result) {
+	key := directoryKey{path: filepath.Dir(result.File.Path), dialect: result.File.Type}
+	if directory, ok := s.directory(result.File.Path); ok {
+		key.path = directory.Path
+	}
+
+	if result.File.Path == sql.StdinPath {
+		key.path = sql.StdinPath
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	directory, ok := s.directories[key]
+	if !ok {
+		directory = &directoryReport{Directory: key.path, Dialect: key.dialect}
+		s.directories[key] = directory
+	}
+
+	directory.Files++
+	directory.Statements += result.StatementCount
+	for kind, count := range result.Kinds {
+		s.kinds[kind] += uint64(count) //nolint: gosec
+	}
+
+	if result.Err != nil {
+		directory.Failed++
+		s.addCause(result)
+	}
+
+	s.addSlowest(result)
+}
+
+// addCause groups the failed file by the root cause of its error.
+func (s *summary) addCause(result processor.Result) {
+	cause := rootCause(result.Err).Error()
+	report, ok := s.causes[cause]
+	if !ok {
+		report = &errorReport{Cause: cause}
+		s.causes[cause] = report
+	}
+
+	report.Count++
+	if len(report.Files) < causeFileCount {
+		report.Files = append(report.Files, result.File.Path)
+	}
+}
+
+func (s *summary) addSlowest(result processor.Result) {
+	if len(s.slowest) == slowestFileCount && result.Duration <= s.slowest[len(s.slowest)-1].duration {
+		return
+	}
+
+	s.slowest = append(s.slowest, fileReport{
+		Path:            result.File.Path,
+		Dialect:         result.File.Type,
+		Statements:      result.StatementCount,
+		DurationSeconds: result.Duration.Seconds(),
+		duration:        result.Duration,
+	})
+	slices.SortStableFunc(s.slowest, func(a, b fileReport) int {
+		return cmp.Compare(b.duration, a.duration)
+	})
+	s.slowest = s.slowest[:min(len(s.slowest), slowestFileCount)]
+}
+
+// report returns the [report] of the run so far with the given pipeline statistics.
+func (s *summary) report(processorStats processor.Stats, exporterStats []exporter.ExporterStats) report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := report{
+		ElapsedSeconds: time.Since(s.start).Seconds(),
+		Files: fileCounts{
+			Total:     s.files,
+			Processed: processorStats.ProcessedCount,
+			Failed:    processorStats.FailedCount,
+		},
+		Statements: statementCounts{
+			Total: processorStats.StatementCount,
+			Kinds: maps.Clone(s.kinds),
+		},
+		Directories: make([]directoryReport, 0, len(s.directories)),
+		Errors:      make([]errorReport, 0, len(s.causes)),
+		Slowest:     slices.Clone(s.slowest),
+		Exporters:   make([]exporterReport, 0, len(exporterStats)),
+	}
+	if s.files > 0 {
+		r.Files.Skipped = s.files - int(processorStats.ProcessedCount) //nolint: gosec
+	}
+
+	for _, directory := range s.directories {
+		r.Directories = append(r.Directories, *directory)
+	}
+
+	slices.SortFunc(r.Directories, func(a, b directoryReport) int {
+		return cmp.Or(cmp.Compare(a.Directory, b.Directory), cmp.Compare(a.Dialect, b.Dialect))
+	})
+
+	for _, cause := range s.causes {
+		e := *cause
+		e.Files = slices.Clone(cause.Files)
+		r.Errors = append(r.Errors, e)
+	}
+
+	slices.SortFunc(r.Errors, func(a, b errorReport) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Cause, b.Cause))
+	})
+
+	for _, stats := range exporterStats {
+		e := exporterReport{
+			Name:        stats.Name,
+			Exported:    stats.ExportedCount,
+			Failed:      stats.FailedCount,
+			Dropped:     stats.DroppedCount,
+			DeadLetters: stats.DeadLetterCount,
+		}
+		if stats.LastError != nil {
+			e.LastError = stats.LastError.Error()
+		}
+
+		r.Exporters = append(r.Exporters, e)
+	}
+
+	return r
+}
+
+// rootCause returns the innermost error wrapped by err.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+
+		err = next
+	}
+}
+
+// report represents the summary of a run. It is written to the JSON report file as it is.
+type report struct {
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	Files          fileCounts        `json:"files"`
+	Statements     statementCounts   `json:"statements"`
+	Directories    []directoryReport `json:"directories"`
+	Errors         []errorReport     `json:"errors"`
+	Slowest        []fileReport      `json:"slowest"`
+	Exporters      []exporterReport  `json:"exporters"`
+}
+
+type fileCounts struct {
+	// Total is the number of files passed to the pipeline. Zero when not known upfront.
+	Total     int    `json:"total,omitempty"`
+	Processed uint64 `json:"processed"`
+	Failed    uint64 `json:"failed"`
+	// Skipped is the number of files that were not processed at all, e.g. due to cancellation.
+	Skipped int `json:"skipped"`
+}
+
+type statementCounts struct {
+	Total uint64              `json:"total"`
+	Kinds map[sql.Kind]uint64 `json:"kinds"`
+}
+
+type directoryReport struct {
+	Directory  string   `json:"directory"`
+	Dialect    sql.Type `json:"dialect"`
+	Files      int      `json:"files"`
+	Failed     int      `json:"failed"`
+	Statements int      `json:"statements"`
+}
+
+type errorReport struct {
+	Cause string `json:"cause"`
+	Count int    `json:"count"`
+	// Files are the first files failed by the cause.
+	Files []string `json:"files"`
+}
+
+type fileReport struct {
+	Path            string   `json:"path"`
+	Dialect         sql.Type `json:"dialect"`
+	Statements      int      `json:"statements"`
+	DurationSeconds float64  `json:"duration_seconds"`
+
+	duration time.Duration
+}
+
+type exporterReport struct {
+	Name        string `json:"name"`
+	Exported    uint64 `json:"exported"`
+	Failed      uint64 `json:"failed"`
+	Dropped     uint64 `json:"dropped"`
+	DeadLetters uint64 `json:"dead_letters"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// unexported returns the number of statements that did not reach all exporters.
+func (r report) unexported() uint64 {
+	var unexported uint64
+	for _, e := range r.Exporters {
+		unexported += e.Failed + e.Dropped
+	}
+
+	return unexported
+}
+
+// write prints the human-readable report.
+func (r report) write(w io.Writer) {
+	elapsed := time.Duration(r.ElapsedSeconds * float64(time.Second)).Round(time.Millisecond)
+	files := fmt.Sprintf("%d files", r.Files.Processed)
+	if r.Files.Total > 0 {
+		files = fmt.Sprintf("%d of %d files", r.Files.Processed, r.Files.Total)
+	}
+
+	fmt.Fprintf(w, "processed %s in %s: %d statements, %d failed files\n",
+		files, elapsed, r.Statements.Total, r.Files.Failed)
+
+	for _, directory := range r.Directories {
+		fmt.Fprintf(w, "  directory %s (%s): %d files, %d failed, %d statements\n",
+			directory.Directory, directory.Dialect, directory.Files, directory.Failed, directory.Statements)
+	}
+
+	kinds := make([]string, 0, len(kindOrder))
+	for _, kind := range kindOrder {
+		if count := r.Statements.Kinds[kind]; count > 0 {
+			kinds = append(kinds, fmt.Sprintf("%d %s", count, kind))
+		}
+	}
+
+	if len(kinds) > 0 {
+		fmt.Fprintf(w, "statements: %s\n", strings.Join(kinds, ", "))
+	}
+
+	for _, e := range r.Errors {
+		fmt.Fprintf(w, "error %q: %d files, such as %s\n", e.Cause, e.Count, strings.Join(e.Files, ", "))
+	}
+
+	if len(r.Slowest) > 0 {
+		fmt.Fprintln(w, "slowest files:")
+	}
+
+	for _, file := range r.Slowest {
+		fmt.Fprintf(w, "  %s %s: %d statements\n", file.duration.Round(time.Microsecond), file.Path, file.Statements)
+	}
+
+	for _, e := range r.Exporters {
+		fmt.Fprintf(w, "exporter %s: %d exported, %d failed, %d dropped, %d dead letters\n",
+			e.Name, e.Exported, e.Failed, e.Dropped, e.DeadLetters)
+		if e.LastError != "" {
+			fmt.Fprintf(w, "  last error: %s\n", e.LastError)
+		}
+	}
+}
+
+// writeFile writes the JSON report to the file at path, replacing it.
+// The report is written to a temporary file renamed over the path, so readers never see a partial report.
+func (r report) writeFile(path string) (err error) {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed encoding report: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed creating report: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(append(bytes, '\n'))
+	if err != nil {
+		return fmt.Errorf("failed writing report: %w", err)
+	}
+
+	err = f.Chmod(reportFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed writing report: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("failed writing report: %w", err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("failed replacing report: %w", err)
+	}
+
+	return nil
+}
+
+// err returns an error when any file was not processed or any statement was not exported.
+func (r report) err() error {
+	failed, skipped, unexported := r.Files.Failed, r.Files.Skipped, r.unexported()
+	if failed == 0 && skipped == 0 && unexported == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d failed files, %d skipped files, %d statements not exported",
+		ErrProcessingFailed, failed, skipped, unexported)
+}
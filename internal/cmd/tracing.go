@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracingShutdownTimeout = 5 * time.Second
+
+// startTracing creates the tracer provider of the pipeline exporting spans to its OTLP endpoint, if any.
+// The returned function exports the remaining spans. It is called once the pipeline finishes.
+func startTracing(ctx context.Context, p *pipeline) (provider trace.TracerProvider, stop func(), err error) {
+	tp, err := tracing.New(ctx, p.otlpEndpoint, appName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed starting tracing: %w", err)
+	}
+
+	return tp, func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tracingShutdownTimeout)
+		defer cancel()
+
+		err := tp.Shutdown(shutdownCtx)
+		if err != nil {
+			p.logger.Error("failed exporting traces", "error", err)
+		}
+	}, nil
+}
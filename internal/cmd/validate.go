@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/course-go/sql-processor/internal/config"
+	"github.com/course-go/sql-processor/internal/exporter"
+)
+
+const (
+	configCommand         = "config"
+	configValidateCommand = "validate"
+)
+
+// runValidate runs the validate command:
+//
+//	sql-processor validate [FLAGS] [DIRECTIVE...]
+//
+// It checks the directives, the configuration file and the exporter flags the same way watch and process do,
+// without running the pipeline.
+func runValidate(w io.Writer, args []string, exporters []exporter.Exporter) error {
+	registry := newRegistry()
+	flags := newFlagSet(validateCommand, "[DIRECTIVE...]",
+		"Validates the PATH:DIALECT directives, the -config file and the flags without processing anything.")
+	pipelineFlags := addPipelineFlags(flags, registry)
+	directives, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	directories, err := parseDirectories(directives, false)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	p, err := pipelineFlags.setup(registry, exporters, directories)
+	if err != nil {
+		return err
+	}
+
+	defer p.close()
+	defer p.closeLog()
+
+	if len(p.directories) == 0 {
+		return fmt.Errorf("%w: %w", ErrUsage, ErrNoInputs)
+	}
+
+	for _, directory := range p.directories {
+		if directory.Path == stdinInput {
+			continue
+		}
+
+		_, err = os.Stat(directory.Path)
+		if err != nil {
+			return fmt.Errorf("%w: invalid directive path: %w", ErrUsage, err)
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "valid: %d directories, %d exporters\n", len(p.directories), len(p.exporters))
+	return err
+}
+
+// runConfig runs the config command:
+//
+//	sql-processor config validate FILE
+//
+// It reports all problems of the configuration file with their positions in the file.
+func runConfig(w io.Writer, args []string) error {
+	if len(args) == 0 || args[0] != configValidateCommand {
+		return fmt.Errorf("%w: %w: expected %s %s", ErrUsage, ErrUnknownCommand, configCommand, configValidateCommand)
+	}
+
+	flags := newFlagSet(configCommand+" "+configValidateCommand, "FILE",
+		"Validates the configuration file, including the directories and the exporter settings.")
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return fmt.Errorf("%w: expected a single configuration file", ErrUsage)
+	}
+
+	c, err := config.Load(positional[0])
+	if err != nil {
+		return fmt.Errorf("%w: invalid config: %w", ErrUsage, err)
+	}
+
+	exporters, _, exportersErr := c.CreateExporters(newRegistry())
+	closeExporters(exporters)
+
+	err = errors.Join(c.CheckDirectories(), exportersErr)
+	if err != nil {
+		return fmt.Errorf("%w: invalid config: %w", ErrUsage, err)
+	}
+
+	_, err = fmt.Fprintf(w, "%s: valid: %d directories, %d exporters\n",
+		c.Path, len(c.Directories), len(c.Exporters))
+	return err
+}
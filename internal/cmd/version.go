@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+const revisionLength = 12
+
+// runVersion runs the version command printing the build information:
+//
+//	sql-processor version
+func runVersion(w io.Writer, args []string) error {
+	flags := newFlagSet(versionCommand, "", "Prints the version, the VCS revision and the Go version of the build.")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) > 0 {
+		return fmt.Errorf("%w: unexpected arguments: %s", ErrUsage, strings.Join(positional, " "))
+	}
+
+	_, err = fmt.Fprintln(w, version())
+	return err
+}
+
+// version describes the build from its embedded build information.
+func version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return appName + " (unknown)"
+	}
+
+	parts := []string{appName, info.Main.Version}
+	settings := make(map[string]string)
+	for _, setting := range info.Settings {
+		settings[setting.Key] = setting.Value
+	}
+
+	if revision := settings["vcs.revision"]; revision != "" {
+		parts = append(parts, "revision", revision[:min(len(revision), revisionLength)])
+		if settings["vcs.modified"] == "true" {
+			parts = append(parts, "(modified)")
+		}
+	}
+
+	return strings.Join(append(parts, info.GoVersion), " ")
+}
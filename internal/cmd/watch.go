@@ -0,0 +1,257 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/processor"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+// runWatch runs the watch command:
+//
+//	sql-processor [watch] [FLAGS] DIRECTIVE...
+func runWatch(ctx context.Context, registry *exporter.Registry, args []string, exporters []exporter.Exporter) error {
+	flags := newFlagSet(watchCommand, "[DIRECTIVE...]",
+		"Watches the directories given by DIRECTORY:DIALECT directives and processes new SQL files.\n"+
+			"Without directives, the directories of the -config file are watched.\n"+
+			"A summary is printed to stderr on exit and on SIGUSR1, and written to the -report file, if any.")
+	pipelineFlags := addPipelineFlags(flags, registry)
+	directives, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	directories, err := parseDirectories(directives, false)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+
+	p, err := pipelineFlags.setup(registry, exporters, directories)
+	if err != nil {
+		return err
+	}
+
+	defer p.closeLog()
+
+	if len(p.directories) == 0 {
+		p.close()
+		return fmt.Errorf("%w: %w", ErrUsage, observer.ErrNoDirectoryDirectivesProvided)
+	}
+
+	return watch(ctx, p, os.Stderr)
+}
+
+// watch observes the directories of the pipeline and processes new files until the context is done
+// or a shutdown signal is received.
+//
+// Once shut down, no new files are accepted, the files being processed are finished and their statements
+// exported within the drain timeout. Files left unprocessed are written to the checkpoint, if any,
+// and processed first by the next run of the same directories. Statements left unexported after
+// the drain timeout are written to the dead letters.
+// The summary of the run is written to w, and to the report file if any, once it finishes.
+//
+// The exporters are closed by the manager once it runs or by watch when it fails before that.
+func watch(ctx context.Context, p *pipeline, w io.Writer) error {
+	tracerProvider, stopTracing, err := startTracing(ctx, p)
+	if err != nil {
+		p.close()
+		return err
+	}
+
+	defer stopTracing()
+
+	checkpoint := newCheckpoint(p.checkpointPath, p.directories)
+	resumed, err := checkpoint.take()
+	if err != nil {
+		p.close()
+		return fmt.Errorf("failed resuming checkpoint: %w", err)
+	}
+
+	fileCh := make(chan sql.File, max(channelBufferSize, len(resumed)))
+	statementCh := make(chan sql.Statement, channelBufferSize)
+	for _, file := range resumed {
+		fileCh <- file
+	}
+
+	metrics := newPipelineMetrics()
+	o, err := observer.NewFromDirectories(p.logger, p.directories, fileCh,
+		observer.WithMetrics(metrics.observer),
+		observer.WithTracerProvider(tracerProvider),
+	)
+	if err != nil {
+		p.close()
+		checkpoint.add(resumed...)
+		return errors.Join(fmt.Errorf("failed creating observer: %w", err), checkpoint.write())
+	}
+
+	defer func() {
+		err := o.Close()
+		if err != nil {
+			p.logger.Error("failed closing observer", "error", err)
+		}
+	}()
+
+	disposer := newDisposer(p.logger, o.Directory)
+	s := newSummary(o.Directory, 0)
+	pr := processor.New(p.logger, fileCh, statementCh,
+		processor.WithWorkers(p.workers),
+		processor.WithMetrics(metrics.processor),
+		processor.WithTracerProvider(tracerProvider),
+		processor.WithResultHandler(func(result processor.Result) {
+			s.add(result)
+			// Files interrupted by the drain timeout are processed again from the start.
+			if errors.Is(result.Err, context.Canceled) {
+				checkpoint.add(result.File)
+				return
+			}
+
+			disposer.dispose(result)
+		}),
+	)
+
+	deadLetterQueue := exporter.NewDeadLetterQueue(p.deadLetterDirectory)
+	opts := append([]exporter.Option{
+		exporter.WithDeadLetterQueue(deadLetterQueue),
+		exporter.WithMetrics(metrics.exporter),
+		exporter.WithTracerProvider(tracerProvider),
+	}, p.managerOptions...)
+	m, err := exporter.NewManager(p.logger, statementCh, p.exporters, opts...)
+	if err != nil {
+		p.close()
+		checkpoint.add(resumed...)
+		return errors.Join(fmt.Errorf("failed creating exporter manager: %w", err), checkpoint.write())
+	}
+
+	p.logger.Info("watching directories",
+		"directories", directoryPaths(p.directories),
+		"dead-letters", deadLetterQueue.Directory(),
+		"resumed", len(resumed),
+	)
+
+	sh := startShutdown(ctx, p.logger, p.drainTimeout)
+	defer sh.stop()
+
+	stopAdmin, err := serveAdmin(ctx, p, &pipelineStatus{
+		directories: p.directories,
+		observer:    &o,
+		processor:   &pr,
+		manager:     &m,
+		fileCh:      fileCh,
+		statementCh: statementCh,
+		shutdown:    sh,
+		metrics:     metrics,
+	})
+	if err != nil {
+		p.close()
+		checkpoint.add(resumed...)
+		return errors.Join(err, checkpoint.write())
+	}
+
+	defer stopAdmin()
+
+	stopLevelSignal := watchLevelSignal(p.logger, p.log.Levels)
+	defer stopLevelSignal()
+
+	r := &reporter{logger: p.logger, summary: s, processor: &pr, manager: &m, w: w, path: p.reportPath}
+	stopReportSignal := watchReportSignal(r)
+	defer stopReportSignal()
+
+	stopProcessor := context.AfterFunc(sh.intake, pr.Stop)
+	defer stopProcessor()
+
+	abortExports := context.AfterFunc(sh.drain, m.Abort)
+	defer abortExports()
+
+	var wg sync.WaitGroup
+	wg.Go(func() { o.Run(sh.intake) })
+	wg.Go(func() {
+		defer close(statementCh)
+
+		pr.Run(sh.drain)
+	})
+	// The manager exports statements until the processor is done.
+	wg.Go(func() { m.Run(context.WithoutCancel(ctx)) })
+	wg.Wait()
+
+	stopReportSignal()
+	r.report()
+	checkpoint.add(pending(fileCh)...)
+	err = checkpoint.write()
+	if err != nil {
+		return err
+	}
+
+	if checkpoint.len() > 0 {
+		p.logger.Warn("files left unprocessed", "files", checkpoint.len(), "checkpoint", p.checkpointPath)
+	}
+
+	if sh.timedOut() {
+		return fmt.Errorf("%w: %s", ErrDrainTimeout, p.drainTimeout)
+	}
+
+	return nil
+}
+
+// pending returns the files left in the channel without blocking.
+func pending(fileCh <-chan sql.File) (files []sql.File) {
+	for {
+		select {
+		case file := <-fileCh:
+			files = append(files, file)
+		default:
+			return files
+		}
+	}
+}
+
+// parseDirectories parses the PATH:DIALECT directives into directories.
+func parseDirectories(directives []string, recursive bool) ([]observer.Directory, error) {
+	directories := make([]observer.Directory, 0, len(directives))
+	for _, directive := range directives {
+		path, sqlType, err := observer.ParseDirective(directive)
+		if err != nil {
+			return nil, err
+		}
+
+		directories = append(directories, observer.Directory{Path: path, Type: sqlType, Recursive: recursive})
+	}
+
+	return directories, nil
+}
+
+func directoryPaths(directories []observer.Directory) []string {
+	paths := make([]string, 0, len(directories))
+	for _, directory := range directories {
+		paths = append(paths, directory.Path)
+	}
+
+	return paths
+}
+
+// defaultSpillDirectory returns the spill directory of exporter queues in the user cache directory.
+func defaultSpillDirectory() string {
+	directory, err := os.UserCacheDir()
+	if err != nil {
+		directory = os.TempDir()
+	}
+
+	return filepath.Join(directory, appName, "spill")
+}
+
+// defaultDeadLetterDirectory returns the dead letter directory in the user cache directory.
+func defaultDeadLetterDirectory() string {
+	directory, err := os.UserCacheDir()
+	if err != nil {
+		directory = os.TempDir()
+	}
+
+	return filepath.Join(directory, appName, "dead-letters")
+}
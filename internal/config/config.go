@@ -0,0 +1,369 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/retry"
+	"github.com/course-go/sql-processor/internal/logging"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/sql"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// TextLogFormat is the human-readable log format.
+	TextLogFormat = logging.TextFormat
+	// JSONLogFormat is the JSON log format.
+	JSONLogFormat = logging.JSONFormat
+)
+
+var (
+	ErrUnknownField       = errors.New("unknown field")
+	ErrMissingField       = errors.New("missing required field")
+	ErrInvalidValue       = errors.New("invalid value")
+	ErrDuplicateExporter  = errors.New("duplicate exporter name")
+	ErrUndefinedVariable  = errors.New("undefined environment variable")
+	ErrUnknownLogFormat   = logging.ErrUnknownFormat
+	ErrDirectoryNotExists = errors.New("directory does not exist")
+)
+
+// Config represents the configuration of the whole processing pipeline.
+//
+// It is loaded from a YAML file such as:
+//
+//	log:
+//	  level: info
+//	  format: json
+//	  file: /var/log/sql-processor.log
+//	  components:
+//	    observer: debug
+//	processor:
+//	  workers: 4
+//	shutdown:
+//	  drain_timeout: 30s
+//	  checkpoint: /var/lib/sql-processor/checkpoint.jsonl
+//	admin:
+//	  address: :9090
+//	tracing:
+//	  endpoint: http://localhost:4317
+//	batch:
+//	  max_count: 100
+//	  max_bytes: 1048576
+//	  max_linger: 100ms
+//	  split_on_file: true
+//	queue:
+//	  size: 64
+//	  overflow: spill
+//	  spill_dir: /var/lib/sql-processor/spill
+//	dead_letter_dir: /var/lib/sql-processor/dead-letters
+//	report: ./report.json
+//	directories:
+//	  - path: ./migrations
+//	    dialect: postgres
+//	    recursive: true
+//	    include: ["*.sql"]
+//	    exclude: ["*_test.sql"]
+//	    disposition: move:./processed
+//	exporters:
+//	  - name: archive
+//	    type: jsonl
+//	    settings:
+//	      path: ${LOG_DIR:-/var/log}/sql.jsonl
+//	  - name: review
+//	    type: plugin
+//	    settings:
+//	      command: ./review-hook
+//	    route:
+//	      kinds: [ddl]
+//	    queue:
+//	      overflow: drop-oldest
+//	    retry:
+//	      max_attempts: 3
+//	      initial_backoff: 1s
+//
+// Scalar values may reference environment variables as ${NAME} or ${NAME:-default}.
+// A literal dollar sign is written as $$. Relative directory paths are resolved against
+// the directory of the file. Exporter settings are passed to the exporters as they are.
+type Config struct {
+	// Path is the path of the loaded file.
+	Path string
+	// Log configures logging.
+	Log Log
+	// Processor configures the processor.
+	Processor Processor
+	// Shutdown configures the graceful shutdown.
+	Shutdown Shutdown
+	// Admin configures the admin HTTP server.
+	Admin Admin
+	// Tracing configures the export of traces.
+	Tracing Tracing
+	// Batch configures batching of the exported statements. Zero fields are not configured.
+	Batch exporter.BatchConfig
+	// Queue configures the queues of exporters without their own queue configuration.
+	// Zero fields are not configured.
+	Queue exporter.QueueConfig
+	// DeadLetterDirectory is the directory statements that failed to export are written to.
+	DeadLetterDirectory string
+	// Report is the JSON file the summary of the run is written to. Empty when not configured.
+	Report string
+	// Directories are the observed directories.
+	Directories []observer.Directory
+	// Exporters are the named exporters.
+	Exporters []Exporter
+
+	// positions maps field paths to their positions in the file.
+	positions map[string]position
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+// Log represents logging configuration.
+type Log struct {
+	// Level is the minimum log level. Nil when not configured.
+	Level *slog.Level
+	// Format is either [TextLogFormat] or [JSONLogFormat]. Empty when not configured.
+	Format string
+	// File is the file logs are appended to. Empty when not configured.
+	File string
+	// Components are the per-component overrides of the minimum level, keyed by the component name.
+	Components map[string]slog.Level
+}
+
+// Processor represents processor configuration.
+type Processor struct {
+	// Workers is the number of files processed concurrently. Zero when not configured.
+	Workers int
+}
+
+// Shutdown represents graceful shutdown configuration.
+type Shutdown struct {
+	// DrainTimeout is the time the pipeline gets to finish its work once shut down. Zero when not configured.
+	DrainTimeout time.Duration
+	// Checkpoint is the file the files left unprocessed are written to. Empty when not configured.
+	Checkpoint string
+}
+
+// Admin represents admin HTTP server configuration.
+type Admin struct {
+	// Address is the address the server listens on. Empty when not configured.
+	Address string
+}
+
+// Tracing represents tracing configuration.
+type Tracing struct {
+	// Endpoint is the URL of the OTLP/gRPC endpoint the traces are exported to. Empty when not configured.
+	Endpoint string
+}
+
+// Exporter represents a named exporter configuration.
+type Exporter struct {
+	// Name names the exporter in logs, statistics and dead letters. Defaults to the type.
+	Name string
+	// Type is the exporter type registered in the [exporter.Registry].
+	Type string
+	// Settings are passed to the exporter factory.
+	Settings exporter.Params
+	// Route limits the statements passed to the exporter.
+	Route Route
+	// Queue configures the queue of the exporter. Zero fields fall back to the default queue configuration.
+	Queue exporter.QueueConfig
+	// Retry configures retries and the circuit breaker of the exporter. Nil when exports are not retried.
+	// Fields which are not configured keep their [retry.DefaultConfig] values.
+	Retry *retry.Config
+}
+
+// Route represents exporter routing rules. A statement is routed to the exporter
+// when it matches all configured rules and any value of each rule.
+type Route struct {
+	Dialects    []sql.Type
+	Directories []string
+	Globs       []string
+	Kinds       []sql.Kind
+	Content     *regexp.Regexp
+}
+
+// Load loads the [Config] from the YAML file of the given path.
+// Environment variables are interpolated from the process environment.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading config file: %w", err)
+	}
+
+	return Parse(path, data, os.LookupEnv)
+}
+
+// Parse parses the [Config] from YAML data read from the file of the given path.
+// Environment variables are looked up by lookupEnv.
+//
+// All problems are reported at once, each as an [*Error] referencing its position in the file.
+func Parse(path string, data []byte, lookupEnv func(name string) (string, bool)) (*Config, error) {
+	config := &Config{Path: path, positions: make(map[string]position)}
+	d := &decoder{config: config, lookupEnv: lookupEnv}
+
+	var document yaml.Node
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, &Error{File: path, Err: err}
+	}
+
+	if len(document.Content) > 0 {
+		d.root(document.Content[0], config)
+	}
+
+	if len(d.errs) > 0 {
+		return nil, errors.Join(d.errs...)
+	}
+
+	return config, nil
+}
+
+// CheckDirectories reports all configured directories which do not exist.
+func (c *Config) CheckDirectories() error {
+	var errs []error
+	for i, directory := range c.Directories {
+		info, err := os.Stat(directory.Path)
+		if err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", directory.Path)
+		}
+
+		if err != nil {
+			field := fmt.Sprintf("directories[%d].path", i)
+			errs = append(errs, c.error(field, fmt.Errorf("%w: %w", ErrDirectoryNotExists, err)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// CreateExporters creates the configured exporters using the registry.
+// Exporters with retries configured are wrapped by [retry.Exporter].
+// It returns the [exporter.Manager] options naming, routing and queueing them.
+// All problems are reported at once and no exporter is returned on failure.
+func (c *Config) CreateExporters(
+	registry *exporter.Registry,
+) (exporters []exporter.Exporter, opts []exporter.Option, err error) {
+	var errs []error
+	for i, config := range c.Exporters {
+		e, err := registry.Create(config.Type, config.Settings)
+		if err != nil {
+			errs = append(errs, c.error(fmt.Sprintf("exporters[%d]", i), err))
+			continue
+		}
+
+		if config.Retry != nil {
+			e = retry.NewExporter(e, *config.Retry)
+		}
+
+		exporters = append(exporters, e)
+		opts = append(opts, exporter.WithName(e, config.Name))
+		if route := config.Route.Route(); route != nil {
+			opts = append(opts, exporter.WithRoute(e, route))
+		}
+
+		if config.Queue != (exporter.QueueConfig{}) {
+			opts = append(opts, exporter.WithQueueConfig(e, config.Queue))
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, e := range exporters {
+			if closer, ok := e.(exporter.Closer); ok {
+				_ = closer.Close()
+			}
+		}
+
+		return nil, nil, errors.Join(errs...)
+	}
+
+	return exporters, opts, nil
+}
+
+// error creates an [*Error] of the field at its position in the file.
+func (c *Config) error(field string, err error) *Error {
+	position := c.positions[field]
+	return &Error{
+		File:   c.Path,
+		Line:   position.line,
+		Column: position.column,
+		Field:  field,
+		Err:    err,
+	}
+}
+
+// Route returns the [exporter.Route] of the rules. It returns nil when there are no rules.
+func (r Route) Route() exporter.Route {
+	var routes []exporter.Route
+	if len(r.Dialects) > 0 {
+		routes = append(routes, exporter.ByType(r.Dialects...))
+	}
+
+	if len(r.Directories) > 0 {
+		routes = append(routes, exporter.ByDirectory(r.Directories...))
+	}
+
+	if len(r.Globs) > 0 {
+		globs := make([]exporter.Route, 0, len(r.Globs))
+		for _, glob := range r.Globs {
+			// Patterns are validated while parsing.
+			route, _ := exporter.ByGlob(glob)
+			globs = append(globs, route)
+		}
+
+		routes = append(routes, exporter.Any(globs...))
+	}
+
+	if len(r.Kinds) > 0 {
+		routes = append(routes, exporter.ByKind(r.Kinds...))
+	}
+
+	if r.Content != nil {
+		routes = append(routes, exporter.ByContent(r.Content))
+	}
+
+	if len(routes) == 0 {
+		return nil
+	}
+
+	return exporter.All(routes...)
+}
+
+// Error represents a problem of the configuration file at the given position.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	// Field is the path of the field, such as "directories[0].dialect".
+	Field string
+	Err   error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(e.File)
+	if e.Line > 0 {
+		fmt.Fprintf(&b, ":%d:%d", e.Line, e.Column)
+	}
+
+	if e.Field != "" {
+		b.WriteString(": " + e.Field)
+	}
+
+	return b.String() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,332 @@
+package config_test
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/config"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/retry"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+)
+
+func TestParse(t *testing.T) { //nolint: cyclop
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		c := parseFile(t, filepath.Join("testdata", "valid.yaml"), map[string]string{})
+
+		if c.Log.Level == nil || *c.Log.Level != slog.LevelDebug || c.Log.Format != config.JSONLogFormat {
+			t.Errorf("unexpected log config: got = %+v", c.Log)
+		}
+
+		if expected := filepath.Join("testdata", "logs", "sql-processor.log"); c.Log.File != expected {
+			t.Errorf("expected = %v, got = %v", expected, c.Log.File)
+		}
+
+		if level, ok := c.Log.Components["observer"]; !ok || level != slog.LevelWarn || len(c.Log.Components) != 1 {
+			t.Errorf("unexpected component levels: got = %v", c.Log.Components)
+		}
+
+		if c.Processor.Workers != 4 {
+			t.Errorf("expected = %v, got = %v", 4, c.Processor.Workers)
+		}
+
+		expectedShutdown := config.Shutdown{
+			DrainTimeout: 10 * time.Second,
+			Checkpoint:   filepath.Join("testdata", "checkpoint.jsonl"),
+		}
+		if c.Shutdown != expectedShutdown {
+			t.Errorf("expected = %+v, got = %+v", expectedShutdown, c.Shutdown)
+		}
+
+		if c.Admin.Address != "localhost:9090" {
+			t.Errorf("expected = %v, got = %v", "localhost:9090", c.Admin.Address)
+		}
+
+		if c.Tracing.Endpoint != "http://localhost:4317" {
+			t.Errorf("expected = %v, got = %v", "http://localhost:4317", c.Tracing.Endpoint)
+		}
+
+		if expected := filepath.Join("testdata", "dead-letters"); c.DeadLetterDirectory != expected {
+			t.Errorf("expected = %v, got = %v", expected, c.DeadLetterDirectory)
+		}
+
+		if expected := filepath.Join("testdata", "report.json"); c.Report != expected {
+			t.Errorf("expected = %v, got = %v", expected, c.Report)
+		}
+
+		expectedBatch := exporter.BatchConfig{MaxCount: 50, MaxLinger: 250 * time.Millisecond, SplitOnFile: true}
+		if c.Batch != expectedBatch {
+			t.Errorf("expected = %+v, got = %+v", expectedBatch, c.Batch)
+		}
+
+		expectedQueue := exporter.QueueConfig{
+			Size:           16,
+			Overflow:       exporter.OverflowSpill,
+			SpillDirectory: filepath.Join("testdata", "spill"),
+		}
+		if c.Queue != expectedQueue {
+			t.Errorf("expected = %+v, got = %+v", expectedQueue, c.Queue)
+		}
+
+		expectedDirectories := []observer.Directory{
+			{
+				Path:      filepath.Join("testdata", "migrations"),
+				Type:      sql.PostgresType,
+				Recursive: true,
+				Include:   []string{"*.sql"},
+				Exclude:   []string{"*_test.sql"},
+				Disposition: observer.Disposition{
+					Action: observer.MoveAction,
+					Target: filepath.Join("testdata", "processed"),
+				},
+			},
+			{Path: "/var/sql/mysql", Type: sql.MySQL},
+		}
+		if !slices.EqualFunc(c.Directories, expectedDirectories, equalDirectories) {
+			t.Errorf("expected = %+v, got = %+v", expectedDirectories, c.Directories)
+		}
+
+		if len(c.Exporters) != 2 {
+			t.Fatalf("expected = %v, got = %v", 2, len(c.Exporters))
+		}
+
+		archive, stdout := c.Exporters[0], c.Exporters[1]
+		if archive.Name != "archive" || archive.Settings["path"][0] != "/var/log/sql.jsonl" {
+			t.Errorf("unexpected archive exporter: got = %+v", archive)
+		}
+
+		if expected := (exporter.QueueConfig{Overflow: exporter.OverflowDropOldest}); archive.Queue != expected {
+			t.Errorf("expected = %+v, got = %+v", expected, archive.Queue)
+		}
+
+		if stdout.Name != "stdout" || stdout.Settings["template"][0] != "${{.Content}}" {
+			t.Errorf("unexpected stdout exporter: got = %+v", stdout)
+		}
+
+		if archive.Retry != nil {
+			t.Errorf("expected archive exporter without retries: got = %+v", archive.Retry)
+		}
+
+		expectedRetry := retry.DefaultConfig()
+		expectedRetry.MaxAttempts = 3
+		expectedRetry.InitialBackoff = time.Second
+		if stdout.Retry == nil || stdout.Retry.MaxAttempts != expectedRetry.MaxAttempts ||
+			stdout.Retry.InitialBackoff != expectedRetry.InitialBackoff || stdout.Retry.Jitter != expectedRetry.Jitter {
+			t.Errorf("expected = %+v, got = %+v", expectedRetry, stdout.Retry)
+		}
+
+		route := stdout.Route.Route()
+		statement := sql.Statement{
+			File:    sql.File{Path: filepath.Join("testdata", "migrations", "001.sql"), Type: sql.PostgresType},
+			Content: "DROP TABLE users",
+		}
+		if !route(statement) {
+			t.Errorf("expected statement to be routed: %v", statement)
+		}
+
+		statement.Content = "CREATE TABLE users"
+		if route(statement) {
+			t.Errorf("expected statement not to be routed: %v", statement)
+		}
+	})
+
+	t.Run("Environment", func(t *testing.T) {
+		t.Parallel()
+
+		c := parseFile(t, filepath.Join("testdata", "valid.yaml"), map[string]string{"LOG_DIR": "/srv/logs"})
+		if path := c.Exporters[0].Settings["path"][0]; path != "/srv/logs/sql.jsonl" {
+			t.Fatalf("expected = %v, got = %v", "/srv/logs/sql.jsonl", path)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join("testdata", "invalid.yaml")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed reading config: %v", err)
+		}
+
+		_, err = config.Parse(path, data, lookupEnv(nil))
+		if err == nil {
+			t.Fatalf("expected parse error")
+		}
+
+		expected := []string{
+			"invalid.yaml:2:10: log.level: invalid value",
+			"invalid.yaml:3:11: log.format: invalid value: unknown log format",
+			"invalid.yaml:5:12: processor.workers: invalid value",
+			"invalid.yaml:8:14: directories[0].dialect: invalid value: unknown sql type",
+			"invalid.yaml:9:18: directories[0].disposition: invalid value: unknown disposition",
+			"invalid.yaml:10:5: directories[1].path: missing required field",
+			"invalid.yaml:11:16: directories[1].recursive: invalid value",
+			"invalid.yaml:16:13: exporters[0].settings.path: undefined environment variable: UNDEFINED_LOG_DIR",
+			"invalid.yaml:20:15: exporters[1].route.kinds[0]: invalid value: unknown statement kind",
+			"invalid.yaml:21:16: exporters[1].route.content: invalid value",
+			"invalid.yaml:17:5: exporters[1]: duplicate exporter name: archive",
+			"invalid.yaml:23:18: shutdown.drain_timeout: invalid value: expected a positive duration",
+			"invalid.yaml:25:13: tracing.endpoint: invalid value: invalid OTLP endpoint",
+			"invalid.yaml:26:1: colour: unknown field",
+			"invalid.yaml:28:9: queue.size: invalid value",
+			"invalid.yaml:29:13: queue.overflow: invalid value: unknown overflow policy",
+			"invalid.yaml:31:14: batch.max_bytes: invalid value",
+		}
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) != len(expected) {
+			t.Fatalf("expected = %v, got = %v", len(expected), strings.Join(lines, "\n"))
+		}
+
+		for i, line := range lines {
+			if !strings.Contains(line, expected[i]) {
+				t.Errorf("expected = %v, got = %v", expected[i], line)
+			}
+		}
+
+		if !errors.Is(err, config.ErrUnknownField) || !errors.Is(err, sql.ErrUnknownType) {
+			t.Errorf("expected wrapped errors: got = %v", err)
+		}
+	})
+
+	t.Run("ComponentLevels", func(t *testing.T) {
+		t.Parallel()
+
+		data := []byte("log:\n  components:\n    observer: loud\n    processor: [debug]\n")
+		_, err := config.Parse("config.yaml", data, lookupEnv(nil))
+		for _, expected := range []string{
+			"config.yaml:3:15: log.components.observer: invalid value",
+			"config.yaml:4:16: log.components.processor: invalid value: expected a scalar",
+		} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected = %v, got = %v", expected, err)
+			}
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		data := []byte("exporters:\n  - type: stdout\n    retry:\n      multiplier: 0.5\n      jitter: 2\n")
+		_, err := config.Parse("config.yaml", data, lookupEnv(nil))
+		for _, expected := range []string{
+			"config.yaml:4:19: exporters[0].retry.multiplier: invalid value",
+			"config.yaml:5:15: exporters[0].retry.jitter: invalid value",
+		} {
+			if err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected = %v, got = %v", expected, err)
+			}
+		}
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := config.Parse("malformed.yaml", []byte("directories: [\n"), lookupEnv(nil))
+		var configErr *config.Error
+		if !errors.As(err, &configErr) || configErr.File != "malformed.yaml" {
+			t.Fatalf("expected config error: got = %v", err)
+		}
+	})
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("CheckDirectories", func(t *testing.T) {
+		t.Parallel()
+
+		data := []byte("directories:\n  - path: " + t.TempDir() + "\n    dialect: sqlite\n" +
+			"  - path: missing\n    dialect: sqlite\n")
+		c, err := config.Parse("config.yaml", data, lookupEnv(nil))
+		if err != nil {
+			t.Fatalf("failed parsing config: %v", err)
+		}
+
+		err = c.CheckDirectories()
+		if !errors.Is(err, config.ErrDirectoryNotExists) || !strings.HasPrefix(err.Error(), "config.yaml:4:5:") {
+			t.Fatalf("expected = %v, got = %v", config.ErrDirectoryNotExists, err)
+		}
+	})
+
+	t.Run("CreateExporters", func(t *testing.T) {
+		t.Parallel()
+
+		e := testexporter.New()
+		registry := exporter.NewRegistry()
+		exporter.Register(registry, "test", func(struct{ Fail bool }) (exporter.Exporter, error) {
+			return e, nil
+		})
+
+		data := []byte("exporters:\n  - name: first\n    type: test\n    route:\n      kinds: ddl\n" +
+			"    queue:\n      size: 8\n    retry:\n      max_attempts: 2\n")
+		c, err := config.Parse("config.yaml", data, lookupEnv(nil))
+		if err != nil {
+			t.Fatalf("failed parsing config: %v", err)
+		}
+
+		exporters, opts, err := c.CreateExporters(registry)
+		if err != nil || len(exporters) != 1 || len(opts) != 3 {
+			t.Fatalf("unexpected exporters: got = %v, %v, %v", exporters, opts, err)
+		}
+
+		if _, ok := exporters[0].(*retry.Exporter); !ok {
+			t.Fatalf("expected exporter wrapped with retries: got = %T", exporters[0])
+		}
+
+		data = []byte("exporters:\n  - type: test\n    settings:\n      colour: red\n  - type: missing\n")
+		c, err = config.Parse("config.yaml", data, lookupEnv(nil))
+		if err != nil {
+			t.Fatalf("failed parsing config: %v", err)
+		}
+
+		_, _, err = c.CreateExporters(registry)
+		if !errors.Is(err, exporter.ErrUnknownParam) || !errors.Is(err, exporter.ErrUnknownExporterType) {
+			t.Fatalf("expected both exporter errors: got = %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "config.yaml:5:5: exporters[1]") {
+			t.Fatalf("expected exporter position: got = %v", err)
+		}
+	})
+}
+
+func parseFile(t *testing.T, path string, env map[string]string) *config.Config {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed reading config: %v", err)
+	}
+
+	c, err := config.Parse(path, data, lookupEnv(env))
+	if err != nil {
+		t.Fatalf("failed parsing config: %v", err)
+	}
+
+	return c
+}
+
+func lookupEnv(env map[string]string) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func equalDirectories(a, b observer.Directory) bool {
+	return a.Path == b.Path && a.Type == b.Type && a.Recursive == b.Recursive &&
+		slices.Equal(a.Include, b.Include) && slices.Equal(a.Exclude, b.Exclude) &&
+		a.Disposition == b.Disposition
+}
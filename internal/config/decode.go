@@ -0,0 +1,527 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/retry"
+	"github.com/course-go/sql-processor/internal/observer"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+// decoder decodes the YAML document node by node so each problem can reference its position.
+// It collects all problems instead of stopping at the first one.
+type decoder struct {
+	config    *Config
+	lookupEnv func(name string) (string, bool)
+	errs      []error
+}
+
+// fields maps the keys of a mapping to their decoders.
+type fields map[string]func(node *yaml.Node, field string)
+
+func (d *decoder) error(node *yaml.Node, field string, err error) {
+	d.errs = append(d.errs, &Error{
+		File:   d.config.Path,
+		Line:   node.Line,
+		Column: node.Column,
+		Field:  field,
+		Err:    err,
+	})
+}
+
+func (d *decoder) errorf(node *yaml.Node, field string, format string, args ...any) {
+	d.error(node, field, fmt.Errorf(format, args...))
+}
+
+func (d *decoder) record(node *yaml.Node, field string) {
+	d.config.positions[field] = position{line: node.Line, column: node.Column}
+}
+
+// mapping decodes the mapping node using the decoders of its fields. Unknown keys are reported.
+func (d *decoder) mapping(node *yaml.Node, field string, decoders fields) {
+	if isNull(node) {
+		return
+	}
+
+	if node.Kind != yaml.MappingNode {
+		d.errorf(node, field, "%w: expected a mapping", ErrInvalidValue)
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := join(field, key.Value)
+		decode, ok := decoders[key.Value]
+		if !ok {
+			d.errorf(key, path, "%w", ErrUnknownField)
+			continue
+		}
+
+		d.record(key, path)
+		decode(value, path)
+	}
+}
+
+// require reports the required keys missing in the mapping node.
+func (d *decoder) require(node *yaml.Node, field string, keys ...string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	for _, key := range keys {
+		found := false
+		for i := 0; i < len(node.Content); i += 2 {
+			found = found || node.Content[i].Value == key
+		}
+
+		if !found {
+			d.errorf(node, join(field, key), "%w", ErrMissingField)
+		}
+	}
+}
+
+// entries decodes each value of the mapping node with arbitrary keys.
+func (d *decoder) entries(node *yaml.Node, field string, decode func(key string, node *yaml.Node, field string)) {
+	if isNull(node) {
+		return
+	}
+
+	if node.Kind != yaml.MappingNode {
+		d.errorf(node, field, "%w: expected a mapping", ErrInvalidValue)
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := join(field, key.Value)
+		d.record(key, path)
+		decode(key.Value, value, path)
+	}
+}
+
+// sequence decodes each item of the sequence node.
+func (d *decoder) sequence(node *yaml.Node, field string, decode func(node *yaml.Node, field string)) {
+	if isNull(node) {
+		return
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		d.errorf(node, field, "%w: expected a sequence", ErrInvalidValue)
+		return
+	}
+
+	for i, item := range node.Content {
+		path := fmt.Sprintf("%s[%d]", field, i)
+		d.record(item, path)
+		decode(item, path)
+	}
+}
+
+// scalar returns the scalar value with environment variables interpolated.
+func (d *decoder) scalar(node *yaml.Node, field string) (value string, ok bool) {
+	if node.Kind != yaml.ScalarNode {
+		d.errorf(node, field, "%w: expected a scalar", ErrInvalidValue)
+		return "", false
+	}
+
+	value, err := interpolate(node.Value, d.lookupEnv)
+	if err != nil {
+		d.error(node, field, err)
+		return "", false
+	}
+
+	return value, true
+}
+
+// scalars returns the values of a sequence of scalars. A single scalar is a sequence of one.
+func (d *decoder) scalars(node *yaml.Node, field string) (values []string) {
+	if node.Kind == yaml.ScalarNode {
+		value, ok := d.scalar(node, field)
+		if ok {
+			values = append(values, value)
+		}
+
+		return values
+	}
+
+	d.sequence(node, field, func(node *yaml.Node, field string) {
+		value, ok := d.scalar(node, field)
+		if ok {
+			values = append(values, value)
+		}
+	})
+
+	return values
+}
+
+func (d *decoder) string(target *string) func(node *yaml.Node, field string) {
+	return func(node *yaml.Node, field string) {
+		value, ok := d.scalar(node, field)
+		if ok {
+			*target = value
+		}
+	}
+}
+
+func (d *decoder) bool(target *bool) func(node *yaml.Node, field string) {
+	return parsed(d, target, strconv.ParseBool)
+}
+
+// parsed returns a decoder of a scalar parsed by parse.
+func parsed[T any](d *decoder, target *T, parse func(value string) (T, error)) func(node *yaml.Node, field string) {
+	return func(node *yaml.Node, field string) {
+		value, ok := d.scalar(node, field)
+		if !ok {
+			return
+		}
+
+		parsed, err := parse(value)
+		if err != nil {
+			d.errorf(node, field, "%w: %w", ErrInvalidValue, err)
+			return
+		}
+
+		*target = parsed
+	}
+}
+
+// parsedAll returns a decoder of a sequence of scalars parsed by parse. A single scalar is a sequence of one.
+func parsedAll[T any](
+	d *decoder,
+	target *[]T,
+	parse func(value string) (T, error),
+) func(node *yaml.Node, field string) {
+	return func(node *yaml.Node, field string) {
+		decode := func(node *yaml.Node, field string) {
+			var value T
+			errs := len(d.errs)
+			parsed(d, &value, parse)(node, field)
+			if len(d.errs) == errs {
+				*target = append(*target, value)
+			}
+		}
+
+		if node.Kind == yaml.ScalarNode {
+			decode(node, field)
+			return
+		}
+
+		d.sequence(node, field, decode)
+	}
+}
+
+// resolve resolves the path relative to the directory of the configuration file.
+func (d *decoder) resolve(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(filepath.Dir(d.config.Path), path)
+}
+
+func (d *decoder) root(node *yaml.Node, c *Config) {
+	d.mapping(node, "", fields{
+		"log": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"level": parsed(d, &c.Log.Level, parseLevel),
+				"format": parsed(d, &c.Log.Format, func(value string) (string, error) {
+					if value != TextLogFormat && value != JSONLogFormat {
+						return "", fmt.Errorf("%w: %s, expected %s or %s",
+							ErrUnknownLogFormat, value, TextLogFormat, JSONLogFormat)
+					}
+
+					return value, nil
+				}),
+				"file": func(node *yaml.Node, field string) {
+					d.string(&c.Log.File)(node, field)
+					c.Log.File = d.resolve(c.Log.File)
+				},
+				"components": func(node *yaml.Node, field string) {
+					c.Log.Components = make(map[string]slog.Level)
+					d.entries(node, field, func(key string, node *yaml.Node, field string) {
+						var level *slog.Level
+						parsed(d, &level, parseLevel)(node, field)
+						if level != nil {
+							c.Log.Components[key] = *level
+						}
+					})
+				},
+			})
+		},
+		"processor": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"workers": parsed(d, &c.Processor.Workers, parsePositive),
+			})
+		},
+		"shutdown": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"drain_timeout": parsed(d, &c.Shutdown.DrainTimeout, parsePositiveDuration),
+				"checkpoint": func(node *yaml.Node, field string) {
+					d.string(&c.Shutdown.Checkpoint)(node, field)
+					c.Shutdown.Checkpoint = d.resolve(c.Shutdown.Checkpoint)
+				},
+			})
+		},
+		"admin": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"address": d.string(&c.Admin.Address),
+			})
+		},
+		"tracing": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"endpoint": parsed(d, &c.Tracing.Endpoint, tracing.ParseEndpoint),
+			})
+		},
+		"batch": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"max_count":     parsed(d, &c.Batch.MaxCount, parsePositive),
+				"max_bytes":     parsed(d, &c.Batch.MaxBytes, parsePositive),
+				"max_linger":    parsed(d, &c.Batch.MaxLinger, parsePositiveDuration),
+				"split_on_file": d.bool(&c.Batch.SplitOnFile),
+			})
+		},
+		"queue": d.queue(&c.Queue),
+		"dead_letter_dir": func(node *yaml.Node, field string) {
+			d.string(&c.DeadLetterDirectory)(node, field)
+			c.DeadLetterDirectory = d.resolve(c.DeadLetterDirectory)
+		},
+		"report": func(node *yaml.Node, field string) {
+			d.string(&c.Report)(node, field)
+			c.Report = d.resolve(c.Report)
+		},
+		"directories": func(node *yaml.Node, field string) {
+			d.sequence(node, field, func(node *yaml.Node, field string) {
+				c.Directories = append(c.Directories, d.directory(node, field))
+			})
+		},
+		"exporters": func(node *yaml.Node, field string) {
+			names := make(map[string]struct{})
+			d.sequence(node, field, func(node *yaml.Node, field string) {
+				e := d.exporter(node, field)
+				if _, ok := names[e.Name]; ok && e.Name != "" {
+					d.errorf(node, field, "%w: %s", ErrDuplicateExporter, e.Name)
+				}
+
+				names[e.Name] = struct{}{}
+				c.Exporters = append(c.Exporters, e)
+			})
+		},
+	})
+}
+
+func (d *decoder) directory(node *yaml.Node, field string) (directory observer.Directory) {
+	d.require(node, field, "path", "dialect")
+	d.mapping(node, field, fields{
+		"path":        d.string(&directory.Path),
+		"dialect":     parsed(d, &directory.Type, sql.ParseType),
+		"recursive":   d.bool(&directory.Recursive),
+		"include":     parsedAll(d, &directory.Include, parseGlob),
+		"exclude":     parsedAll(d, &directory.Exclude, parseGlob),
+		"disposition": parsed(d, &directory.Disposition, observer.ParseDisposition),
+	})
+
+	directory.Path = d.resolve(directory.Path)
+	if directory.Disposition.Action == observer.MoveAction {
+		directory.Disposition.Target = d.resolve(directory.Disposition.Target)
+	}
+
+	return directory
+}
+
+func (d *decoder) exporter(node *yaml.Node, field string) (e Exporter) {
+	d.require(node, field, "type")
+	e.Settings = make(exporter.Params)
+	d.mapping(node, field, fields{
+		"name": d.string(&e.Name),
+		"type": d.string(&e.Type),
+		"settings": func(node *yaml.Node, field string) {
+			d.entries(node, field, func(key string, node *yaml.Node, field string) {
+				e.Settings[key] = d.scalars(node, field)
+			})
+		},
+		"route": func(node *yaml.Node, field string) {
+			d.mapping(node, field, fields{
+				"dialects": parsedAll(d, &e.Route.Dialects, sql.ParseType),
+				"directories": func(node *yaml.Node, field string) {
+					for _, directory := range d.scalars(node, field) {
+						e.Route.Directories = append(e.Route.Directories, d.resolve(directory))
+					}
+				},
+				"globs":   parsedAll(d, &e.Route.Globs, parseGlob),
+				"kinds":   parsedAll(d, &e.Route.Kinds, sql.ParseKind),
+				"content": parsed(d, &e.Route.Content, regexp.Compile),
+			})
+		},
+		"queue": d.queue(&e.Queue),
+		"retry": func(node *yaml.Node, field string) {
+			config := retry.DefaultConfig()
+			e.Retry = &config
+			d.mapping(node, field, fields{
+				"max_attempts":      parsed(d, &config.MaxAttempts, parsePositive),
+				"initial_backoff":   parsed(d, &config.InitialBackoff, parsePositiveDuration),
+				"max_backoff":       parsed(d, &config.MaxBackoff, parsePositiveDuration),
+				"multiplier":        parsed(d, &config.Multiplier, parseMultiplier),
+				"jitter":            parsed(d, &config.Jitter, parseFraction),
+				"failure_threshold": parsed(d, &config.FailureThreshold, parsePositive),
+				"open_timeout":      parsed(d, &config.OpenTimeout, parsePositiveDuration),
+			})
+		},
+	})
+
+	if e.Name == "" {
+		e.Name = e.Type
+	}
+
+	return e
+}
+
+func (d *decoder) queue(queue *exporter.QueueConfig) func(node *yaml.Node, field string) {
+	return func(node *yaml.Node, field string) {
+		d.mapping(node, field, fields{
+			"size":     parsed(d, &queue.Size, parsePositive),
+			"overflow": parsed(d, &queue.Overflow, exporter.ParseOverflowPolicy),
+			"spill_dir": func(node *yaml.Node, field string) {
+				d.string(&queue.SpillDirectory)(node, field)
+				queue.SpillDirectory = d.resolve(queue.SpillDirectory)
+			},
+		})
+	}
+}
+
+func parseLevel(value string) (*slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+
+	return &level, nil
+}
+
+func parsePositive(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 1 {
+		return 0, fmt.Errorf("expected a positive number, got %d", n)
+	}
+
+	return n, nil
+}
+
+func parsePositiveDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("expected a positive duration, got %s", duration)
+	}
+
+	return duration, nil
+}
+
+func parseMultiplier(value string) (float64, error) {
+	multiplier, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if multiplier < 1 {
+		return 0, fmt.Errorf("expected a multiplier of at least 1, got %v", multiplier)
+	}
+
+	return multiplier, nil
+}
+
+func parseFraction(value string) (float64, error) {
+	fraction, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if fraction < 0 || fraction > 1 {
+		return 0, fmt.Errorf("expected a fraction between 0 and 1, got %v", fraction)
+	}
+
+	return fraction, nil
+}
+
+func parseGlob(value string) (string, error) {
+	_, err := exporter.ByGlob(value)
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+func join(field, key string) string {
+	if field == "" {
+		return key
+	}
+
+	return field + "." + key
+}
+
+// interpolate replaces ${NAME} and ${NAME:-default} references with environment variables.
+// The $$ sequence is replaced by a single dollar sign.
+func interpolate(value string, lookupEnv func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+
+		switch value[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated variable reference in %q", ErrInvalidValue, value)
+			}
+
+			reference := value[i+2 : i+end]
+			name, fallback, hasFallback := strings.Cut(reference, ":-")
+			variable, ok := lookupEnv(name)
+			switch {
+			case ok && variable != "":
+				b.WriteString(variable)
+			case hasFallback:
+				b.WriteString(fallback)
+			case ok:
+			default:
+				return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+			}
+
+			i += end
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
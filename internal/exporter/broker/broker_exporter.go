@@ -0,0 +1,218 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const defaultBatchSize = 100
+
+var (
+	ErrNoTopic          = errors.New("no topic configured")
+	ErrUnknownTransport = errors.New("unknown transport")
+	ErrInvalidAddress   = errors.New("invalid address")
+)
+
+var (
+	_ exporter.Exporter        = &Exporter{}
+	_ exporter.ContextExporter = &Exporter{}
+	_ exporter.Closer          = &Exporter{}
+)
+
+// Message represents a message published to a message broker.
+type Message struct {
+	// Topic is the NATS subject or Kafka topic.
+	Topic string
+	// Key orders messages. Messages with the same key are delivered in order.
+	Key string
+	// Value is the message payload.
+	Value []byte
+}
+
+// Transport publishes [Message]s to a message broker.
+type Transport interface {
+	// Publish publishes the messages. It returns once the messages are acknowledged
+	// as configured by the transport.
+	Publish(ctx context.Context, messages []Message) (err error)
+	// Close closes the connections of the transport.
+	Close() (err error)
+}
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Topic is the NATS subject or Kafka topic statements are published to.
+	Topic string
+	// BatchSize is the maximum number of messages published at once. Defaults to 100.
+	BatchSize int
+}
+
+// Exporter implements [exporter.Exporter] and publishes given [sql.Statement]s to a message broker.
+//
+// Statements are published as JSON with their file path as the message key,
+// so statements of a single file keep their order.
+//
+// Batches larger than [Config.BatchSize] are published in chunks. When a chunk fails,
+// the statements of the chunks published before it are remembered and skipped by the
+// next export, so retrying the batch does not publish them twice.
+type Exporter struct {
+	transport Transport
+	config    Config
+
+	mu sync.Mutex
+	// published holds the statements of the last failed export which were published before it failed.
+	published map[sql.Statement]struct{}
+}
+
+// NewExporter creates a new [Exporter] publishing using the [Transport].
+func NewExporter(transport Transport, config Config) (*Exporter, error) {
+	if config.Topic == "" {
+		return nil, ErrNoTopic
+	}
+
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+
+	return &Exporter{
+		transport: transport,
+		config:    config,
+	}, nil
+}
+
+// FactoryConfig represents configuration of an [Exporter] and its [Transport] used by [Register].
+type FactoryConfig struct {
+	// Transport is either "nats" or "kafka".
+	Transport string
+	// Topic is the NATS subject or Kafka topic statements are published to.
+	Topic string
+	// BatchSize is the maximum number of messages published at once. Defaults to 100.
+	BatchSize int
+	// Address is the NATS server or the Kafka bootstrap brokers. Defaults to the local server.
+	Address []string
+	// Ack is the acknowledgement mode of the transport, see [NATSAck] and [KafkaAck].
+	Ack string
+	// Timeout limits connecting and waiting for acknowledgements. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// Register registers the "broker" exporter type configured by [FactoryConfig].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "broker", func(config FactoryConfig) (exporter.Exporter, error) {
+		transport, err := config.transport()
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := NewExporter(transport, Config{Topic: config.Topic, BatchSize: config.BatchSize})
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+func (c FactoryConfig) transport() (Transport, error) {
+	switch c.Transport {
+	case "nats":
+		if len(c.Address) > 1 {
+			return nil, fmt.Errorf("%w: nats transport takes a single address", ErrInvalidAddress)
+		}
+
+		config := NATSConfig{Ack: NATSAck(c.Ack), Timeout: c.Timeout}
+		if len(c.Address) == 1 {
+			config.Address = c.Address[0]
+		}
+
+		return NewNATSTransport(config)
+	case "kafka":
+		return NewKafkaTransport(KafkaConfig{Brokers: c.Address, Ack: KafkaAck(c.Ack), Timeout: c.Timeout})
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTransport, c.Transport)
+	}
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), []sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+// ExportContext implements exporter.ContextExporter.
+func (e *Exporter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	return e.ExportBatchContext(ctx, []sql.Statement{statement})
+}
+
+// ExportBatchContext implements exporter.ContextExporter.
+func (e *Exporter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	published := e.published
+	e.published = nil
+
+	pending := make([]sql.Statement, 0, len(statements))
+	messages := make([]Message, 0, len(statements))
+	for _, statement := range statements {
+		if _, ok := published[statement]; ok {
+			continue
+		}
+
+		value, err := json.Marshal(statement)
+		if err != nil {
+			return fmt.Errorf("failed encoding statement: %w", err)
+		}
+
+		messages = append(messages, Message{
+			Topic: e.config.Topic,
+			Key:   statement.File.Path,
+			Value: value,
+		})
+		pending = append(pending, statement)
+	}
+
+	for start := 0; start < len(messages); start += e.config.BatchSize {
+		end := min(start+e.config.BatchSize, len(messages))
+		err = e.transport.Publish(ctx, messages[start:end])
+		if err != nil {
+			e.remember(published, pending[:start])
+			return fmt.Errorf("failed publishing statements: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// remember keeps the published statements of a failed export to be skipped by the next one.
+// The statements skipped by the failed export are kept as well.
+func (e *Exporter) remember(skipped map[sql.Statement]struct{}, published []sql.Statement) {
+	if len(skipped) == 0 && len(published) == 0 {
+		return
+	}
+
+	if skipped == nil {
+		skipped = make(map[sql.Statement]struct{}, len(published))
+	}
+
+	for _, statement := range published {
+		skipped[statement] = struct{}{}
+	}
+
+	e.published = skipped
+}
+
+// Close implements exporter.Closer.
+func (e *Exporter) Close() (err error) {
+	return e.transport.Close()
+}
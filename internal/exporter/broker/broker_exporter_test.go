@@ -0,0 +1,179 @@
+package broker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/broker"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+var errPublish = errors.New("publish failed")
+
+type fakeTransport struct {
+	mu      sync.Mutex
+	batches [][]broker.Message
+	err     error
+	// failAt fails the publish of the given number, counted from 1, when set.
+	failAt int
+	calls  int
+	closed bool
+}
+
+func (t *fakeTransport) Publish(_ context.Context, messages []broker.Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.calls++
+	if t.err != nil || t.calls == t.failAt {
+		return errors.Join(t.err, errPublish)
+	}
+
+	t.batches = append(t.batches, messages)
+	return nil
+}
+
+func (t *fakeTransport) Close() error {
+	t.closed = true
+	return nil
+}
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoTopic", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := broker.NewExporter(&fakeTransport{}, broker.Config{})
+		if !errors.Is(err, broker.ErrNoTopic) {
+			t.Fatalf("expected no topic error: got = %v", err)
+		}
+	})
+
+	t.Run("Batching", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &fakeTransport{}
+		e, err := broker.NewExporter(transport, broker.Config{Topic: "sql", BatchSize: 2})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		statements := testStatements(5)
+		err = e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		if len(transport.batches) != 3 {
+			t.Fatalf("expected = %d batches, got = %d", 3, len(transport.batches))
+		}
+
+		var messages []broker.Message
+		for _, batch := range transport.batches {
+			messages = append(messages, batch...)
+		}
+
+		for i, message := range messages {
+			assertMessage(t, message, "sql", statements[i])
+		}
+
+		err = e.Close()
+		if err != nil || !transport.closed {
+			t.Fatalf("expected transport to be closed: got = %v", err)
+		}
+	})
+
+	t.Run("RetryPublishesRemainingChunks", func(t *testing.T) {
+		t.Parallel()
+
+		transport := &fakeTransport{failAt: 2}
+		e, err := broker.NewExporter(transport, broker.Config{Topic: "sql", BatchSize: 2})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		statements := testStatements(5)
+		err = e.ExportBatch(statements)
+		if !errors.Is(err, errPublish) {
+			t.Fatalf("expected = %v, got = %v", errPublish, err)
+		}
+
+		err = e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		var messages []broker.Message
+		for _, batch := range transport.batches {
+			messages = append(messages, batch...)
+		}
+
+		if len(messages) != len(statements) {
+			t.Fatalf("expected = %d messages, got = %d", len(statements), len(messages))
+		}
+
+		for i, message := range messages {
+			assertMessage(t, message, "sql", statements[i])
+		}
+
+		// Once the batch succeeds, the same statements are published again.
+		err = e.ExportBatch(statements[:1])
+		if err != nil || len(transport.batches) != 4 {
+			t.Fatalf("expected statement to be published again: got = %d batches, %v", len(transport.batches), err)
+		}
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		t.Parallel()
+
+		e, err := broker.NewExporter(&fakeTransport{err: errPublish}, broker.Config{Topic: "sql"})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		err = e.Export(testStatements(1)[0])
+		if !errors.Is(err, errPublish) {
+			t.Fatalf("expected = %v, got = %v", errPublish, err)
+		}
+	})
+}
+
+func testStatements(count int) []sql.Statement {
+	statements := make([]sql.Statement, 0, count)
+	for i := range count {
+		statements = append(statements, sql.Statement{
+			File:    sql.File{Path: "/var/sql/" + strconv.Itoa(i%2) + ".sql", Type: sql.PostgresType},
+			Content: "SELECT " + strconv.Itoa(i),
+			LineNum: i + 1,
+		})
+	}
+
+	return statements
+}
+
+func assertMessage(t *testing.T, message broker.Message, topic string, statement sql.Statement) {
+	t.Helper()
+
+	if message.Topic != topic {
+		t.Fatalf("expected = %s, got = %s", topic, message.Topic)
+	}
+
+	if message.Key != statement.File.Path {
+		t.Fatalf("expected = %s, got = %s", statement.File.Path, message.Key)
+	}
+
+	var got sql.Statement
+	err := json.Unmarshal(message.Value, &got)
+	if err != nil {
+		t.Fatalf("failed decoding message: %v", err)
+	}
+
+	if got != statement {
+		t.Fatalf("expected = %v, got = %v", statement, got)
+	}
+}
@@ -0,0 +1,630 @@
+package broker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKafkaAddress = "127.0.0.1:9092"
+	defaultKafkaTimeout = 5 * time.Second
+	kafkaClientID       = "sql-processor"
+
+	kafkaProduceKey      int16 = 0
+	kafkaProduceVersion  int16 = 3
+	kafkaMetadataKey     int16 = 3
+	kafkaMetadataVersion int16 = 4
+
+	kafkaRecordBatchMagic   int8 = 2
+	kafkaMaxResponseSize         = 64 << 20
+	kafkaRecordBatchHeader       = 61
+	kafkaRecordBatchCRCFrom      = 21
+)
+
+var (
+	ErrUnknownKafkaAck = errors.New("unknown kafka ack mode")
+	ErrKafkaBroker     = errors.New("kafka broker error")
+	ErrKafkaProtocol   = errors.New("kafka protocol violation")
+	ErrNoKafkaLeader   = errors.New("kafka partition has no leader")
+)
+
+var _ Transport = &KafkaTransport{}
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaAck represents how [KafkaTransport] waits for published messages to be acknowledged.
+type KafkaAck string
+
+const (
+	// KafkaAckNone does not wait for any acknowledgement.
+	KafkaAckNone KafkaAck = "none"
+	// KafkaAckLeader waits until the partition leader writes the messages.
+	KafkaAckLeader KafkaAck = "leader"
+	// KafkaAckAll waits until all in-sync replicas write the messages.
+	KafkaAckAll KafkaAck = "all"
+)
+
+func (a KafkaAck) required() int16 {
+	switch a {
+	case KafkaAckNone:
+		return 0
+	case KafkaAckLeader:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// KafkaConfig represents [KafkaTransport] configuration.
+type KafkaConfig struct {
+	// Brokers are the host and port pairs used for discovering the cluster. Defaults to "127.0.0.1:9092".
+	Brokers []string
+	// Ack sets how published messages are acknowledged. Defaults to [KafkaAckAll].
+	Ack KafkaAck
+	// Timeout limits connecting and waiting for acknowledgements. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// KafkaTransport implements [Transport] for Kafka using its wire protocol.
+//
+// Only the Metadata (v4) and Produce (v3) requests publishing needs are implemented,
+// which brokers from Kafka 1.0 to 4.x accept. A full client library would bring consumer
+// groups, compression codecs and their dependencies the exporter does not use.
+//
+// Messages are assigned to partitions by the murmur2 hash of their key,
+// the same way the default Kafka partitioner does. Messages are not compressed.
+type KafkaTransport struct {
+	config KafkaConfig
+
+	mu            sync.Mutex
+	correlationID int32
+	brokers       map[int32]string
+	partitions    map[string][]int32
+	conns         map[string]*kafkaConn
+}
+
+// NewKafkaTransport creates a new [KafkaTransport] from the given [KafkaConfig].
+func NewKafkaTransport(config KafkaConfig) (*KafkaTransport, error) {
+	if len(config.Brokers) == 0 {
+		config.Brokers = []string{defaultKafkaAddress}
+	}
+
+	switch config.Ack {
+	case "":
+		config.Ack = KafkaAckAll
+	case KafkaAckNone, KafkaAckLeader, KafkaAckAll:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKafkaAck, config.Ack)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultKafkaTimeout
+	}
+
+	return &KafkaTransport{
+		config:     config,
+		brokers:    make(map[int32]string),
+		partitions: make(map[string][]int32),
+		conns:      make(map[string]*kafkaConn),
+	}, nil
+}
+
+// Publish implements Transport.
+func (t *KafkaTransport) Publish(ctx context.Context, messages []Message) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err = t.publish(ctx, messages)
+	if err != nil {
+		t.reset()
+		return err
+	}
+
+	return nil
+}
+
+// Close implements Transport.
+func (t *KafkaTransport) Close() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, conn := range t.conns {
+		err = errors.Join(err, conn.Close())
+	}
+
+	t.reset()
+	return err
+}
+
+// kafkaPartition identifies a topic partition.
+type kafkaPartition struct {
+	topic string
+	id    int32
+}
+
+func (t *KafkaTransport) publish(ctx context.Context, messages []Message) error {
+	// Leader address -> partitions in order of first appearance -> messages.
+	requests := make(map[string]map[kafkaPartition][]Message)
+	order := make(map[string][]kafkaPartition)
+	for _, message := range messages {
+		leaders, err := t.leaders(ctx, message.Topic)
+		if err != nil {
+			return err
+		}
+
+		id := int32(murmur2([]byte(message.Key))&0x7fffffff) % int32(len(leaders))
+		address, ok := t.brokers[leaders[id]]
+		if !ok {
+			return fmt.Errorf("%w: %s/%d", ErrNoKafkaLeader, message.Topic, id)
+		}
+
+		partition := kafkaPartition{topic: message.Topic, id: id}
+		if requests[address] == nil {
+			requests[address] = make(map[kafkaPartition][]Message)
+		}
+
+		if requests[address][partition] == nil {
+			order[address] = append(order[address], partition)
+		}
+
+		requests[address][partition] = append(requests[address][partition], message)
+	}
+
+	for address, partitions := range order {
+		err := t.produce(ctx, address, partitions, requests[address])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// leaders returns leader broker IDs indexed by partition IDs of the topic.
+func (t *KafkaTransport) leaders(ctx context.Context, topic string) ([]int32, error) {
+	leaders, ok := t.partitions[topic]
+	if ok {
+		return leaders, nil
+	}
+
+	var errs error
+	for _, address := range t.config.Brokers {
+		err := t.metadata(ctx, address, topic)
+		if err == nil {
+			return t.partitions[topic], nil
+		}
+
+		errs = errors.Join(errs, err)
+	}
+
+	return nil, errs
+}
+
+func (t *KafkaTransport) metadata(ctx context.Context, address, topic string) error {
+	var request kafkaEncoder
+	request.int32(1)
+	request.string(topic)
+	request.int8(1) // allow auto topic creation
+
+	response, err := t.roundTrip(ctx, address, kafkaMetadataKey, kafkaMetadataVersion, request.Bytes(), true)
+	if err != nil {
+		return err
+	}
+
+	decoder := kafkaDecoder{data: response}
+	_ = decoder.int32() // throttle time
+	for range decoder.array() {
+		id := decoder.int32()
+		host := decoder.string()
+		port := decoder.int32()
+		_ = decoder.string() // rack
+		t.brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+
+	_ = decoder.string() // cluster ID
+	_ = decoder.int32()  // controller ID
+	for range decoder.array() {
+		code := decoder.int16()
+		name := decoder.string()
+		_ = decoder.int8() // is internal
+		partitions := decoder.array()
+		leaders := make([]int32, partitions)
+		for range partitions {
+			_ = decoder.int16() // partition error code
+			id := decoder.int32()
+			leader := decoder.int32()
+			for range decoder.array() {
+				_ = decoder.int32() // replica
+			}
+
+			for range decoder.array() {
+				_ = decoder.int32() // in-sync replica
+			}
+
+			if id >= 0 && int(id) < len(leaders) {
+				leaders[id] = leader
+			}
+		}
+
+		if decoder.err != nil {
+			break
+		}
+
+		if name != topic {
+			continue
+		}
+
+		if code != 0 {
+			return fmt.Errorf("%w: metadata of topic %s: error code %d", ErrKafkaBroker, topic, code)
+		}
+
+		if len(leaders) == 0 {
+			return fmt.Errorf("%w: topic %s has no partitions", ErrKafkaBroker, topic)
+		}
+
+		t.partitions[topic] = leaders
+	}
+
+	if decoder.err != nil {
+		return decoder.err
+	}
+
+	if _, ok := t.partitions[topic]; !ok {
+		return fmt.Errorf("%w: metadata of topic %s missing", ErrKafkaBroker, topic)
+	}
+
+	return nil
+}
+
+func (t *KafkaTransport) produce(
+	ctx context.Context,
+	address string,
+	partitions []kafkaPartition,
+	messages map[kafkaPartition][]Message,
+) error {
+	topics := make(map[string][]kafkaPartition)
+	var topicOrder []string
+	for _, partition := range partitions {
+		if topics[partition.topic] == nil {
+			topicOrder = append(topicOrder, partition.topic)
+		}
+
+		topics[partition.topic] = append(topics[partition.topic], partition)
+	}
+
+	var request kafkaEncoder
+	request.int16(-1) // null transactional ID
+	request.int16(t.config.Ack.required())
+	request.int32(int32(t.config.Timeout.Milliseconds()))
+	request.int32(int32(len(topicOrder)))
+	for _, topic := range topicOrder {
+		request.string(topic)
+		request.int32(int32(len(topics[topic])))
+		for _, partition := range topics[topic] {
+			request.int32(partition.id)
+			request.bytes(recordBatch(messages[partition], time.Now()))
+		}
+	}
+
+	awaitResponse := t.config.Ack != KafkaAckNone
+	response, err := t.roundTrip(ctx, address, kafkaProduceKey, kafkaProduceVersion, request.Bytes(), awaitResponse)
+	if err != nil || !awaitResponse {
+		return err
+	}
+
+	decoder := kafkaDecoder{data: response}
+	for range decoder.array() {
+		topic := decoder.string()
+		for range decoder.array() {
+			partition := decoder.int32()
+			code := decoder.int16()
+			_ = decoder.int64() // base offset
+			_ = decoder.int64() // log append time
+			if decoder.err == nil && code != 0 {
+				return fmt.Errorf("%w: produce to %s/%d: error code %d", ErrKafkaBroker, topic, partition, code)
+			}
+		}
+	}
+
+	return decoder.err
+}
+
+// roundTrip sends the request to the broker and returns the response body.
+func (t *KafkaTransport) roundTrip(
+	ctx context.Context,
+	address string,
+	key, version int16,
+	body []byte,
+	awaitResponse bool,
+) ([]byte, error) {
+	conn, ok := t.conns[address]
+	if !ok {
+		dialer := net.Dialer{Timeout: t.config.Timeout}
+		c, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed connecting to kafka broker %s: %w", address, err)
+		}
+
+		conn = &kafkaConn{Conn: c, reader: bufio.NewReader(c)}
+		t.conns[address] = conn
+	}
+
+	deadline := time.Now().Add(t.config.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	t.correlationID++
+	correlationID := t.correlationID
+
+	var header kafkaEncoder
+	header.int32(0) // size placeholder
+	header.int16(key)
+	header.int16(version)
+	header.int32(correlationID)
+	header.string(kafkaClientID)
+	header.Write(body)
+	request := header.Bytes()
+	binary.BigEndian.PutUint32(request, uint32(len(request)-4))
+
+	_, err := conn.Write(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed writing to kafka broker %s: %w", address, err)
+	}
+
+	if !awaitResponse {
+		return nil, nil
+	}
+
+	var size int32
+	err = binary.Read(conn.reader, binary.BigEndian, &size)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading from kafka broker %s: %w", address, err)
+	}
+
+	if size < 4 || size > kafkaMaxResponseSize {
+		return nil, fmt.Errorf("%w: invalid response size %d", ErrKafkaProtocol, size)
+	}
+
+	response := make([]byte, size)
+	_, err = io.ReadFull(conn.reader, response)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading from kafka broker %s: %w", address, err)
+	}
+
+	if got := int32(binary.BigEndian.Uint32(response)); got != correlationID {
+		return nil, fmt.Errorf("%w: expected correlation ID %d, got %d", ErrKafkaProtocol, correlationID, got)
+	}
+
+	return response[4:], nil
+}
+
+// reset drops the cluster metadata and connections so they get re-established.
+func (t *KafkaTransport) reset() {
+	for address, conn := range t.conns {
+		_ = conn.Close()
+		delete(t.conns, address)
+	}
+
+	clear(t.brokers)
+	clear(t.partitions)
+}
+
+type kafkaConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// recordBatch encodes the messages as an uncompressed record batch (magic v2).
+func recordBatch(messages []Message, now time.Time) []byte {
+	timestamp := now.UnixMilli()
+
+	var records kafkaEncoder
+	for i, message := range messages {
+		var record kafkaEncoder
+		record.int8(0) // attributes
+		record.varint(0)
+		record.varint(int64(i))
+		record.varint(int64(len(message.Key)))
+		record.WriteString(message.Key)
+		record.varint(int64(len(message.Value)))
+		record.Write(message.Value)
+		record.varint(0) // headers
+
+		records.varint(int64(record.Len()))
+		records.Write(record.Bytes())
+	}
+
+	var batch kafkaEncoder
+	batch.int64(0) // base offset
+	batch.int32(int32(kafkaRecordBatchHeader - 12 + records.Len()))
+	batch.int32(-1) // partition leader epoch
+	batch.int8(kafkaRecordBatchMagic)
+	batch.int32(0) // CRC placeholder
+	batch.int16(0) // attributes
+	batch.int32(int32(len(messages) - 1))
+	batch.int64(timestamp)
+	batch.int64(timestamp)
+	batch.int64(-1) // producer ID
+	batch.int16(-1) // producer epoch
+	batch.int32(-1) // base sequence
+	batch.int32(int32(len(messages)))
+	batch.Write(records.Bytes())
+
+	data := batch.Bytes()
+	crc := crc32.Checksum(data[kafkaRecordBatchCRCFrom:], crc32c)
+	binary.BigEndian.PutUint32(data[kafkaRecordBatchCRCFrom-4:], crc)
+	return data
+}
+
+// murmur2 computes the hash used by the default Kafka partitioner.
+func murmur2(data []byte) uint32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+	for i := 0; i+4 <= length; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := data[length&^3:]
+	switch len(tail) {
+	case 3:
+		h ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(tail[0])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// kafkaEncoder encodes Kafka protocol primitives.
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	e.Buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	e.Buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	e.Buffer.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+}
+
+func (e *kafkaEncoder) varint(v int64) {
+	e.Buffer.Write(binary.AppendVarint(nil, v))
+}
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
+
+func (e *kafkaEncoder) bytes(v []byte) {
+	e.int32(int32(len(v)))
+	e.Write(v)
+}
+
+// kafkaDecoder decodes Kafka protocol primitives. The first failure is kept in err
+// and all following reads return zero values.
+type kafkaDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(d.data) {
+		d.err = fmt.Errorf("%w: response truncated", ErrKafkaProtocol)
+		return nil
+	}
+
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	b := d.next(1)
+	if b == nil {
+		return 0
+	}
+
+	return int8(b[0])
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	b := d.next(2)
+	if b == nil {
+		return 0
+	}
+
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// string decodes a nullable string. Null is decoded as an empty string.
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+
+	return string(d.next(int(n)))
+}
+
+// array decodes an array length. Null arrays and failures are decoded as empty.
+func (d *kafkaDecoder) array() int {
+	n := d.int32()
+	if n < 0 || d.err != nil {
+		return 0
+	}
+
+	if int(n) > len(d.data) {
+		d.err = fmt.Errorf("%w: invalid array length %d", ErrKafkaProtocol, n)
+		return 0
+	}
+
+	return int(n)
+}
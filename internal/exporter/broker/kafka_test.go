@@ -0,0 +1,433 @@
+package broker_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter/broker"
+)
+
+const (
+	kafkaPartitions   = 4
+	serverTestTimeout = 10 * time.Second
+)
+
+// kafkaBroker is a minimal in-process Kafka broker recording produced messages.
+type kafkaBroker struct {
+	listener  net.Listener
+	errorCode int16
+
+	mu         sync.Mutex
+	partitions map[int32][]broker.Message
+}
+
+func newKafkaBroker(t *testing.T) *kafkaBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed listening: %v", err)
+	}
+
+	b := &kafkaBroker{listener: listener, partitions: make(map[int32][]broker.Message)}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go b.serve()
+	return b
+}
+
+func (b *kafkaBroker) serve() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go b.handle(conn)
+	}
+}
+
+func (b *kafkaBroker) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	for {
+		var size int32
+		err := binary.Read(conn, binary.BigEndian, &size)
+		if err != nil {
+			return
+		}
+
+		request := make([]byte, size)
+		_, err = io.ReadFull(conn, request)
+		if err != nil {
+			return
+		}
+
+		r := bytes.NewReader(request)
+		key := readInt16(r)
+		version := readInt16(r)
+		correlationID := readInt32(r)
+		_ = readString(r) // client ID
+
+		var response bytes.Buffer
+		writeInt32(&response, correlationID)
+		switch key {
+		// Versions removed by Kafka 4.0 are rejected by closing the connection, as brokers do.
+		case 3:
+			if version < 4 {
+				return
+			}
+
+			b.metadata(r, &response)
+		case 0:
+			if version < 3 {
+				return
+			}
+
+			acks := b.produce(r, &response)
+			if acks == 0 {
+				continue
+			}
+		default:
+			return
+		}
+
+		writeInt32(conn, int32(response.Len()))
+		_, _ = conn.Write(response.Bytes())
+	}
+}
+
+func (b *kafkaBroker) metadata(r *bytes.Reader, w *bytes.Buffer) {
+	_ = readInt32(r) // topic count
+	topic := readString(r)
+
+	host, port, _ := net.SplitHostPort(b.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	writeInt32(w, 0) // throttle time
+	writeInt32(w, 1)
+	writeInt32(w, 7) // node ID
+	writeString(w, host)
+	writeInt32(w, int32(portNum))
+	writeInt16(w, -1) // rack
+	writeString(w, "cluster")
+	writeInt32(w, 7) // controller ID
+	writeInt32(w, 1)
+	writeInt16(w, 0)
+	writeString(w, topic)
+	w.WriteByte(0)
+	writeInt32(w, kafkaPartitions)
+	for partition := range int32(kafkaPartitions) {
+		writeInt16(w, 0)
+		writeInt32(w, partition)
+		writeInt32(w, 7) // leader
+		writeInt32(w, 0) // replicas
+		writeInt32(w, 0) // in-sync replicas
+	}
+}
+
+func (b *kafkaBroker) produce(r *bytes.Reader, w *bytes.Buffer) (acks int16) {
+	_ = readInt16(r) // transactional ID
+	acks = readInt16(r)
+	_ = readInt32(r) // timeout
+
+	topicCount := readInt32(r)
+	writeInt32(w, topicCount)
+	for range topicCount {
+		topic := readString(r)
+		writeString(w, topic)
+
+		partitionCount := readInt32(r)
+		writeInt32(w, partitionCount)
+		for range partitionCount {
+			partition := readInt32(r)
+			batch := make([]byte, readInt32(r))
+			_, _ = io.ReadFull(r, batch)
+
+			errorCode := b.errorCode
+			messages, err := decodeRecordBatch(topic, batch)
+			if err != nil {
+				errorCode = 2 // corrupt message
+			}
+
+			b.mu.Lock()
+			b.partitions[partition] = append(b.partitions[partition], messages...)
+			b.mu.Unlock()
+
+			writeInt32(w, partition)
+			writeInt16(w, errorCode)
+			writeInt64(w, 0)  // base offset
+			writeInt64(w, -1) // log append time
+		}
+	}
+
+	writeInt32(w, 0) // throttle time
+	return acks
+}
+
+func (b *kafkaBroker) produced() map[int32][]broker.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.partitions
+}
+
+func decodeRecordBatch(topic string, batch []byte) ([]broker.Message, error) {
+	if len(batch) < 61 || batch[16] != 2 {
+		return nil, errors.New("invalid record batch header")
+	}
+
+	crc := binary.BigEndian.Uint32(batch[17:])
+	if crc != crc32.Checksum(batch[21:], crc32.MakeTable(crc32.Castagnoli)) {
+		return nil, errors.New("invalid record batch checksum")
+	}
+
+	r := bytes.NewReader(batch[57:])
+	count := readInt32(r)
+	messages := make([]broker.Message, 0, count)
+	for range count {
+		_, _ = binary.ReadVarint(r) // length
+		_, _ = r.ReadByte()         // attributes
+		_, _ = binary.ReadVarint(r) // timestamp delta
+		_, _ = binary.ReadVarint(r) // offset delta
+
+		keyLength, _ := binary.ReadVarint(r)
+		key := make([]byte, keyLength)
+		_, _ = io.ReadFull(r, key)
+
+		valueLength, _ := binary.ReadVarint(r)
+		value := make([]byte, valueLength)
+		_, _ = io.ReadFull(r, value)
+
+		_, _ = binary.ReadVarint(r) // headers
+		messages = append(messages, broker.Message{Topic: topic, Key: string(key), Value: value})
+	}
+
+	return messages, nil
+}
+
+func TestKafkaTransport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnknownAck", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := broker.NewKafkaTransport(broker.KafkaConfig{Ack: "maybe"})
+		if !errors.Is(err, broker.ErrUnknownKafkaAck) {
+			t.Fatalf("expected unknown ack error: got = %v", err)
+		}
+	})
+
+	t.Run("Partitioning", func(t *testing.T) {
+		t.Parallel()
+
+		kafka := newKafkaBroker(t)
+		transport, err := broker.NewKafkaTransport(broker.KafkaConfig{
+			Brokers: []string{kafka.listener.Addr().String()},
+		})
+		if err != nil {
+			t.Fatalf("failed creating transport: %v", err)
+		}
+
+		defer func() {
+			_ = transport.Close()
+		}()
+
+		messages := []broker.Message{
+			{Topic: "sql", Key: "foobar", Value: []byte("1")},
+			{Topic: "sql", Key: "21", Value: []byte("2")},
+			{Topic: "sql", Key: "abc", Value: []byte("3")},
+			{Topic: "sql", Key: "foobar", Value: []byte("4")},
+		}
+		err = transport.Publish(t.Context(), messages)
+		if err != nil {
+			t.Fatalf("failed publishing messages: %v", err)
+		}
+
+		// Partitions match the murmur2 hashes computed by the Java client.
+		expected := map[int32][]broker.Message{
+			0: {messages[1]},
+			2: {messages[0], messages[3]},
+			3: {messages[2]},
+		}
+		got := kafka.produced()
+		if len(got) != len(expected) {
+			t.Fatalf("expected = %v, got = %v", expected, got)
+		}
+
+		for partition, messages := range expected {
+			assertMessages(t, messages, got[partition])
+		}
+	})
+
+	t.Run("Exporter", func(t *testing.T) {
+		t.Parallel()
+
+		kafka := newKafkaBroker(t)
+		transport, err := broker.NewKafkaTransport(broker.KafkaConfig{
+			Brokers: []string{kafka.listener.Addr().String()},
+			Ack:     broker.KafkaAckLeader,
+		})
+		if err != nil {
+			t.Fatalf("failed creating transport: %v", err)
+		}
+
+		e, err := broker.NewExporter(transport, broker.Config{Topic: "sql", BatchSize: 3})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		defer func() {
+			_ = e.Close()
+		}()
+
+		statements := testStatements(8)
+		err = e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		count := 0
+		for _, messages := range kafka.produced() {
+			count += len(messages)
+			for _, message := range messages {
+				if message.Key != messages[0].Key {
+					t.Fatalf("expected partition to contain a single key, got = %s, %s", messages[0].Key, message.Key)
+				}
+			}
+		}
+
+		if count != len(statements) {
+			t.Fatalf("expected = %d messages, got = %d", len(statements), count)
+		}
+	})
+
+	t.Run("BrokerError", func(t *testing.T) {
+		t.Parallel()
+
+		kafka := newKafkaBroker(t)
+		kafka.errorCode = 6 // not leader for partition
+		transport, err := broker.NewKafkaTransport(broker.KafkaConfig{
+			Brokers: []string{kafka.listener.Addr().String()},
+		})
+		if err != nil {
+			t.Fatalf("failed creating transport: %v", err)
+		}
+
+		defer func() {
+			_ = transport.Close()
+		}()
+
+		err = transport.Publish(t.Context(), []broker.Message{{Topic: "sql", Key: "a", Value: []byte("1")}})
+		if !errors.Is(err, broker.ErrKafkaBroker) {
+			t.Fatalf("expected broker error: got = %v", err)
+		}
+	})
+}
+
+func assertMessages(t *testing.T, expected, got []broker.Message) {
+	t.Helper()
+
+	if len(expected) != len(got) {
+		t.Fatalf("expected = %v, got = %v", expected, got)
+	}
+
+	for i := range expected {
+		if expected[i].Topic != got[i].Topic || expected[i].Key != got[i].Key ||
+			!bytes.Equal(expected[i].Value, got[i].Value) {
+			t.Fatalf("expected = %v, got = %v", expected[i], got[i])
+		}
+	}
+}
+
+func readInt16(r io.Reader) (v int16) {
+	_ = binary.Read(r, binary.BigEndian, &v)
+	return v
+}
+
+func readInt32(r io.Reader) (v int32) {
+	_ = binary.Read(r, binary.BigEndian, &v)
+	return v
+}
+
+func readString(r io.Reader) string {
+	n := readInt16(r)
+	if n < 0 {
+		return ""
+	}
+
+	b := make([]byte, n)
+	_, _ = io.ReadFull(r, b)
+	return string(b)
+}
+
+func writeInt16(w io.Writer, v int16) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func writeInt32(w io.Writer, v int32) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func writeInt64(w io.Writer, v int64) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func writeString(w io.Writer, v string) {
+	writeInt16(w, int16(len(v)))
+	_, _ = io.WriteString(w, v)
+}
+
+// kafkaServerEnv names the variable with the address of a real Kafka broker the transport is tested against.
+// The broker has to create topics automatically.
+const kafkaServerEnv = "BROKER_TEST_KAFKA_ADDRESS"
+
+func TestKafkaServer(t *testing.T) {
+	t.Parallel()
+
+	address := os.Getenv(kafkaServerEnv)
+	if address == "" {
+		t.Skipf("%s is not set", kafkaServerEnv)
+	}
+
+	transport, err := broker.NewKafkaTransport(broker.KafkaConfig{Brokers: []string{address}})
+	if err != nil {
+		t.Fatalf("failed creating transport: %v", err)
+	}
+
+	e, err := broker.NewExporter(transport, broker.Config{Topic: "sql-processor-test", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	defer func() {
+		_ = e.Close()
+	}()
+
+	// The topic is created by the first metadata request and gets its leader shortly after.
+	deadline := time.Now().Add(serverTestTimeout)
+	for {
+		err = e.ExportBatch(testStatements(5))
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+
+		time.Sleep(serverTestTimeout / 10)
+	}
+
+	if err != nil {
+		t.Fatalf("failed publishing to %s: %v", address, err)
+	}
+}
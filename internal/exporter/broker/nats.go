@@ -0,0 +1,425 @@
+package broker
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNATSAddress = "127.0.0.1:4222"
+	defaultNATSTimeout = 5 * time.Second
+	natsClientName     = "sql-processor"
+	natsKeyHeader      = "Message-Key"
+	natsInboxPrefix    = "_INBOX."
+	natsInboxSID       = "1"
+	natsInboxIDSize    = 8
+	natsMaxControlLine = 4096
+)
+
+var (
+	ErrUnknownNATSAck = errors.New("unknown nats ack mode")
+	ErrNATSServer     = errors.New("nats server error")
+	ErrNATSProtocol   = errors.New("nats protocol violation")
+)
+
+var _ Transport = &NATSTransport{}
+
+// NATSAck represents how [NATSTransport] waits for published messages to be acknowledged.
+type NATSAck string
+
+const (
+	// NATSAckNone does not wait for any acknowledgement.
+	NATSAckNone NATSAck = "none"
+	// NATSAckFlush waits until the server processes the messages.
+	NATSAckFlush NATSAck = "flush"
+	// NATSAckJetStream waits until a JetStream stream persists the messages.
+	NATSAckJetStream NATSAck = "jetstream"
+)
+
+// NATSConfig represents [NATSTransport] configuration.
+type NATSConfig struct {
+	// Address is the server host and port. Defaults to "127.0.0.1:4222".
+	Address string
+	// Ack sets how published messages are acknowledged. Defaults to [NATSAckFlush].
+	Ack NATSAck
+	// Timeout limits connecting and waiting for acknowledgements. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+// NATSTransport implements [Transport] for NATS using its client protocol.
+//
+// Only the publishing part of the protocol is implemented: PUB and HPUB acknowledged
+// by PING or by JetStream replies. A full client library would bring subscriptions,
+// authentication schemes and their dependencies the exporter does not use.
+//
+// Message keys are sent in the Message-Key header when the server supports headers.
+// The connection is established lazily and re-established after a failure.
+type NATSTransport struct {
+	config NATSConfig
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+// NewNATSTransport creates a new [NATSTransport] from the given [NATSConfig].
+func NewNATSTransport(config NATSConfig) (*NATSTransport, error) {
+	if config.Address == "" {
+		config.Address = defaultNATSAddress
+	}
+
+	switch config.Ack {
+	case "":
+		config.Ack = NATSAckFlush
+	case NATSAckNone, NATSAckFlush, NATSAckJetStream:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNATSAck, config.Ack)
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = defaultNATSTimeout
+	}
+
+	return &NATSTransport{config: config}, nil
+}
+
+// Publish implements Transport.
+func (t *NATSTransport) Publish(ctx context.Context, messages []Message) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		t.conn, err = dialNATS(ctx, t.config)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = t.conn.publish(ctx, messages, t.config)
+	if err != nil {
+		_ = t.conn.close()
+		t.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// Close implements Transport.
+func (t *NATSTransport) Close() (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+
+	err = t.conn.close()
+	t.conn = nil
+	return err
+}
+
+// natsConn is a single NATS connection with a goroutine reading server messages.
+type natsConn struct {
+	conn    net.Conn
+	headers bool
+	inbox   string
+
+	writeMu sync.Mutex
+	writer  *bufio.Writer
+
+	pongCh  chan struct{}
+	replyCh chan []byte
+	errCh   chan error
+	closeCh chan struct{}
+	doneCh  chan struct{}
+}
+
+type natsInfo struct {
+	Headers bool `json:"headers"`
+}
+
+func dialNATS(ctx context.Context, config NATSConfig) (c *natsConn, err error) {
+	dialer := net.Dialer{Timeout: config.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", config.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to nats: %w", err)
+	}
+
+	c = &natsConn{
+		conn:    conn,
+		writer:  bufio.NewWriter(conn),
+		pongCh:  make(chan struct{}, 1),
+		replyCh: make(chan []byte, 1),
+		errCh:   make(chan error, 1),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+
+	reader := bufio.NewReaderSize(conn, natsMaxControlLine)
+	_ = conn.SetReadDeadline(time.Now().Add(config.Timeout))
+	line, err := reader.ReadString('\n')
+	_ = conn.SetReadDeadline(time.Time{})
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed reading nats server info: %w", err)
+	}
+
+	payload, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: expected INFO, got %q", ErrNATSProtocol, line)
+	}
+
+	var info natsInfo
+	err = json.Unmarshal([]byte(payload), &info)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed decoding nats server info: %w", err)
+	}
+
+	c.headers = info.Headers
+	go c.read(reader)
+
+	connect, _ := json.Marshal(map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     natsClientName,
+		"lang":     "go",
+		"headers":  c.headers,
+	})
+	commands := "CONNECT " + string(connect) + "\r\n"
+	if config.Ack == NATSAckJetStream {
+		c.inbox = natsInboxPrefix + randomID()
+		commands += "SUB " + c.inbox + ".* " + natsInboxSID + "\r\n"
+	}
+
+	err = c.write(commands + "PING\r\n")
+	if err == nil {
+		err = c.awaitPong(ctx, config.Timeout)
+	}
+
+	if err != nil {
+		_ = c.close()
+		return nil, fmt.Errorf("failed connecting to nats: %w", err)
+	}
+
+	return c, nil
+}
+
+func (c *natsConn) publish(ctx context.Context, messages []Message, config NATSConfig) error {
+	var builder strings.Builder
+	for i, message := range messages {
+		reply := ""
+		if config.Ack == NATSAckJetStream {
+			reply = " " + c.inbox + "." + strconv.Itoa(i)
+		}
+
+		if !c.headers {
+			fmt.Fprintf(&builder, "PUB %s%s %d\r\n", message.Topic, reply, len(message.Value))
+			builder.Write(message.Value)
+			builder.WriteString("\r\n")
+			continue
+		}
+
+		key := strings.NewReplacer("\r", " ", "\n", " ").Replace(message.Key)
+		header := "NATS/1.0\r\n" + natsKeyHeader + ": " + key + "\r\n\r\n"
+		fmt.Fprintf(&builder, "HPUB %s%s %d %d\r\n", message.Topic, reply, len(header), len(header)+len(message.Value))
+		builder.WriteString(header)
+		builder.Write(message.Value)
+		builder.WriteString("\r\n")
+	}
+
+	switch config.Ack {
+	case NATSAckFlush:
+		builder.WriteString("PING\r\n")
+	case NATSAckNone, NATSAckJetStream:
+	}
+
+	err := c.write(builder.String())
+	if err != nil {
+		return err
+	}
+
+	switch config.Ack {
+	case NATSAckFlush:
+		return c.awaitPong(ctx, config.Timeout)
+	case NATSAckJetStream:
+		return c.awaitJetStreamAcks(ctx, len(messages), config.Timeout)
+	default:
+		return nil
+	}
+}
+
+func (c *natsConn) awaitPong(ctx context.Context, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-c.pongCh:
+		return nil
+	case err := <-c.errCh:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out waiting for nats acknowledgement: %w", context.DeadlineExceeded)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type jetStreamAck struct {
+	Stream string `json:"stream"`
+	Error  *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+func (c *natsConn) awaitJetStreamAcks(ctx context.Context, count int, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for range count {
+		select {
+		case reply := <-c.replyCh:
+			var ack jetStreamAck
+			err := json.Unmarshal(reply, &ack)
+			if err != nil {
+				return fmt.Errorf("failed decoding jetstream acknowledgement: %w", err)
+			}
+
+			if ack.Error != nil {
+				return fmt.Errorf("%w: jetstream: %s (%d)", ErrNATSServer, ack.Error.Description, ack.Error.Code)
+			}
+		case err := <-c.errCh:
+			return err
+		case <-timer.C:
+			return fmt.Errorf("timed out waiting for jetstream acknowledgement: %w", context.DeadlineExceeded)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// read reads server messages until the connection gets closed.
+func (c *natsConn) read(reader *bufio.Reader) {
+	defer close(c.doneCh)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			c.fail(fmt.Errorf("failed reading from nats: %w", err))
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		operation, args, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(operation) {
+		case "PING":
+			err = c.write("PONG\r\n")
+		case "PONG":
+			select {
+			case c.pongCh <- struct{}{}:
+			default:
+			}
+		case "MSG", "HMSG":
+			err = c.readMessage(reader, strings.ToUpper(operation) == "HMSG", strings.Fields(args))
+		case "-ERR":
+			c.fail(fmt.Errorf("%w: %s", ErrNATSServer, strings.Trim(args, "'")))
+		case "+OK", "INFO":
+		default:
+			err = fmt.Errorf("%w: unexpected operation %q", ErrNATSProtocol, operation)
+		}
+
+		if err != nil {
+			c.fail(err)
+			return
+		}
+	}
+}
+
+// readMessage reads message payload. Only replies to the inbox are expected.
+func (c *natsConn) readMessage(reader *bufio.Reader, headers bool, args []string) error {
+	minArgs := 3
+	if headers {
+		minArgs = 4
+	}
+
+	if len(args) < minArgs {
+		return fmt.Errorf("%w: invalid message arguments %v", ErrNATSProtocol, args)
+	}
+
+	total, err := strconv.Atoi(args[len(args)-1])
+	if err != nil {
+		return fmt.Errorf("%w: invalid message size: %w", ErrNATSProtocol, err)
+	}
+
+	headerSize := 0
+	if headers {
+		headerSize, err = strconv.Atoi(args[len(args)-2])
+		if err != nil || headerSize > total {
+			return fmt.Errorf("%w: invalid message header size", ErrNATSProtocol)
+		}
+	}
+
+	payload := make([]byte, total+len("\r\n"))
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return fmt.Errorf("failed reading nats message: %w", err)
+	}
+
+	select {
+	case c.replyCh <- payload[headerSize:total]:
+	case <-c.closeCh:
+	}
+
+	return nil
+}
+
+func (c *natsConn) write(commands string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.writer.WriteString(commands)
+	if err == nil {
+		err = c.writer.Flush()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed writing to nats: %w", err)
+	}
+
+	return nil
+}
+
+func (c *natsConn) fail(err error) {
+	select {
+	case c.errCh <- err:
+	default:
+	}
+}
+
+func (c *natsConn) close() error {
+	close(c.closeCh)
+	err := c.conn.Close()
+	<-c.doneCh
+	return err
+}
+
+func randomID() string {
+	id := make([]byte, natsInboxIDSize)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,272 @@
+package broker_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/broker"
+)
+
+// natsServer is a minimal in-process NATS server recording published messages.
+type natsServer struct {
+	listener  net.Listener
+	headers   bool
+	jetStream bool
+	ackError  bool
+
+	mu       sync.Mutex
+	messages []broker.Message
+}
+
+func newNATSServer(t *testing.T, headers, jetStream bool) *natsServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed listening: %v", err)
+	}
+
+	s := &natsServer{listener: listener, headers: headers, jetStream: jetStream}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go s.serve()
+	return s
+}
+
+func (s *natsServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *natsServer) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"headers\":%t}\r\n", s.headers)
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "PING":
+			_, _ = io.WriteString(conn, "PONG\r\n")
+		case "PUB", "HPUB":
+			err = s.publish(conn, reader, fields)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *natsServer) publish(conn net.Conn, reader *bufio.Reader, fields []string) error {
+	total, _ := strconv.Atoi(fields[len(fields)-1])
+	headerSize := 0
+	if fields[0] == "HPUB" {
+		headerSize, _ = strconv.Atoi(fields[len(fields)-2])
+	}
+
+	payload := make([]byte, total+2)
+	_, err := io.ReadFull(reader, payload)
+	if err != nil {
+		return err
+	}
+
+	message := broker.Message{Topic: fields[1], Value: payload[headerSize:total]}
+	for header := range strings.SplitSeq(string(payload[:headerSize]), "\r\n") {
+		if key, ok := strings.CutPrefix(header, "Message-Key: "); ok {
+			message.Key = key
+		}
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, message)
+	s.mu.Unlock()
+
+	replyArgs := 4
+	if fields[0] == "PUB" {
+		replyArgs = 3
+	}
+
+	if !s.jetStream || len(fields) <= replyArgs {
+		return nil
+	}
+
+	reply := `{"stream":"sql","seq":1}`
+	if s.ackError {
+		reply = `{"error":{"code":503,"description":"no responders"}}`
+	}
+
+	_, err = fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2], len(reply), reply)
+	return err
+}
+
+func (s *natsServer) published() []broker.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.messages
+}
+
+func TestNATSTransport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("UnknownAck", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := broker.NewNATSTransport(broker.NATSConfig{Ack: "maybe"})
+		if !errors.Is(err, broker.ErrUnknownNATSAck) {
+			t.Fatalf("expected unknown ack error: got = %v", err)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		headers bool
+		ack     broker.NATSAck
+	}{
+		{name: "Flush", headers: true, ack: broker.NATSAckFlush},
+		{name: "NoHeaders", headers: false, ack: broker.NATSAckFlush},
+		{name: "JetStream", headers: true, ack: broker.NATSAckJetStream},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newNATSServer(t, test.headers, test.ack == broker.NATSAckJetStream)
+			transport, err := broker.NewNATSTransport(broker.NATSConfig{
+				Address: server.listener.Addr().String(),
+				Ack:     test.ack,
+			})
+			if err != nil {
+				t.Fatalf("failed creating transport: %v", err)
+			}
+
+			e, err := broker.NewExporter(transport, broker.Config{Topic: "sql.statements", BatchSize: 2})
+			if err != nil {
+				t.Fatalf("failed creating exporter: %v", err)
+			}
+
+			defer func() {
+				_ = e.Close()
+			}()
+
+			statements := testStatements(3)
+			err = e.ExportBatch(statements)
+			if err != nil {
+				t.Fatalf("failed exporting statements: %v", err)
+			}
+
+			messages := server.published()
+			if len(messages) != len(statements) {
+				t.Fatalf("expected = %d messages, got = %d", len(statements), len(messages))
+			}
+
+			for i, message := range messages {
+				if !test.headers {
+					message.Key = statements[i].File.Path
+				}
+
+				assertMessage(t, message, "sql.statements", statements[i])
+			}
+		})
+	}
+
+	t.Run("JetStreamError", func(t *testing.T) {
+		t.Parallel()
+
+		server := newNATSServer(t, true, true)
+		server.ackError = true
+		transport, err := broker.NewNATSTransport(broker.NATSConfig{
+			Address: server.listener.Addr().String(),
+			Ack:     broker.NATSAckJetStream,
+		})
+		if err != nil {
+			t.Fatalf("failed creating transport: %v", err)
+		}
+
+		defer func() {
+			_ = transport.Close()
+		}()
+
+		err = transport.Publish(context.Background(), []broker.Message{{Topic: "sql", Value: []byte("{}")}})
+		if !errors.Is(err, broker.ErrNATSServer) {
+			t.Fatalf("expected server error: got = %v", err)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		server := newNATSServer(t, true, false)
+		address := server.listener.Addr().String()
+		_ = server.listener.Close()
+
+		transport, err := broker.NewNATSTransport(broker.NATSConfig{Address: address})
+		if err != nil {
+			t.Fatalf("failed creating transport: %v", err)
+		}
+
+		err = transport.Publish(context.Background(), []broker.Message{{Topic: "sql", Value: []byte("{}")}})
+		if err == nil {
+			t.Fatal("expected publishing to fail")
+		}
+	})
+}
+
+// natsServerEnv names the variable with the address of a real NATS server the transport is tested against.
+const natsServerEnv = "BROKER_TEST_NATS_ADDRESS"
+
+func TestNATSServer(t *testing.T) {
+	t.Parallel()
+
+	address := os.Getenv(natsServerEnv)
+	if address == "" {
+		t.Skipf("%s is not set", natsServerEnv)
+	}
+
+	transport, err := broker.NewNATSTransport(broker.NATSConfig{Address: address, Timeout: serverTestTimeout})
+	if err != nil {
+		t.Fatalf("failed creating transport: %v", err)
+	}
+
+	e, err := broker.NewExporter(transport, broker.Config{Topic: "sql-processor.test", BatchSize: 2})
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	defer func() {
+		_ = e.Close()
+	}()
+
+	err = e.ExportBatch(testStatements(5))
+	if err != nil {
+		t.Fatalf("failed publishing to %s: %v", address, err)
+	}
+}
@@ -0,0 +1,387 @@
+package database
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	// databasePartCount is the number of parts of a [FactoryConfig] database.
+	databasePartCount = 3
+	// maxFailures is the number of failed files remembered. The oldest failure is forgotten first.
+	maxFailures = 1024
+)
+
+var (
+	ErrNoDatabases     = errors.New("no databases configured")
+	ErrNoDatabase      = errors.New("no database configured for sql type")
+	ErrUnknownMode     = errors.New("unknown execution mode")
+	ErrFileFailed      = errors.New("previous statement of the file failed")
+	ErrInvalidDatabase = errors.New("invalid database")
+)
+
+var (
+	_ exporter.Exporter        = &Exporter{}
+	_ exporter.ContextExporter = &Exporter{}
+	_ exporter.Closer          = &Exporter{}
+)
+
+// Mode represents the way [Exporter] applies statements to a database.
+type Mode string
+
+const (
+	// ModeExecute executes the statements.
+	ModeExecute Mode = "execute"
+	// ModePrepare only prepares the statements. It is a dry-run mode.
+	ModePrepare Mode = "prepare"
+	// ModeExplain only explains the statements using EXPLAIN. It is a dry-run mode.
+	ModeExplain Mode = "explain"
+)
+
+// DryRun reports whether the [Mode] leaves the database unchanged.
+func (m Mode) DryRun() bool {
+	return m == ModePrepare || m == ModeExplain
+}
+
+// Database represents a [database/sql] data source.
+type Database struct {
+	Driver string
+	DSN    string
+}
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Databases maps SQL dialects to databases the statements are applied to.
+	Databases map[sql.Type]Database
+	// Transactions wraps statements of a single file in a transaction.
+	//
+	// A transaction spans the statements of the file within one exported batch. A file split
+	// across batches by the batch limits of the [exporter.Manager] is committed batch by batch,
+	// so statements of an earlier batch stay applied when a later one fails.
+	Transactions bool
+	// Mode sets how the statements are applied. Defaults to [ModeExecute].
+	Mode Mode
+}
+
+// Exporter implements [exporter.Exporter] and applies given [sql.Statement]s to databases.
+//
+// Statements are routed to a database by their [sql.Type]. The exporter stops
+// applying statements of a file after its first failure.
+//
+// Without transactions, the statements applied before the failure stay applied.
+// Exporting the failed statements again, as retries do, resumes at the failed statement
+// rather than applying them twice. Other statements of the file starting at or before
+// the failed line start the file over as it was most likely written again.
+type Exporter struct {
+	mu           sync.Mutex
+	mode         Mode
+	transactions bool
+	databases    map[sql.Type]*stdsql.DB
+	// failures maps paths of failed files to their failures.
+	failures map[string]failure
+	// failed are the paths of failed files from the oldest failure.
+	failed []string
+}
+
+// failure represents the failure of a file.
+type failure struct {
+	// line is the line of the failed statement.
+	line int
+	// statements are the exported statements of the file the failed statement was part of.
+	statements []sql.Statement
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+func NewExporter(config Config) (e *Exporter, err error) {
+	if len(config.Databases) == 0 {
+		return nil, ErrNoDatabases
+	}
+
+	mode := config.Mode
+	switch mode {
+	case "":
+		mode = ModeExecute
+	case ModeExecute, ModePrepare, ModeExplain:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMode, mode)
+	}
+
+	databases := make(map[sql.Type]*stdsql.DB, len(config.Databases))
+	for sqlType, database := range config.Databases {
+		db, err := stdsql.Open(database.Driver, database.DSN)
+		if err != nil {
+			closeDatabases(databases)
+			return nil, fmt.Errorf("failed opening %s database: %w", sqlType, err)
+		}
+
+		databases[sqlType] = db
+	}
+
+	return &Exporter{
+		mode:         mode,
+		transactions: config.Transactions,
+		databases:    databases,
+		failures:     make(map[string]failure),
+	}, nil
+}
+
+// FactoryConfig represents configuration of an [Exporter] used by [Register].
+type FactoryConfig struct {
+	// Database are the databases in the "TYPE:DRIVER:DSN" form, such as "sqlite:sqlite3:/var/lib/sql.db".
+	Database []string
+	// Transactions wraps statements of a single file in a transaction, see [Config].
+	Transactions bool
+	// Mode sets how the statements are applied. Defaults to [ModeExecute].
+	Mode Mode
+}
+
+// Register registers the "database" exporter type configured by [FactoryConfig].
+// The database drivers have to be registered with [database/sql] by the importing program.
+// The processor registers the "sqlite3" driver only when built with cgo.
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "database", func(config FactoryConfig) (exporter.Exporter, error) {
+		databases, err := config.databases()
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := NewExporter(Config{Databases: databases, Transactions: config.Transactions, Mode: config.Mode})
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+func (c FactoryConfig) databases() (map[sql.Type]Database, error) {
+	databases := make(map[sql.Type]Database, len(c.Database))
+	for _, database := range c.Database {
+		parts := strings.SplitN(database, ":", databasePartCount)
+		if len(parts) != databasePartCount || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("%w: %s, expected TYPE:DRIVER:DSN", ErrInvalidDatabase, database)
+		}
+
+		sqlType, err := sql.ParseType(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidDatabase, database, err)
+		}
+
+		databases[sqlType] = Database{Driver: parts[1], DSN: parts[2]}
+	}
+
+	return databases, nil
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), []sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+// ExportContext implements exporter.ContextExporter.
+func (e *Exporter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	return e.ExportBatchContext(ctx, []sql.Statement{statement})
+}
+
+// ExportBatchContext implements exporter.ContextExporter.
+//
+// Consecutive statements of the same file are applied together.
+// When transactions are enabled, each such group runs in its own transaction,
+// so the transaction never spans more than the given statements.
+func (e *Exporter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for start := 0; start < len(statements); {
+		end := start + 1
+		for end < len(statements) && statements[end].File.Path == statements[start].File.Path {
+			end++
+		}
+
+		err = errors.Join(err, e.exportFile(ctx, statements[start:end]))
+		start = end
+	}
+
+	return err
+}
+
+// Close implements exporter.Closer.
+// It closes all databases of the [Exporter].
+func (e *Exporter) Close() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return closeDatabases(e.databases)
+}
+
+func (e *Exporter) exportFile(ctx context.Context, statements []sql.Statement) (err error) {
+	file := statements[0].File
+	failure, failed := e.failures[file.Path]
+	if failed && statements[0].LineNum > failure.line {
+		return fmt.Errorf("%w: %s:%d", ErrFileFailed, file.Path, failure.line)
+	}
+
+	db, ok := e.databases[file.Type]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoDatabase, file.Type)
+	}
+
+	pending := statements
+	if failed && !e.transactions && slices.Equal(statements, failure.statements) {
+		// The failed statements are exported again, those before the failed one were applied.
+		pending = slices.DeleteFunc(slices.Clone(statements), func(statement sql.Statement) bool {
+			return statement.LineNum < failure.line
+		})
+	}
+
+	err = e.applyFile(ctx, db, pending)
+	if err != nil {
+		if line, ok := failedLine(err); ok {
+			e.fail(file.Path, line, statements)
+		}
+
+		return err
+	}
+
+	// The file either never failed, resumed or started over.
+	e.forget(file.Path)
+	return nil
+}
+
+// fail remembers the failure of the file, forgetting the oldest failure when there are too many.
+func (e *Exporter) fail(path string, line int, statements []sql.Statement) {
+	if _, ok := e.failures[path]; !ok {
+		if len(e.failed) >= maxFailures {
+			delete(e.failures, e.failed[0])
+			e.failed = e.failed[1:]
+		}
+
+		e.failed = append(e.failed, path)
+	}
+
+	e.failures[path] = failure{line: line, statements: slices.Clone(statements)}
+}
+
+func (e *Exporter) forget(path string) {
+	if _, ok := e.failures[path]; !ok {
+		return
+	}
+
+	delete(e.failures, path)
+	e.failed = slices.DeleteFunc(e.failed, func(failed string) bool {
+		return failed == path
+	})
+}
+
+func (e *Exporter) applyFile(ctx context.Context, db *stdsql.DB, statements []sql.Statement) (err error) {
+	file := statements[0].File
+	if !e.transactions {
+		return e.apply(ctx, db, statements)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed beginning transaction for %s: %w", file.Path, err)
+	}
+
+	err = e.apply(ctx, tx, statements)
+	if err != nil || e.mode.DryRun() {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed committing transaction for %s: %w", file.Path, err)
+	}
+
+	return nil
+}
+
+func (e *Exporter) apply(ctx context.Context, db executor, statements []sql.Statement) error {
+	for _, statement := range statements {
+		err := e.applyStatement(ctx, db, statement)
+		if err != nil {
+			return &statementError{line: statement.LineNum, err: fmt.Errorf(
+				"failed applying %s:%d: %w", statement.File.Path, statement.LineNum, err,
+			)}
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) applyStatement(ctx context.Context, db executor, statement sql.Statement) error {
+	switch e.mode {
+	case ModePrepare:
+		stmt, err := db.PrepareContext(ctx, statement.Content)
+		if err != nil {
+			return err
+		}
+
+		return stmt.Close()
+	case ModeExplain:
+		rows, err := db.QueryContext(ctx, "EXPLAIN "+statement.Content)
+		if err != nil {
+			return err
+		}
+
+		return errors.Join(rows.Err(), rows.Close())
+	default:
+		_, err := db.ExecContext(ctx, statement.Content)
+		return err
+	}
+}
+
+// statementError is returned when a statement fails to apply.
+type statementError struct {
+	line int
+	err  error
+}
+
+// Error implements error.
+func (e *statementError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *statementError) Unwrap() error {
+	return e.err
+}
+
+// failedLine returns the line of the failed statement, if any.
+func failedLine(err error) (line int, ok bool) {
+	var statementErr *statementError
+	if !errors.As(err, &statementErr) {
+		return 0, false
+	}
+
+	return statementErr.line, true
+}
+
+// executor is satisfied by both [stdsql.DB] and [stdsql.Tx].
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (stdsql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*stdsql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*stdsql.Rows, error)
+}
+
+func closeDatabases(databases map[sql.Type]*stdsql.DB) (err error) {
+	for _, db := range databases {
+		err = errors.Join(err, db.Close())
+	}
+
+	return err
+}
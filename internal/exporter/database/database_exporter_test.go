@@ -0,0 +1,389 @@
+//go:build cgo
+
+// The tests run on SQLite whose driver requires cgo.
+
+package database_test
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/database"
+	"github.com/course-go/sql-processor/internal/sql"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const driverName = "sqlite3"
+
+func TestExporter(t *testing.T) { //nolint: gocognit
+	t.Parallel()
+
+	file1 := sql.File{
+		Path: "test1.sql",
+		Type: sql.SQLite,
+	}
+	file2 := sql.File{
+		Path: "test2.sql",
+		Type: sql.SQLite,
+	}
+	statements := []sql.Statement{
+		{Content: "CREATE TABLE users (id INT, name TEXT)", LineNum: 1, File: file1},
+		{Content: "INSERT INTO missing VALUES (1, 'John')", LineNum: 2, File: file1},
+		{Content: "INSERT INTO users VALUES (2, 'Jane')", LineNum: 3, File: file1},
+		{Content: "CREATE TABLE audit (id INT)", LineNum: 1, File: file2},
+	}
+
+	t.Run("NoDatabases", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := database.NewExporter(database.Config{})
+		if !errors.Is(err, database.ErrNoDatabases) {
+			t.Fatalf("expected no databases error: got = %v", err)
+		}
+	})
+
+	t.Run("UnknownMode", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := database.NewExporter(newConfig(t, false, "unknown"))
+		if !errors.Is(err, database.ErrUnknownMode) {
+			t.Fatalf("expected unknown mode error: got = %v", err)
+		}
+	})
+
+	t.Run("UnconfiguredType", func(t *testing.T) {
+		t.Parallel()
+
+		e := newExporter(t, newConfig(t, false, database.ModeExecute))
+
+		err := e.Export(sql.Statement{Content: "SELECT 1", LineNum: 1, File: sql.File{Path: "a.sql", Type: sql.MySQL}})
+		if !errors.Is(err, database.ErrNoDatabase) {
+			t.Fatalf("expected no database error: got = %v", err)
+		}
+	})
+
+	t.Run("Execute", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, false, database.ModeExecute)
+		e := newExporter(t, config)
+
+		for _, statement := range statements {
+			_ = e.Export(statement)
+		}
+
+		expected := []string{"audit", "users"}
+		if tables := tables(t, config); !slices.Equal(tables, expected) {
+			t.Fatalf("tables do not match: expected = %v, got = %v", expected, tables)
+		}
+
+		if count := rowCount(t, config, "users"); count != 0 {
+			t.Fatalf("statements after the failure were applied: expected = 0, got = %v", count)
+		}
+	})
+
+	t.Run("ExecuteStopsAtFirstFailure", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, false, database.ModeExecute)
+		e := newExporter(t, config)
+
+		err := e.ExportBatch(statements[:2])
+		if err == nil || errors.Is(err, database.ErrFileFailed) {
+			t.Fatalf("expected statement failure: got = %v", err)
+		}
+
+		// A new observation of the same file carries a different trace.
+		statement := statements[2]
+		statement.File.Trace = "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+
+		err = e.Export(statement)
+		if !errors.Is(err, database.ErrFileFailed) {
+			t.Fatalf("expected file failed error: got = %v", err)
+		}
+
+		if count := rowCount(t, config, "users"); count != 0 {
+			t.Fatalf("statements after the failure were applied: expected = 0, got = %v", count)
+		}
+	})
+
+	t.Run("ExecuteFileStartsOver", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, false, database.ModeExecute)
+		e := newExporter(t, config)
+
+		err := e.ExportBatch(statements[:2])
+		if err == nil {
+			t.Fatalf("expected statement failure")
+		}
+
+		// The file was written again and fixed.
+		fixed := []sql.Statement{
+			{Content: "CREATE TABLE IF NOT EXISTS users (id INT, name TEXT)", LineNum: 1, File: file1},
+			{Content: "INSERT INTO users VALUES (1, 'John')", LineNum: 2, File: file1},
+		}
+
+		err = e.ExportBatch(fixed)
+		if err != nil {
+			t.Fatalf("failed exporting the fixed file: %v", err)
+		}
+
+		err = e.Export(statements[2])
+		if err != nil {
+			t.Fatalf("failed exporting the rest of the fixed file: %v", err)
+		}
+
+		if count := rowCount(t, config, "users"); count != 2 { //nolint: mnd
+			t.Fatalf("row count does not match: expected = 2, got = %v", count)
+		}
+	})
+
+	t.Run("ExecuteRetryResumes", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, false, database.ModeExecute)
+		e := newExporter(t, config)
+
+		batch := []sql.Statement{
+			{Content: "CREATE TABLE users (id INT, name TEXT)", LineNum: 1, File: file1},
+			{Content: "INSERT INTO users VALUES (1, 'John')", LineNum: 2, File: file1},
+			{Content: "INSERT INTO audit VALUES (1)", LineNum: 3, File: file1},
+		}
+
+		err := e.ExportBatch(batch)
+		if err == nil {
+			t.Fatalf("expected statement failure")
+		}
+
+		_, err = openDatabase(t, config).ExecContext(t.Context(), "CREATE TABLE audit (id INT)")
+		if err != nil {
+			t.Fatalf("failed creating table: %v", err)
+		}
+
+		err = e.ExportBatch(batch)
+		if err != nil {
+			t.Fatalf("failed retrying batch: %v", err)
+		}
+
+		// The statements applied before the failure are not applied again.
+		if count := rowCount(t, config, "users"); count != 1 {
+			t.Fatalf("row count does not match: expected = 1, got = %v", count)
+		}
+
+		if count := rowCount(t, config, "audit"); count != 1 {
+			t.Fatalf("row count does not match: expected = 1, got = %v", count)
+		}
+	})
+
+	t.Run("ForgetsOldestFailures", func(t *testing.T) {
+		t.Parallel()
+
+		e := newExporter(t, newConfig(t, false, database.ModeExecute))
+
+		const files = 1025
+		for i := range files {
+			file := sql.File{Path: fmt.Sprintf("test%d.sql", i), Type: sql.SQLite}
+			err := e.Export(sql.Statement{Content: "INSERT INTO missing VALUES (1)", LineNum: 1, File: file})
+			if err == nil {
+				t.Fatalf("expected statement failure")
+			}
+		}
+
+		last := sql.Statement{Content: "SELECT 1", LineNum: 2, File: sql.File{Path: "test1024.sql", Type: sql.SQLite}}
+		err := e.Export(last)
+		if !errors.Is(err, database.ErrFileFailed) {
+			t.Fatalf("expected file failed error: got = %v", err)
+		}
+
+		first := sql.Statement{Content: "SELECT 1", LineNum: 2, File: sql.File{Path: "test0.sql", Type: sql.SQLite}}
+		err = e.Export(first)
+		if err != nil {
+			t.Fatalf("expected the oldest failure to be forgotten: got = %v", err)
+		}
+	})
+
+	t.Run("Transactions", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, true, database.ModeExecute)
+		e := newExporter(t, config)
+
+		err := e.ExportBatch(statements)
+		if err == nil {
+			t.Fatalf("expected statement failure")
+		}
+
+		// The first file is rolled back as a whole.
+		expected := []string{"audit"}
+		if tables := tables(t, config); !slices.Equal(tables, expected) {
+			t.Fatalf("tables do not match: expected = %v, got = %v", expected, tables)
+		}
+	})
+
+	t.Run("DryRunPrepare", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, true, database.ModePrepare)
+		e := newExporter(t, config)
+
+		err := e.ExportBatch([]sql.Statement{statements[0], statements[3]})
+		if err != nil {
+			t.Fatalf("failed exporting batch: %v", err)
+		}
+
+		if tables := tables(t, config); len(tables) != 0 {
+			t.Fatalf("dry run created tables: %v", tables)
+		}
+
+		err = e.Export(statements[1])
+		if err == nil {
+			t.Fatalf("expected preparing statement of a missing table to fail")
+		}
+	})
+
+	t.Run("DryRunExplain", func(t *testing.T) {
+		t.Parallel()
+
+		config := newConfig(t, false, database.ModeExplain)
+		e := newExporter(t, config)
+
+		err := e.Export(statements[0])
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		if tables := tables(t, config); len(tables) != 0 {
+			t.Fatalf("dry run created tables: %v", tables)
+		}
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "test.db")
+		registry := exporter.NewRegistry()
+		database.Register(registry)
+
+		_, err := registry.CreateFromSpec("database:database=sqlite:" + driverName)
+		if !errors.Is(err, database.ErrInvalidDatabase) {
+			t.Fatalf("expected invalid database error: got = %v", err)
+		}
+
+		e, err := registry.CreateFromSpec("database:database=sqlite:" + driverName + ":" + path + ",transactions=true")
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		t.Cleanup(func() {
+			_ = e.(exporter.Closer).Close()
+		})
+
+		err = e.Export(statements[3])
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		config := database.Config{
+			Databases: map[sql.Type]database.Database{sql.SQLite: {Driver: driverName, DSN: path}},
+		}
+		expected := []string{"audit"}
+		if tables := tables(t, config); !slices.Equal(tables, expected) {
+			t.Fatalf("tables do not match: expected = %v, got = %v", expected, tables)
+		}
+	})
+}
+
+// newConfig returns a configuration of a new SQLite database in a temporary directory.
+func newConfig(t *testing.T, transactions bool, mode database.Mode) database.Config {
+	t.Helper()
+
+	return database.Config{
+		Databases: map[sql.Type]database.Database{
+			sql.SQLite: {Driver: driverName, DSN: filepath.Join(t.TempDir(), "test.db")},
+		},
+		Transactions: transactions,
+		Mode:         mode,
+	}
+}
+
+func newExporter(t *testing.T, config database.Config) *database.Exporter {
+	t.Helper()
+
+	e, err := database.NewExporter(config)
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = e.Close()
+	})
+
+	return e
+}
+
+// tables returns the sorted table names of the SQLite database of the configuration.
+func tables(t *testing.T, config database.Config) []string {
+	t.Helper()
+
+	db := openDatabase(t, config)
+	rows, err := db.QueryContext(context.Background(),
+		"SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		t.Fatalf("failed querying tables: %v", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			t.Fatalf("failed scanning table name: %v", err)
+		}
+
+		names = append(names, name)
+	}
+
+	if rows.Err() != nil {
+		t.Fatalf("failed reading tables: %v", rows.Err())
+	}
+
+	return names
+}
+
+func rowCount(t *testing.T, config database.Config, table string) (count int) {
+	t.Helper()
+
+	db := openDatabase(t, config)
+	err := db.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM "+table).Scan(&count)
+	if err != nil {
+		t.Fatalf("failed counting rows of %s: %v", table, err)
+	}
+
+	return count
+}
+
+func openDatabase(t *testing.T, config database.Config) *stdsql.DB {
+	t.Helper()
+
+	db, err := stdsql.Open(driverName, config.Databases[sql.SQLite].DSN)
+	if err != nil {
+		t.Fatalf("failed opening database: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return db
+}
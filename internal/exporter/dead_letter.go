@@ -0,0 +1,247 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	deadLetterFileSuffix      = ".jsonl"
+	deadLetterBufferSize      = 64 << 10
+	deadLetterMaxLineSize     = 16 << 20
+	deadLetterDefaultAttempts = 1
+
+	deadLetterDirectoryPermissions = 0o700
+	deadLetterFilePermissions      = 0o600
+)
+
+// DeadLetter represents a [sql.Statement] an [Exporter] failed to export.
+type DeadLetter struct {
+	Exporter  string        `json:"exporter"`
+	Error     string        `json:"error"`
+	Attempts  int           `json:"attempts"`
+	Time      time.Time     `json:"time"`
+	Statement sql.Statement `json:"statement"`
+}
+
+// DeadLetterQueue stores [DeadLetter]s in a directory.
+//
+// Each failed batch is written to its own JSON lines file
+// in a subdirectory named after the exporter.
+type DeadLetterQueue struct {
+	directory string
+
+	mu       sync.Mutex
+	sequence uint64
+}
+
+// NewDeadLetterQueue creates a new [DeadLetterQueue] in the given directory.
+// The directory is created once the first dead letter is written.
+func NewDeadLetterQueue(directory string) *DeadLetterQueue {
+	return &DeadLetterQueue{directory: directory}
+}
+
+// Directory returns the directory of the [DeadLetterQueue].
+func (q *DeadLetterQueue) Directory() string {
+	return q.directory
+}
+
+// Write writes the statements the named exporter failed to export with the given error.
+//
+// The attempt count is taken from the error when it provides an Attempts method.
+func (q *DeadLetterQueue) Write(exporter string, statements []sql.Statement, exportErr error) error {
+	attempts := deadLetterDefaultAttempts
+	var attempter interface{ Attempts() int }
+	if errors.As(exportErr, &attempter) {
+		attempts = attempter.Attempts()
+	}
+
+	now := time.Now()
+	var builder strings.Builder
+	encoder := json.NewEncoder(&builder)
+	for _, statement := range statements {
+		err := encoder.Encode(DeadLetter{
+			Exporter:  exporter,
+			Error:     exportErr.Error(),
+			Attempts:  attempts,
+			Time:      now,
+			Statement: statement,
+		})
+		if err != nil {
+			return fmt.Errorf("failed encoding dead letter: %w", err)
+		}
+	}
+
+	directory := filepath.Join(q.directory, exporterDirectoryName(exporter))
+	err := os.MkdirAll(directory, deadLetterDirectoryPermissions)
+	if err != nil {
+		return fmt.Errorf("failed creating dead letter directory: %w", err)
+	}
+
+	q.mu.Lock()
+	q.sequence++
+	name := fmt.Sprintf("%020d-%06d%s", now.UnixNano(), q.sequence, deadLetterFileSuffix)
+	q.mu.Unlock()
+
+	err = os.WriteFile(filepath.Join(directory, name), []byte(builder.String()), deadLetterFilePermissions)
+	if err != nil {
+		return fmt.Errorf("failed writing dead letters: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads all stored [DeadLetter]s.
+// Non-empty exporter limits them to the ones of the named exporter.
+func (q *DeadLetterQueue) Read(exporter string) (deadLetters []DeadLetter, err error) {
+	files, err := q.files(exporter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		fileDeadLetters, err := readDeadLetters(file)
+		if err != nil {
+			return nil, err
+		}
+
+		deadLetters = append(deadLetters, fileDeadLetters...)
+	}
+
+	return deadLetters, nil
+}
+
+// Replay exports the stored [DeadLetter]s to the given [Exporter] and removes them.
+// Non-empty from limits them to the ones of the named exporter.
+//
+// The exporter is started before the first batch when it is a [Starter] and flushed after
+// each batch when it is a [Flusher], so dead letters are removed only once their statements
+// leave the exporter. Closing the exporter is left to the caller.
+//
+// Replaying stops at the first failed batch, which is kept in the queue.
+// It returns the number of replayed statements.
+func (q *DeadLetterQueue) Replay(ctx context.Context, from string, to Exporter) (replayed int, err error) {
+	files, err := q.files(from)
+	if err != nil {
+		return 0, err
+	}
+
+	if starter, ok := to.(Starter); ok && len(files) > 0 {
+		err = starter.Start(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrNotStarted, err)
+		}
+	}
+
+	flusher, _ := to.(Flusher)
+	contextExporter := Adapt(to)
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return replayed, ctx.Err()
+		}
+
+		deadLetters, err := readDeadLetters(file)
+		if err != nil {
+			return replayed, err
+		}
+
+		statements := make([]sql.Statement, 0, len(deadLetters))
+		for _, deadLetter := range deadLetters {
+			statements = append(statements, deadLetter.Statement)
+		}
+
+		err = contextExporter.ExportBatchContext(ctx, statements)
+		if err == nil && flusher != nil {
+			err = flusher.Flush(ctx)
+		}
+
+		if err != nil {
+			return replayed, fmt.Errorf("failed replaying %s: %w", file, err)
+		}
+
+		err = os.Remove(file)
+		if err != nil {
+			return replayed, fmt.Errorf("failed removing replayed dead letters: %w", err)
+		}
+
+		replayed += len(statements)
+	}
+
+	return replayed, nil
+}
+
+// files returns paths of dead letter files ordered by their creation.
+func (q *DeadLetterQueue) files(exporter string) (files []string, err error) {
+	pattern := filepath.Join(q.directory, "*", "*"+deadLetterFileSuffix)
+	if exporter != "" {
+		pattern = filepath.Join(q.directory, exporterDirectoryName(exporter), "*"+deadLetterFileSuffix)
+	}
+
+	files, err = filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(files, func(a, b string) int {
+		return strings.Compare(filepath.Base(a), filepath.Base(b))
+	})
+
+	return files, nil
+}
+
+func readDeadLetters(path string) (deadLetters []DeadLetter, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed opening dead letters: %w", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, deadLetterBufferSize), deadLetterMaxLineSize)
+	for scanner.Scan() {
+		var deadLetter DeadLetter
+		err := json.Unmarshal(scanner.Bytes(), &deadLetter)
+		if err != nil {
+			return nil, fmt.Errorf("failed decoding dead letter in %s: %w", path, err)
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed reading dead letters: %w", err)
+	}
+
+	return deadLetters, nil
+}
+
+// exporterDirectoryName makes the exporter name safe to use as a directory name.
+func exporterDirectoryName(exporter string) string {
+	if exporter == "" || exporter == "." || exporter == ".." {
+		return "_"
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, exporter)
+}
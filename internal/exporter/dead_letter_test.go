@@ -0,0 +1,181 @@
+package exporter_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/synctest"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+	"github.com/course-go/sql-processor/internal/test/testlogger"
+)
+
+var errExport = errors.New("export failed")
+
+func TestDeadLetterQueue(t *testing.T) {
+	t.Parallel()
+
+	file := sql.File{
+		Path: "test.sql",
+		Type: sql.PostgresType,
+	}
+	statements := []sql.Statement{
+		{Content: "SELECT * FROM users", LineNum: 1, File: file},
+		{Content: "DELETE FROM users", LineNum: 2, File: file},
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		failing := testexporter.New()
+		failing.Fail(errExport)
+
+		queue := exporter.NewDeadLetterQueue(t.TempDir())
+		statementCh := make(chan sql.Statement, len(statements))
+		logger, _ := testlogger.NewTestErrorLogger()
+		m, err := exporter.NewManager(
+			logger,
+			statementCh,
+			[]exporter.Exporter{failing},
+			exporter.WithName(failing, "failing"),
+			exporter.WithDeadLetterQueue(queue),
+		)
+		if err != nil {
+			t.Fatalf("failed creating manager: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		go m.Run(ctx)
+
+		for _, statement := range statements {
+			statementCh <- statement
+		}
+
+		synctest.Wait()
+
+		deadLetters, err := queue.Read("failing")
+		if err != nil {
+			t.Fatalf("failed reading dead letters: %v", err)
+		}
+
+		if len(deadLetters) != len(statements) {
+			t.Fatalf("dead letter count does not match: expected = %v, got = %v", len(statements), len(deadLetters))
+		}
+
+		for i, deadLetter := range deadLetters {
+			if deadLetter.Statement != statements[i] {
+				t.Errorf(
+					"dead letter statement does not match: expected = %v, got = %v",
+					statements[i],
+					deadLetter.Statement,
+				)
+			}
+
+			if deadLetter.Exporter != "failing" || deadLetter.Error != errExport.Error() || deadLetter.Attempts != 1 {
+				t.Errorf("unexpected dead letter: %+v", deadLetter)
+			}
+		}
+
+		if m.Stats()[0].DeadLetterCount != uint64(len(statements)) {
+			t.Errorf(
+				"dead letter count does not match: expected = %v, got = %v",
+				len(statements),
+				m.Stats()[0].DeadLetterCount,
+			)
+		}
+
+		// Replaying to an unhealthy exporter keeps the dead letters.
+		_, err = queue.Replay(t.Context(), "failing", failing)
+		if !errors.Is(err, errExport) {
+			t.Fatalf("expected export error: got = %v", err)
+		}
+
+		healthy := testexporter.New()
+		replayed, err := queue.Replay(t.Context(), "failing", healthy)
+		if err != nil {
+			t.Fatalf("failed replaying dead letters: %v", err)
+		}
+
+		if replayed != len(statements) || len(healthy.Statements()) != len(statements) {
+			t.Fatalf("replayed count does not match: expected = %v, got = %v", len(statements), replayed)
+		}
+
+		deadLetters, err = queue.Read("")
+		if err != nil {
+			t.Fatalf("failed reading dead letters: %v", err)
+		}
+
+		if len(deadLetters) != 0 {
+			t.Fatalf("replayed dead letters were not removed: %v", deadLetters)
+		}
+	})
+}
+
+func TestDeadLetterQueueReplay(t *testing.T) {
+	t.Parallel()
+
+	statements := []sql.Statement{
+		{Content: "SELECT * FROM users", LineNum: 1, File: sql.File{Path: "users.sql", Type: sql.PostgresType}},
+		{Content: "SELECT * FROM orders", LineNum: 1, File: sql.File{Path: "orders.sql", Type: sql.PostgresType}},
+	}
+
+	newQueue := func(t *testing.T) *exporter.DeadLetterQueue {
+		t.Helper()
+
+		queue := exporter.NewDeadLetterQueue(t.TempDir())
+		for _, statement := range statements {
+			err := queue.Write("live", []sql.Statement{statement}, errExport)
+			if err != nil {
+				t.Fatalf("failed writing dead letters: %v", err)
+			}
+		}
+
+		return queue
+	}
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		t.Parallel()
+
+		queue := newQueue(t)
+		e := &lifecycleExporter{Exporter: testexporter.New()}
+		replayed, err := queue.Replay(t.Context(), "", e)
+		if err != nil || replayed != len(statements) {
+			t.Fatalf("failed replaying dead letters: %d, %v", replayed, err)
+		}
+
+		// Each batch is flushed before its dead letters are removed.
+		expected := []string{"start", "export", "flush", "export", "flush"}
+		if !slices.Equal(e.calls, expected) {
+			t.Fatalf("lifecycle calls do not match: expected = %v, got = %v", expected, e.calls)
+		}
+	})
+
+	t.Run("FailedStart", func(t *testing.T) {
+		t.Parallel()
+
+		queue := newQueue(t)
+		e := &lifecycleExporter{Exporter: testexporter.New(), startErr: errExport}
+		_, err := queue.Replay(t.Context(), "", e)
+		if !errors.Is(err, exporter.ErrNotStarted) || !errors.Is(err, errExport) {
+			t.Fatalf("expected = %v, got = %v", exporter.ErrNotStarted, err)
+		}
+
+		deadLetters, err := queue.Read("")
+		if err != nil || len(deadLetters) != len(statements) {
+			t.Fatalf("expected dead letters to be kept: got = %v, %v", deadLetters, err)
+		}
+	})
+
+	t.Run("NothingToReplay", func(t *testing.T) {
+		t.Parallel()
+
+		e := &lifecycleExporter{Exporter: testexporter.New()}
+		_, err := exporter.NewDeadLetterQueue(t.TempDir()).Replay(t.Context(), "", e)
+		if err != nil || len(e.calls) != 0 {
+			t.Fatalf("expected exporter not to be started: got = %v, %v", e.calls, err)
+		}
+	})
+}
@@ -1,9 +1,98 @@
 package exporter
 
-import "github.com/course-go/sql-processor/internal/sql"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
 
 // Exporter represents [sql.Statement] exporter.
 type Exporter interface {
 	Export(statement sql.Statement) (err error)
 	ExportBatch(statements []sql.Statement) (err error)
 }
+
+// ContextExporter represents context-aware [sql.Statement] exporter.
+// Exports get cancelled once the context is done.
+type ContextExporter interface {
+	ExportContext(ctx context.Context, statement sql.Statement) (err error)
+	ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error)
+}
+
+// Starter is implemented by exporters that need to start before exporting.
+// The [Manager] starts them before passing them any statements.
+type Starter interface {
+	Start(ctx context.Context) (err error)
+}
+
+// Flusher is implemented by exporters that buffer statements.
+// The [Manager] flushes them once it exports all statements during shutdown.
+type Flusher interface {
+	Flush(ctx context.Context) (err error)
+}
+
+// CircuitBreaker is implemented by exporters that reject exports while their circuit is open.
+type CircuitBreaker interface {
+	CircuitOpen() bool
+}
+
+// Closer is implemented by exporters that hold resources.
+// The [Manager] closes them after flushing during shutdown.
+type Closer interface {
+	Close() (err error)
+}
+
+// Namer is implemented by [Exporter]s that provide their own name.
+type Namer interface {
+	Name() string
+}
+
+// Name returns name of the [Exporter].
+// It defaults to the exporter type name for exporters not implementing [Namer].
+func Name(e Exporter) string {
+	namer, ok := e.(Namer)
+	if ok {
+		return namer.Name()
+	}
+
+	return strings.TrimPrefix(fmt.Sprintf("%T", e), "*")
+}
+
+// Adapt adapts the [Exporter] to the [ContextExporter] interface.
+//
+// Exporters already implementing it are returned as they are. Other exporters
+// are wrapped so they are not called once the context is done.
+func Adapt(e Exporter) ContextExporter {
+	contextExporter, ok := e.(ContextExporter)
+	if ok {
+		return contextExporter
+	}
+
+	return &adapter{exporter: e}
+}
+
+type adapter struct {
+	exporter Exporter
+}
+
+// ExportContext implements ContextExporter.
+func (a *adapter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return a.exporter.Export(statement)
+}
+
+// ExportBatchContext implements ContextExporter.
+func (a *adapter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	return a.exporter.ExportBatch(statements)
+}
@@ -0,0 +1,50 @@
+package exporter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+)
+
+func TestAdapt(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "test.sql", Type: sql.SQLite},
+		Content: "SELECT * FROM users",
+		LineNum: 1,
+	}
+
+	mock := testexporter.New()
+	adapted := exporter.Adapt(mock)
+
+	err := adapted.ExportContext(t.Context(), statement)
+	if err != nil {
+		t.Fatalf("failed exporting statement: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	err = adapted.ExportBatchContext(ctx, []sql.Statement{statement})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled error: got = %v", err)
+	}
+
+	if len(mock.Statements()) != 1 {
+		t.Fatalf("statement count does not match: expected = %v, got = %v", 1, len(mock.Statements()))
+	}
+}
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	name := exporter.Name(testexporter.New())
+	if name != "testexporter.Exporter" {
+		t.Fatalf("name does not match: expected = %v, got = %v", "testexporter.Exporter", name)
+	}
+}
@@ -0,0 +1,331 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	sqlprocessorv1 "github.com/course-go/sql-processor/api/sqlprocessor/v1"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const defaultAckTimeout = 10 * time.Second
+
+var (
+	ErrNoTarget      = errors.New("no target configured")
+	ErrRejected      = errors.New("batch rejected")
+	ErrUnexpectedAck = errors.New("unexpected acknowledgement")
+	ErrAckTimeout    = errors.New("timed out waiting for acknowledgement")
+	ErrInvalidTLS    = errors.New("invalid tls configuration")
+	errStreamFailed  = errors.New("stream failed")
+)
+
+var (
+	_ exporter.Exporter        = &Exporter{}
+	_ exporter.ContextExporter = &Exporter{}
+	_ exporter.Closer          = &Exporter{}
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Target is the gRPC target of the StatementService server, such as "localhost:50051".
+	Target string
+	// TLS enables transport security. The connection is insecure when nil.
+	TLS *tls.Config
+	// AckTimeout limits waiting for a batch acknowledgement. Defaults to 10 seconds.
+	AckTimeout time.Duration
+}
+
+// Exporter implements [exporter.Exporter] and streams given [sql.Statement]s to a gRPC
+// StatementService server defined in api/sqlprocessor/v1/statement.proto.
+//
+// Each batch is sent as one ExportRequest on a bidirectional stream and the export
+// returns once the server acknowledges it. A failed stream is re-established and
+// the unacknowledged batch is sent again, so batches are delivered at least once.
+type Exporter struct {
+	config Config
+	conn   *grpc.ClientConn
+	client sqlprocessorv1.StatementServiceClient
+
+	mu     sync.Mutex
+	nextID uint64
+	stream *stream
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+// The connection is established lazily on the first export.
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Target == "" {
+		return nil, ErrNoTarget
+	}
+
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = defaultAckTimeout
+	}
+
+	creds := insecure.NewCredentials()
+	if config.TLS != nil {
+		creds = credentials.NewTLS(config.TLS)
+	}
+
+	conn, err := grpc.NewClient(config.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating grpc client: %w", err)
+	}
+
+	return &Exporter{
+		config: config,
+		conn:   conn,
+		client: sqlprocessorv1.NewStatementServiceClient(conn),
+	}, nil
+}
+
+// FactoryConfig represents configuration of an [Exporter] used by [Register].
+type FactoryConfig struct {
+	// Target is the gRPC target of the StatementService server, such as "localhost:50051".
+	Target string
+	// TLS enables transport security verified by the system certificate authorities.
+	// It is enabled by any of the other TLS settings as well.
+	TLS bool
+	// CA is the PEM file of the certificate authorities verifying the server instead of the system ones.
+	CA string
+	// Cert is the PEM file of the client certificate. It requires Key.
+	Cert string
+	// Key is the PEM file of the private key of the client certificate. It requires Cert.
+	Key string
+	// ServerName overrides the server name verified in the server certificate.
+	ServerName string
+	// AckTimeout limits waiting for a batch acknowledgement. Defaults to 10 seconds.
+	AckTimeout time.Duration
+}
+
+// Register registers the "grpc" exporter type configured by [FactoryConfig].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "grpc", func(config FactoryConfig) (exporter.Exporter, error) {
+		exporterConfig := Config{Target: config.Target, AckTimeout: config.AckTimeout}
+		if config.tlsEnabled() {
+			var err error
+			exporterConfig.TLS, err = config.tls()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		e, err := NewExporter(exporterConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+func (c FactoryConfig) tlsEnabled() bool {
+	return c.TLS || c.CA != "" || c.Cert != "" || c.Key != "" || c.ServerName != ""
+}
+
+// tls returns the TLS configuration with the certificates loaded from the files.
+func (c FactoryConfig) tls() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: c.ServerName}
+	if c.CA != "" {
+		data, err := os.ReadFile(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed reading ca: %w", ErrInvalidTLS, err)
+		}
+
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("%w: no certificates in ca %s", ErrInvalidTLS, c.CA)
+		}
+	}
+
+	if (c.Cert == "") != (c.Key == "") {
+		return nil, fmt.Errorf("%w: cert and key have to be set together", ErrInvalidTLS)
+	}
+
+	if c.Cert != "" {
+		certificate, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTLS, err)
+		}
+
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), []sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+// ExportContext implements exporter.ContextExporter.
+func (e *Exporter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	return e.ExportBatchContext(ctx, []sql.Statement{statement})
+}
+
+// ExportBatchContext implements exporter.ContextExporter.
+func (e *Exporter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.nextID++
+	request := &sqlprocessorv1.ExportRequest{
+		Id:         e.nextID,
+		Statements: make([]*sqlprocessorv1.Statement, 0, len(statements)),
+	}
+	for _, statement := range statements {
+		request.Statements = append(request.Statements, toProto(statement))
+	}
+
+	err = e.send(ctx, request)
+	if errors.Is(err, errStreamFailed) && ctx.Err() == nil {
+		err = e.send(ctx, request)
+	}
+
+	return err
+}
+
+// send sends the request on the current stream, starting a new one if needed,
+// and waits for its acknowledgement. The stream is dropped on failure.
+func (e *Exporter) send(ctx context.Context, request *sqlprocessorv1.ExportRequest) error {
+	if e.stream == nil {
+		s, err := e.startStream()
+		if err != nil {
+			return err
+		}
+
+		e.stream = s
+	}
+
+	err := e.stream.send(ctx, request, e.config.AckTimeout)
+	if err != nil && !errors.Is(err, ErrRejected) {
+		e.stream.close()
+		e.stream = nil
+	}
+
+	return err
+}
+
+func (e *Exporter) startStream() (*stream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client, err := e.client.Export(ctx)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed starting export stream: %w", err)
+	}
+
+	s := &stream{
+		client: client,
+		cancel: cancel,
+		ackCh:  make(chan *sqlprocessorv1.ExportResponse),
+		doneCh: make(chan struct{}),
+	}
+	go s.receive()
+	return s, nil
+}
+
+// Close implements exporter.Closer.
+func (e *Exporter) Close() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stream != nil {
+		_ = e.stream.client.CloseSend()
+		e.stream.close()
+		e.stream = nil
+	}
+
+	return e.conn.Close()
+}
+
+// stream is a single export stream with a goroutine receiving acknowledgements.
+type stream struct {
+	client grpc.BidiStreamingClient[sqlprocessorv1.ExportRequest, sqlprocessorv1.ExportResponse]
+	cancel context.CancelFunc
+
+	ackCh  chan *sqlprocessorv1.ExportResponse
+	doneCh chan struct{}
+	err    error
+}
+
+func (s *stream) send(ctx context.Context, request *sqlprocessorv1.ExportRequest, timeout time.Duration) error {
+	err := s.client.Send(request)
+	if err != nil {
+		return fmt.Errorf("%w: failed sending batch: %w", errStreamFailed, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case ack := <-s.ackCh:
+		if ack.GetId() != request.GetId() {
+			return fmt.Errorf("%w: expected ID %d, got %d", ErrUnexpectedAck, request.GetId(), ack.GetId())
+		}
+
+		if ack.GetError() != "" {
+			return fmt.Errorf("%w: %s", ErrRejected, ack.GetError())
+		}
+
+		return nil
+	case <-s.doneCh:
+		return fmt.Errorf("%w: %w", errStreamFailed, s.err)
+	case <-timer.C:
+		return ErrAckTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// receive receives acknowledgements until the stream ends.
+func (s *stream) receive() {
+	defer close(s.doneCh)
+
+	for {
+		ack, err := s.client.Recv()
+		if err != nil {
+			s.err = err
+			return
+		}
+
+		select {
+		case s.ackCh <- ack:
+		case <-s.client.Context().Done():
+			s.err = s.client.Context().Err()
+			return
+		}
+	}
+}
+
+func (s *stream) close() {
+	s.cancel()
+	<-s.doneCh
+}
+
+func toProto(statement sql.Statement) *sqlprocessorv1.Statement {
+	return &sqlprocessorv1.Statement{
+		File: &sqlprocessorv1.File{
+			Path: statement.File.Path,
+			Type: string(statement.File.Type),
+		},
+		Content: statement.Content,
+		LineNum: int64(statement.LineNum),
+	}
+}
@@ -0,0 +1,407 @@
+package grpc_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	sqlprocessorv1 "github.com/course-go/sql-processor/api/sqlprocessor/v1"
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/exporter/grpc"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+type statementServer struct {
+	sqlprocessorv1.UnimplementedStatementServiceServer
+
+	mu         sync.Mutex
+	streams    int
+	failFirst  bool
+	reject     string
+	statements []*sqlprocessorv1.Statement
+}
+
+func (s *statementServer) Export(
+	stream grpcgo.BidiStreamingServer[sqlprocessorv1.ExportRequest, sqlprocessorv1.ExportResponse],
+) error {
+	s.mu.Lock()
+	s.streams++
+	fail := s.failFirst && s.streams == 1
+	s.mu.Unlock()
+
+	for {
+		request, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if fail {
+			return status.Error(codes.Unavailable, "shutting down")
+		}
+
+		response := &sqlprocessorv1.ExportResponse{Id: request.GetId(), Error: s.reject}
+		if s.reject == "" {
+			s.mu.Lock()
+			s.statements = append(s.statements, request.GetStatements()...)
+			s.mu.Unlock()
+		}
+
+		err = stream.Send(response)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newServer(t *testing.T, server *statementServer, opts ...grpcgo.ServerOption) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed listening: %v", err)
+	}
+
+	s := grpcgo.NewServer(opts...)
+	sqlprocessorv1.RegisterStatementServiceServer(s, server)
+	go func() {
+		_ = s.Serve(listener)
+	}()
+
+	t.Cleanup(s.Stop)
+	return listener.Addr().String()
+}
+
+func newExporter(t *testing.T, target string) *grpc.Exporter {
+	t.Helper()
+
+	e, err := grpc.NewExporter(grpc.Config{Target: target})
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = e.Close()
+	})
+
+	return e
+}
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	statements := []sql.Statement{
+		{File: sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType}, Content: "SELECT 1", LineNum: 1},
+		{File: sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType}, Content: "SELECT 2", LineNum: 3},
+	}
+
+	t.Run("NoTarget", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := grpc.NewExporter(grpc.Config{})
+		if !errors.Is(err, grpc.ErrNoTarget) {
+			t.Fatalf("expected no target error: got = %v", err)
+		}
+	})
+
+	t.Run("Acknowledged", func(t *testing.T) {
+		t.Parallel()
+
+		server := &statementServer{}
+		e := newExporter(t, newServer(t, server))
+
+		err := e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		err = e.Export(statements[0])
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		assertStatements(t, append(statements, statements[0]), server)
+		if server.streams != 1 {
+			t.Fatalf("expected = %d streams, got = %d", 1, server.streams)
+		}
+	})
+
+	t.Run("Reconnect", func(t *testing.T) {
+		t.Parallel()
+
+		server := &statementServer{failFirst: true}
+		e := newExporter(t, newServer(t, server))
+
+		err := e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		assertStatements(t, statements, server)
+		if server.streams != 2 {
+			t.Fatalf("expected = %d streams, got = %d", 2, server.streams)
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		t.Parallel()
+
+		server := &statementServer{reject: "invalid statement"}
+		e := newExporter(t, newServer(t, server))
+
+		err := e.ExportBatch(statements)
+		if !errors.Is(err, grpc.ErrRejected) {
+			t.Fatalf("expected rejected error: got = %v", err)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed listening: %v", err)
+		}
+
+		target := listener.Addr().String()
+		_ = listener.Close()
+
+		e := newExporter(t, target)
+		err = e.ExportBatch(statements)
+		if err == nil {
+			t.Fatal("expected exporting to fail")
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "users.sql", Type: sql.PostgresType},
+		Content: "SELECT 1",
+		LineNum: 1,
+	}
+
+	t.Run("MutualTLS", func(t *testing.T) {
+		t.Parallel()
+
+		files := newCertificates(t)
+		server := &statementServer{}
+		target := newServer(t, server, grpcgo.Creds(credentials.NewTLS(files.server)))
+
+		r := exporter.NewRegistry()
+		grpc.Register(r)
+		e, err := r.Create("grpc", exporter.Params{
+			"target": {target},
+			"ca":     {files.ca},
+			"cert":   {files.cert},
+			"key":    {files.key},
+		})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		t.Cleanup(func() {
+			_ = e.(exporter.Closer).Close()
+		})
+
+		err = e.Export(statement)
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		assertStatements(t, []sql.Statement{statement}, server)
+	})
+
+	t.Run("UntrustedServer", func(t *testing.T) {
+		t.Parallel()
+
+		files := newCertificates(t)
+		target := newServer(t, &statementServer{}, grpcgo.Creds(credentials.NewTLS(files.server)))
+
+		r := exporter.NewRegistry()
+		grpc.Register(r)
+		e, err := r.Create("grpc", exporter.Params{"target": {target}, "tls": {"true"}})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		t.Cleanup(func() {
+			_ = e.(exporter.Closer).Close()
+		})
+
+		err = e.Export(statement)
+		if err == nil {
+			t.Fatal("expected the server certificate to be rejected")
+		}
+	})
+
+	t.Run("InvalidTLS", func(t *testing.T) {
+		t.Parallel()
+
+		files := newCertificates(t)
+		r := exporter.NewRegistry()
+		grpc.Register(r)
+		for name, params := range map[string]exporter.Params{
+			"CertWithoutKey": {"target": {"localhost:50051"}, "cert": {files.cert}},
+			"MissingCA":      {"target": {"localhost:50051"}, "ca": {filepath.Join(t.TempDir(), "ca.pem")}},
+			"CAWithoutPEM":   {"target": {"localhost:50051"}, "ca": {files.key + ".txt"}},
+		} {
+			_, err := r.Create("grpc", params)
+			if !errors.Is(err, grpc.ErrInvalidTLS) {
+				t.Errorf("%s: expected = %v, got = %v", name, grpc.ErrInvalidTLS, err)
+			}
+		}
+	})
+}
+
+// certificateFiles are the PEM files of a test certificate authority and a client certificate signed by it
+// along with the TLS configuration of a server requiring the client certificate.
+type certificateFiles struct {
+	ca     string
+	cert   string
+	key    string
+	server *tls.Config
+}
+
+func newCertificates(t *testing.T) certificateFiles {
+	t.Helper()
+
+	directory := t.TempDir()
+	caKey, caTemplate := newKey(t), &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER := createCertificate(t, caTemplate, caTemplate, caKey, caKey)
+	caCertificate, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed parsing ca: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCertificate)
+
+	serverKey := newKey(t)
+	serverDER := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, caCertificate, serverKey, caKey)
+
+	clientKey := newKey(t)
+	clientDER := createCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, caCertificate, clientKey, caKey)
+
+	clientKeyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatalf("failed encoding key: %v", err)
+	}
+
+	files := certificateFiles{
+		ca:   writePEM(t, filepath.Join(directory, "ca.pem"), "CERTIFICATE", caDER),
+		cert: writePEM(t, filepath.Join(directory, "client.pem"), "CERTIFICATE", clientDER),
+		key:  writePEM(t, filepath.Join(directory, "client-key.pem"), "EC PRIVATE KEY", clientKeyDER),
+		server: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			Certificates: []tls.Certificate{{
+				Certificate: [][]byte{serverDER},
+				PrivateKey:  serverKey,
+			}},
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  pool,
+		},
+	}
+
+	err = os.WriteFile(files.key+".txt", []byte("not a certificate"), 0o600)
+	if err != nil {
+		t.Fatalf("failed writing file: %v", err)
+	}
+
+	return files
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed generating key: %v", err)
+	}
+
+	return key
+}
+
+// createCertificate creates the DER certificate of the key signed by the parent key.
+func createCertificate(t *testing.T, template, parent *x509.Certificate, key, parentKey *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatalf("failed creating certificate: %v", err)
+	}
+
+	return der
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) string {
+	t.Helper()
+
+	err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600)
+	if err != nil {
+		t.Fatalf("failed writing %s: %v", path, err)
+	}
+
+	return path
+}
+
+func assertStatements(t *testing.T, expected []sql.Statement, server *statementServer) {
+	t.Helper()
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if len(expected) != len(server.statements) {
+		t.Fatalf("expected = %d statements, got = %d", len(expected), len(server.statements))
+	}
+
+	for i, statement := range server.statements {
+		got := sql.Statement{
+			File:    sql.File{Path: statement.GetFile().GetPath(), Type: sql.Type(statement.GetFile().GetType())},
+			Content: statement.GetContent(),
+			LineNum: int(statement.GetLineNum()),
+		}
+		if got != expected[i] {
+			t.Fatalf("expected = %v, got = %v", expected[i], got)
+		}
+	}
+}
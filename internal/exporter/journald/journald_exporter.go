@@ -0,0 +1,141 @@
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	defaultSocketPath = "/run/systemd/journal/socket"
+	defaultIdentifier = "sql-processor"
+	defaultPriority   = 6
+)
+
+var (
+	_ exporter.Exporter = &Exporter{}
+	_ exporter.Closer   = &Exporter{}
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// SocketPath is the journald native protocol socket. Defaults to "/run/systemd/journal/socket".
+	SocketPath string
+	// Identifier is the SYSLOG_IDENTIFIER field. Defaults to "sql-processor".
+	Identifier string
+	// Priority is the syslog priority of the entries. Defaults to 6 (informational).
+	Priority int
+}
+
+// Exporter implements [exporter.Exporter] and exports given [sql.Statement]s to systemd-journald.
+//
+// Each statement is sent as a journal entry using the native protocol with its
+// content as the MESSAGE field and the SQL_FILE, SQL_LINE, SQL_DIALECT and
+// SQL_KIND structured fields. Entries have to fit into a single datagram.
+type Exporter struct {
+	config Config
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+func NewExporter(config Config) *Exporter {
+	if config.SocketPath == "" {
+		config.SocketPath = defaultSocketPath
+	}
+
+	if config.Identifier == "" {
+		config.Identifier = defaultIdentifier
+	}
+
+	if config.Priority == 0 {
+		config.Priority = defaultPriority
+	}
+
+	return &Exporter{config: config}
+}
+
+// Register registers the "journald" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "journald", func(config Config) (exporter.Exporter, error) {
+		return NewExporter(config), nil
+	})
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatch([]sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		address := &net.UnixAddr{Name: e.config.SocketPath, Net: "unixgram"}
+		e.conn, err = net.DialUnix("unixgram", nil, address)
+		if err != nil {
+			return fmt.Errorf("failed connecting to journald: %w", err)
+		}
+	}
+
+	for _, statement := range statements {
+		_, err = e.conn.Write(e.entry(statement))
+		if err != nil {
+			_ = e.conn.Close()
+			e.conn = nil
+			return fmt.Errorf("failed writing to journald: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close implements exporter.Closer.
+func (e *Exporter) Close() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+
+	err = e.conn.Close()
+	e.conn = nil
+	return err
+}
+
+// entry serializes the statement as a journal entry of the native protocol.
+func (e *Exporter) entry(statement sql.Statement) []byte {
+	var buf bytes.Buffer
+	writeField(&buf, "MESSAGE", statement.Content)
+	writeField(&buf, "PRIORITY", strconv.Itoa(e.config.Priority))
+	writeField(&buf, "SYSLOG_IDENTIFIER", e.config.Identifier)
+	writeField(&buf, "SQL_FILE", statement.File.Path)
+	writeField(&buf, "SQL_LINE", strconv.Itoa(statement.LineNum))
+	writeField(&buf, "SQL_DIALECT", string(statement.File.Type))
+	writeField(&buf, "SQL_KIND", string(statement.Kind()))
+	return buf.Bytes()
+}
+
+// writeField writes the field either as "KEY=value" line or,
+// when the value spans multiple lines, in the binary-safe length-prefixed form.
+func writeField(buf *bytes.Buffer, key string, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(key + "=" + value + "\n")
+		return
+	}
+
+	buf.WriteString(key + "\n")
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
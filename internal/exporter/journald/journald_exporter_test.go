@@ -0,0 +1,63 @@
+package journald_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/journald"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	socketPath := filepath.Join(t.TempDir(), "journal.sock")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("failed listening on journal socket: %v", err)
+	}
+
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	e := journald.NewExporter(journald.Config{SocketPath: socketPath})
+	defer func() {
+		_ = e.Close()
+	}()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType},
+		Content: "UPDATE users\nSET name = 'Jane'",
+		LineNum: 3,
+	}
+
+	err = e.Export(statement)
+	if err != nil {
+		t.Fatalf("failed exporting statement: %v", err)
+	}
+
+	datagram := make([]byte, 4096)
+	n, err := listener.Read(datagram)
+	if err != nil {
+		t.Fatalf("failed reading journal entry: %v", err)
+	}
+
+	var expected bytes.Buffer
+	expected.WriteString("MESSAGE\n")
+	_ = binary.Write(&expected, binary.LittleEndian, uint64(len(statement.Content)))
+	expected.WriteString(statement.Content + "\n")
+	expected.WriteString("PRIORITY=6\n")
+	expected.WriteString("SYSLOG_IDENTIFIER=sql-processor\n")
+	expected.WriteString("SQL_FILE=/var/sql/users.sql\n")
+	expected.WriteString("SQL_LINE=3\n")
+	expected.WriteString("SQL_DIALECT=postgres\n")
+	expected.WriteString("SQL_KIND=dml\n")
+
+	if !bytes.Equal(datagram[:n], expected.Bytes()) {
+		t.Fatalf("journal entry does not match: expected = %q, got = %q", expected.Bytes(), datagram[:n])
+	}
+}
@@ -0,0 +1,122 @@
+package jsonl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const filePermissions = 0o600
+
+var ErrNoPath = errors.New("no path configured")
+
+var (
+	_ exporter.Exporter = &Exporter{}
+	_ exporter.Closer   = &Exporter{}
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Path is the file statements are appended to. It is created when it does not exist.
+	Path string
+	// Sync flushes the file to stable storage after each export.
+	Sync bool
+}
+
+// Exporter implements [exporter.Exporter] and appends given [sql.Statement]s
+// to a file as JSON lines.
+//
+// Each export call results in a single write, so batches are not interleaved
+// with writes of other processes appending to the same file.
+type Exporter struct {
+	config Config
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+// The file is opened on the first export, so creating the exporter has no side effects.
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Path == "" {
+		return nil, ErrNoPath
+	}
+
+	return &Exporter{
+		config: config,
+	}, nil
+}
+
+// Register registers the "jsonl" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "jsonl", func(config Config) (exporter.Exporter, error) {
+		e, err := NewExporter(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatch([]sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, statement := range statements {
+		err = encoder.Encode(statement)
+		if err != nil {
+			return fmt.Errorf("failed encoding statement: %w", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		e.file, err = os.OpenFile(e.config.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, filePermissions)
+		if err != nil {
+			e.file = nil
+			return fmt.Errorf("failed opening file: %w", err)
+		}
+	}
+
+	_, err = e.file.Write(buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed writing statements: %w", err)
+	}
+
+	if e.config.Sync {
+		err = e.file.Sync()
+		if err != nil {
+			return fmt.Errorf("failed syncing file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Close implements exporter.Closer. It is a no-op when nothing was exported.
+func (e *Exporter) Close() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.file == nil {
+		return nil
+	}
+
+	err = e.file.Close()
+	e.file = nil
+	return err
+}
@@ -0,0 +1,99 @@
+package jsonl_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/jsonl"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	file := sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType}
+	statements := []sql.Statement{
+		{File: file, Content: "SELECT *\nFROM users", LineNum: 1},
+		{File: file, Content: "SELECT 1", LineNum: 3},
+	}
+
+	t.Run("NoPath", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := jsonl.NewExporter(jsonl.Config{})
+		if !errors.Is(err, jsonl.ErrNoPath) {
+			t.Fatalf("expected = %v, got = %v", jsonl.ErrNoPath, err)
+		}
+	})
+
+	t.Run("OpenLazily", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "statements.jsonl")
+		e, err := jsonl.NewExporter(jsonl.Config{Path: path})
+		if err != nil {
+			t.Fatalf("failed creating exporter: %v", err)
+		}
+
+		err = e.Close()
+		if err != nil {
+			t.Fatalf("failed closing exporter: %v", err)
+		}
+
+		_, err = os.Stat(path)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected = %v, got = %v", os.ErrNotExist, err)
+		}
+	})
+
+	t.Run("Append", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "statements.jsonl")
+		for _, statement := range statements {
+			e, err := jsonl.NewExporter(jsonl.Config{Path: path, Sync: true})
+			if err != nil {
+				t.Fatalf("failed creating exporter: %v", err)
+			}
+
+			err = e.Export(statement)
+			if err != nil {
+				t.Fatalf("failed exporting statement: %v", err)
+			}
+
+			err = e.Close()
+			if err != nil {
+				t.Fatalf("failed closing exporter: %v", err)
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatalf("failed opening file: %v", err)
+		}
+
+		defer func() {
+			_ = file.Close()
+		}()
+
+		var got []sql.Statement
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var statement sql.Statement
+			err = json.Unmarshal(scanner.Bytes(), &statement)
+			if err != nil {
+				t.Fatalf("failed decoding statement: %v", err)
+			}
+
+			got = append(got, statement)
+		}
+
+		if len(got) != len(statements) || got[0] != statements[0] || got[1] != statements[1] {
+			t.Fatalf("expected = %v, got = %v", statements, got)
+		}
+	})
+}
@@ -0,0 +1,372 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	defaultAddress    = "localhost:8090"
+	defaultBufferSize = 64
+	writeTimeout      = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+	readHeaderTimeout = 5 * time.Second
+)
+
+var ErrNotStarted = errors.New("exporter not started")
+
+var (
+	_ exporter.Exporter = &Exporter{}
+	_ exporter.Starter  = &Exporter{}
+	_ exporter.Closer   = &Exporter{}
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Address is the host and port the HTTP server listens on. Defaults to "localhost:8090".
+	Address string
+	// BufferSize is the number of statements buffered for each client. Clients that fall
+	// further behind get disconnected. Defaults to 64.
+	BufferSize int
+	// OriginPatterns are the origins allowed to open WebSocket connections
+	// besides the server's own origin, such as "example.com" or "*.example.com".
+	OriginPatterns []string
+}
+
+// Exporter implements [exporter.Exporter] and streams given [sql.Statement]s
+// to connected HTTP clients.
+//
+// Clients subscribe using Server-Sent Events on /events or WebSocket on /ws.
+// Both endpoints accept optional "dialect" and "path" query parameters filtering
+// statements by the SQL dialect and by a glob pattern of the file path.
+// Each statement is sent as JSON. Exporting never blocks on clients;
+// a client whose buffer is full is disconnected instead.
+type Exporter struct {
+	config  Config
+	handler http.Handler
+	server  *http.Server
+	doneCh  chan struct{}
+
+	mu       sync.Mutex
+	listener net.Listener
+	clients  map[*client]struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+func NewExporter(config Config) *Exporter {
+	if config.Address == "" {
+		config.Address = defaultAddress
+	}
+
+	if config.BufferSize <= 0 {
+		config.BufferSize = defaultBufferSize
+	}
+
+	e := &Exporter{
+		config:  config,
+		doneCh:  make(chan struct{}),
+		clients: make(map[*client]struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /events", e.handleEvents)
+	mux.HandleFunc("GET /ws", e.handleWebSocket)
+	e.handler = mux
+	e.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	return e
+}
+
+// Register registers the "live" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "live", func(config Config) (exporter.Exporter, error) {
+		return NewExporter(config), nil
+	})
+}
+
+// Handler returns the HTTP handler serving the feed endpoints.
+func (e *Exporter) Handler() http.Handler {
+	return e.handler
+}
+
+// Addr returns the address the HTTP server listens on.
+func (e *Exporter) Addr() (addr net.Addr, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.listener == nil {
+		return nil, ErrNotStarted
+	}
+
+	return e.listener.Addr(), nil
+}
+
+// Start implements exporter.Starter.
+func (e *Exporter) Start(ctx context.Context) (err error) {
+	var config net.ListenConfig
+	listener, err := config.Listen(ctx, "tcp", e.config.Address)
+	if err != nil {
+		return fmt.Errorf("failed listening: %w", err)
+	}
+
+	e.mu.Lock()
+	e.listener = listener
+	e.mu.Unlock()
+
+	go func() {
+		_ = e.server.Serve(listener)
+	}()
+
+	return nil
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatch([]sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for c := range e.clients {
+		if !c.send(statements) {
+			delete(e.clients, c)
+			close(c.droppedCh)
+		}
+	}
+
+	return nil
+}
+
+// Close implements exporter.Closer. Closing again returns the result of the first close.
+func (e *Exporter) Close() (err error) {
+	e.closeOnce.Do(func() {
+		close(e.doneCh)
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		e.closeErr = e.server.Shutdown(ctx)
+	})
+
+	return e.closeErr
+}
+
+// Clients returns the number of connected clients.
+func (e *Exporter) Clients() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.clients)
+}
+
+// client is a single subscribed HTTP client.
+type client struct {
+	route       exporter.Route
+	statementCh chan sql.Statement
+	droppedCh   chan struct{}
+}
+
+// send buffers statements passing the client's route. It returns false when the buffer is full.
+func (c *client) send(statements []sql.Statement) bool {
+	for _, statement := range statements {
+		if !c.route(statement) {
+			continue
+		}
+
+		select {
+		case c.statementCh <- statement:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Exporter) subscribe(r *http.Request) (*client, error) {
+	route, err := parseRoute(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &client{
+		route:       route,
+		statementCh: make(chan sql.Statement, e.config.BufferSize),
+		droppedCh:   make(chan struct{}),
+	}
+
+	e.mu.Lock()
+	e.clients[c] = struct{}{}
+	e.mu.Unlock()
+
+	return c, nil
+}
+
+func (e *Exporter) unsubscribe(c *client) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.clients, c)
+}
+
+func (e *Exporter) handleEvents(w http.ResponseWriter, r *http.Request) {
+	c, err := e.subscribe(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defer e.unsubscribe(c)
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	err = controller.Flush()
+	if err != nil {
+		return
+	}
+
+	for {
+		select {
+		case statement := <-c.statementCh:
+			data, err := json.Marshal(statement)
+			if err != nil {
+				return
+			}
+
+			_ = controller.SetWriteDeadline(time.Now().Add(writeTimeout))
+			_, err = fmt.Fprintf(w, "event: statement\ndata: %s\n\n", data)
+			if err == nil {
+				err = controller.Flush()
+			}
+
+			if err != nil {
+				return
+			}
+		case <-c.droppedCh:
+			return
+		case <-e.doneCh:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (e *Exporter) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	c, err := e.subscribe(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defer e.unsubscribe(c)
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: e.config.OriginPatterns})
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = conn.CloseNow()
+	}()
+
+	ctx := conn.CloseRead(r.Context())
+	for {
+		select {
+		case statement := <-c.statementCh:
+			data, err := json.Marshal(statement)
+			if err != nil {
+				_ = conn.Close(websocket.StatusInternalError, "failed encoding statement")
+				return
+			}
+
+			writeCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+			err = conn.Write(writeCtx, websocket.MessageText, data)
+			cancel()
+			if err != nil {
+				return
+			}
+		case <-c.droppedCh:
+			_ = conn.Close(websocket.StatusPolicyViolation, "client too slow")
+			return
+		case <-e.doneCh:
+			_ = conn.Close(websocket.StatusGoingAway, "server shutting down")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parseRoute creates a route from the "dialect" and "path" query parameters.
+// Both accept multiple comma separated values.
+func parseRoute(r *http.Request) (exporter.Route, error) {
+	query := r.URL.Query()
+	routes := make([]exporter.Route, 0, 2)
+
+	var types []sql.Type
+	for _, value := range splitValues(query["dialect"]) {
+		t, err := sql.ParseType(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dialect %s: %w", value, err)
+		}
+
+		types = append(types, t)
+	}
+
+	if len(types) > 0 {
+		routes = append(routes, exporter.ByType(types...))
+	}
+
+	var paths []exporter.Route
+	for _, value := range splitValues(query["path"]) {
+		route, err := exporter.ByGlob(value)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, route)
+	}
+
+	if len(paths) > 0 {
+		routes = append(routes, exporter.Any(paths...))
+	}
+
+	return exporter.All(routes...), nil
+}
+
+func splitValues(values []string) []string {
+	var split []string
+	for _, value := range values {
+		for part := range strings.SplitSeq(value, ",") {
+			if part != "" {
+				split = append(split, part)
+			}
+		}
+	}
+
+	return split
+}
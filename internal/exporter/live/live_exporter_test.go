@@ -0,0 +1,220 @@
+package live_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coder/websocket"
+
+	"github.com/course-go/sql-processor/internal/exporter/live"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+var statements = []sql.Statement{
+	{File: sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType}, Content: "SELECT 1", LineNum: 1},
+	{File: sql.File{Path: "/var/sql/orders.sql", Type: sql.MySQL}, Content: "SELECT 2", LineNum: 1},
+	{File: sql.File{Path: "/var/sql/users.sql", Type: sql.MySQL}, Content: "SELECT 3", LineNum: 2},
+}
+
+func startExporter(t *testing.T, config live.Config) (*live.Exporter, string) {
+	t.Helper()
+
+	config.Address = "127.0.0.1:0"
+	e := live.NewExporter(config)
+	err := e.Start(t.Context())
+	if err != nil {
+		t.Fatalf("failed starting exporter: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = e.Close()
+	})
+
+	addr, err := e.Addr()
+	if err != nil {
+		t.Fatalf("failed getting address: %v", err)
+	}
+
+	return e, addr.String()
+}
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NotStarted", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := live.NewExporter(live.Config{}).Addr()
+		if !errors.Is(err, live.ErrNotStarted) {
+			t.Fatalf("expected = %v, got = %v", live.ErrNotStarted, err)
+		}
+	})
+
+	t.Run("CloseTwice", func(t *testing.T) {
+		t.Parallel()
+
+		e, addr := startExporter(t, live.Config{})
+		for range 2 {
+			err := e.Close()
+			if err != nil {
+				t.Fatalf("failed closing exporter: %v", err)
+			}
+		}
+
+		request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+addr+"/events", nil)
+		if err != nil {
+			t.Fatalf("failed creating request: %v", err)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err == nil {
+			_ = response.Body.Close()
+			t.Fatal("expected server to be closed")
+		}
+	})
+
+	t.Run("ServerSentEvents", func(t *testing.T) {
+		t.Parallel()
+
+		e, addr := startExporter(t, live.Config{})
+		response := subscribe(t, "http://"+addr+"/events?dialect=mysql")
+		defer func() {
+			_ = response.Body.Close()
+		}()
+
+		if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Fatalf("expected = %s, got = %s", "text/event-stream", contentType)
+		}
+
+		err := e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		scanner := bufio.NewScanner(response.Body)
+		for _, expected := range statements[1:] {
+			assertEvent(t, scanner, expected)
+		}
+	})
+
+	t.Run("WebSocket", func(t *testing.T) {
+		t.Parallel()
+
+		e, addr := startExporter(t, live.Config{})
+		conn, _, err := websocket.Dial(t.Context(), "ws://"+addr+"/ws?path=users.sql&dialect=postgres,mysql", nil)
+		if err != nil {
+			t.Fatalf("failed connecting: %v", err)
+		}
+
+		defer func() {
+			_ = conn.CloseNow()
+		}()
+
+		err = e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		for _, expected := range []sql.Statement{statements[0], statements[2]} {
+			_, data, err := conn.Read(t.Context())
+			if err != nil {
+				t.Fatalf("failed reading message: %v", err)
+			}
+
+			assertStatement(t, expected, data)
+		}
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		t.Parallel()
+
+		_, addr := startExporter(t, live.Config{})
+		response := subscribe(t, "http://"+addr+"/events?dialect=oracle")
+		_ = response.Body.Close()
+		if response.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected = %d, got = %d", http.StatusBadRequest, response.StatusCode)
+		}
+	})
+
+	t.Run("SlowClient", func(t *testing.T) {
+		t.Parallel()
+
+		e, addr := startExporter(t, live.Config{BufferSize: 1})
+		response := subscribe(t, "http://"+addr+"/events")
+		defer func() {
+			_ = response.Body.Close()
+		}()
+
+		burst := make([]sql.Statement, 0, 10_000)
+		for range cap(burst) {
+			burst = append(burst, statements[0])
+		}
+
+		err := e.ExportBatch(burst)
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		if clients := e.Clients(); clients != 0 {
+			t.Fatalf("expected = %d clients, got = %d", 0, clients)
+		}
+
+		_, err = io.Copy(io.Discard, response.Body)
+		if err != nil {
+			t.Fatalf("expected stream to end: %v", err)
+		}
+	})
+}
+
+func subscribe(t *testing.T, url string) *http.Response {
+	t.Helper()
+
+	request, err := http.NewRequestWithContext(t.Context(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("failed creating request: %v", err)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("failed subscribing: %v", err)
+	}
+
+	return response
+}
+
+func assertEvent(t *testing.T, scanner *bufio.Scanner, expected sql.Statement) {
+	t.Helper()
+
+	var data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" && data != "" {
+			break
+		}
+
+		if value, ok := strings.CutPrefix(line, "data: "); ok {
+			data = value
+		}
+	}
+
+	assertStatement(t, expected, []byte(data))
+}
+
+func assertStatement(t *testing.T, expected sql.Statement, data []byte) {
+	t.Helper()
+
+	var got sql.Statement
+	err := json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("failed decoding statement %q: %v", data, err)
+	}
+
+	if got != expected {
+		t.Fatalf("expected = %v, got = %v", expected, got)
+	}
+}
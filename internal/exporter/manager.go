@@ -2,28 +2,367 @@ package exporter
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/course-go/sql-processor/internal/sql"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+const (
+	defaultBatchMaxCount = 100
+	defaultBatchMaxBytes = 1 << 20
+	tracerName           = "github.com/course-go/sql-processor/internal/exporter"
+)
+
+// BatchConfig represents [Manager] batching configuration.
+//
+// A batch is exported as soon as any of its limits is reached.
+type BatchConfig struct {
+	// MaxCount is the maximum number of statements in a batch.
+	MaxCount int
+	// MaxBytes is the maximum total size of statement contents in a batch.
+	MaxBytes int
+	// MaxLinger is the maximum time a statement waits in a partial batch.
+	// Zero exports the batch as soon as there are no more statements pending.
+	MaxLinger time.Duration
+	// SplitOnFile exports the batch whenever a statement of another file arrives.
+	SplitOnFile bool
+}
+
+// DefaultBatchConfig returns the default [BatchConfig].
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		MaxCount: defaultBatchMaxCount,
+		MaxBytes: defaultBatchMaxBytes,
+	}
+}
+
+// Option configures the [Manager].
+type Option func(m *Manager)
+
+// WithBatchConfig sets the [BatchConfig] of the [Manager].
+func WithBatchConfig(config BatchConfig) Option {
+	return func(m *Manager) {
+		m.batchConfig = config
+	}
+}
+
+// WithQueueConfig sets the [QueueConfig] of the given exporter.
+// Its zero fields fall back to the default [QueueConfig], see [WithDefaultQueueConfig].
+// The exporter has to be comparable, which pointer implementations are.
+func WithQueueConfig(exporter Exporter, config QueueConfig) Option {
+	return func(m *Manager) {
+		m.queueConfigs[exporter] = config
+	}
+}
+
+// WithDefaultQueueConfig sets the [QueueConfig] of exporters without their own configuration.
+//
+// Its spill directory is shared by all exporters, each spilling to its own subdirectory named after it.
+func WithDefaultQueueConfig(config QueueConfig) Option {
+	return func(m *Manager) {
+		m.defaultQueueConfig = config
+	}
+}
+
+// WithName names the given exporter in logs, statistics and dead letters.
+// It overrides the name returned by [Name].
+func WithName(exporter Exporter, name string) Option {
+	return func(m *Manager) {
+		m.names[exporter] = name
+	}
+}
+
+// WithRoute passes only statements matching the [Route] to the given exporter.
+// Exporters without a route receive all statements.
+func WithRoute(exporter Exporter, route Route) Option {
+	return func(m *Manager) {
+		m.routes[exporter] = route
+	}
+}
+
+// WithDeadLetterQueue makes the [Manager] write statements that failed to export to the queue.
+func WithDeadLetterQueue(queue *DeadLetterQueue) Option {
+	return func(m *Manager) {
+		m.deadLetterQueue = queue
+	}
+}
+
+// WithMetrics records the [Manager] metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(m *Manager) {
+		m.metrics = metrics
+	}
+}
+
+// WithTracerProvider traces each exporter call using the provider.
+// The span of a batch belongs to the trace of its first file and links the traces of all its files.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(m *Manager) {
+		m.tracer = provider.Tracer(tracerName)
+	}
+}
+
 // Manager manages [Exporter]s.
-// It listens for processed [sql.Statement]s and passes them down to all exporters for exporting.
+// It listens for processed [sql.Statement]s, groups them into batches
+// and passes them down to all exporters for exporting.
+//
+// Each exporter gets its own bounded queue and goroutine
+// so a slow exporter does not stall the others.
 type Manager struct {
-	logger      *slog.Logger
-	statementCh <-chan sql.Statement
-	exporters   []Exporter
+	logger             *slog.Logger
+	statementCh        <-chan sql.Statement
+	batchConfig        BatchConfig
+	defaultQueueConfig QueueConfig
+	queueConfigs       map[Exporter]QueueConfig
+	names              map[Exporter]string
+	routes             map[Exporter]Route
+	deadLetterQueue    *DeadLetterQueue
+	metrics            *Metrics
+	tracer             trace.Tracer
+	workers            []*worker
+	abortCh            chan struct{}
+	abortOnce          *sync.Once
+	started            *atomic.Bool
+}
+
+func NewManager(
+	logger *slog.Logger,
+	statementCh <-chan sql.Statement,
+	exporters []Exporter,
+	opts ...Option,
+) (m Manager, err error) {
+	m = Manager{
+		logger:             logger.With("component", "exporter-manager"),
+		statementCh:        statementCh,
+		batchConfig:        DefaultBatchConfig(),
+		defaultQueueConfig: DefaultQueueConfig(),
+		queueConfigs:       make(map[Exporter]QueueConfig),
+		names:              make(map[Exporter]string),
+		routes:             make(map[Exporter]Route),
+		abortCh:            make(chan struct{}),
+		abortOnce:          &sync.Once{},
+		started:            &atomic.Bool{},
+		tracer:             noop.NewTracerProvider().Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	for _, e := range exporters {
+		name, ok := m.names[e]
+		if !ok {
+			name = Name(e)
+		}
+
+		config := m.queueConfig(e, name)
+		w, err := newWorker(m.logger, name, e, config, m.routes[e], m.deadLetterQueue, m.metrics, m.tracer)
+		if err != nil {
+			return Manager{}, fmt.Errorf("failed creating %s exporter queue: %w", name, err)
+		}
+
+		m.workers = append(m.workers, w)
+	}
+
+	return m, nil
+}
+
+// queueConfig returns the [QueueConfig] of the named exporter.
+func (m *Manager) queueConfig(e Exporter, name string) QueueConfig {
+	config := m.queueConfigs[e]
+	if config.Size <= 0 {
+		config.Size = m.defaultQueueConfig.Size
+	}
+
+	if config.Overflow == "" {
+		config.Overflow = m.defaultQueueConfig.Overflow
+	}
+
+	if config.SpillDirectory == "" && m.defaultQueueConfig.SpillDirectory != "" {
+		config.SpillDirectory = filepath.Join(m.defaultQueueConfig.SpillDirectory, exporterDirectoryName(name))
+	}
+
+	return config
 }
 
-func NewManager(logger *slog.Logger, statementCh <-chan sql.Statement, exporters []Exporter) Manager {
-	return Manager{
-		logger:      logger.With("component", "exporter-manager"),
-		statementCh: statementCh,
-		exporters:   exporters,
+// Started reports whether the [Manager] was started by [Manager.Run].
+func (m *Manager) Started() bool {
+	return m.started.Load()
+}
+
+// Stats returns statistics of all managed exporters.
+func (m *Manager) Stats() []ExporterStats {
+	stats := make([]ExporterStats, 0, len(m.workers))
+	for _, w := range m.workers {
+		stats = append(stats, w.stats())
 	}
+
+	return stats
 }
 
 // Run runs the [Manager].
+//
+// It starts exporters implementing [Starter] before exporting any statements.
+// It returns when the context is done or the statement channel gets closed.
+// Any partial batch and statements already pending in the channel are exported
+// and all exporter queues are drained before returning, unless [Manager.Abort] is called.
+// Then the exporters implementing [Flusher] and [Closer] are flushed and closed.
 func (m *Manager) Run(ctx context.Context) {
-	// TODO: Implement.
+	// Exports outlive the context so the queues can be drained during shutdown.
+	exportCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	go func() {
+		select {
+		case <-m.abortCh:
+			cancel()
+		case <-exportCtx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, w := range m.workers {
+		wg.Go(func() {
+			w.run(exportCtx)
+		})
+	}
+
+	m.started.Store(true)
+
+	defer func() {
+		for _, w := range m.workers {
+			w.close()
+		}
+
+		wg.Wait()
+	}()
+
+	m.batch(ctx)
+}
+
+// Abort cancels the exports of the running [Manager], including the ones still queued.
+// Their statements count as failed and are written to the dead letter queue, if any.
+// It is used when draining the queues takes too long during shutdown.
+func (m *Manager) Abort() {
+	m.abortOnce.Do(func() {
+		m.logger.Warn("aborting exports")
+		close(m.abortCh)
+	})
+}
+
+func (m *Manager) batch(ctx context.Context) {
+	var b batch
+	linger := time.NewTimer(m.batchConfig.MaxLinger)
+	linger.Stop()
+
+	defer linger.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.drain(&b)
+			return
+		case statement, ok := <-m.statementCh:
+			if !ok {
+				m.export(b.take())
+				return
+			}
+
+			if m.batchConfig.SplitOnFile && !b.empty() && b.file() != statement.File {
+				m.export(b.take())
+			}
+
+			b.add(statement)
+			switch {
+			case m.full(&b):
+				m.export(b.take())
+			case m.batchConfig.MaxLinger <= 0:
+				if len(m.statementCh) == 0 {
+					m.export(b.take())
+				}
+			case b.len() == 1:
+				linger.Reset(m.batchConfig.MaxLinger)
+			}
+		case <-linger.C:
+			m.export(b.take())
+		}
+
+		if b.empty() {
+			linger.Stop()
+		}
+	}
+}
+
+// drain exports the partial batch along with statements already pending in the channel.
+func (m *Manager) drain(b *batch) {
+	for {
+		select {
+		case statement, ok := <-m.statementCh:
+			if !ok {
+				m.export(b.take())
+				return
+			}
+
+			b.add(statement)
+			if m.full(b) {
+				m.export(b.take())
+			}
+		default:
+			m.export(b.take())
+			return
+		}
+	}
+}
+
+func (m *Manager) full(b *batch) bool {
+	maxCount := m.batchConfig.MaxCount
+	maxBytes := m.batchConfig.MaxBytes
+	return (maxCount > 0 && b.len() >= maxCount) || (maxBytes > 0 && b.bytes >= maxBytes)
+}
+
+func (m *Manager) export(statements []sql.Statement) {
+	if len(statements) == 0 {
+		return
+	}
+
+	m.metrics.batched(len(statements))
+	for _, w := range m.workers {
+		w.enqueue(statements)
+	}
+}
+
+// batch accumulates [sql.Statement]s.
+type batch struct {
+	statements []sql.Statement
+	bytes      int
+}
+
+func (b *batch) add(statement sql.Statement) {
+	b.statements = append(b.statements, statement)
+	b.bytes += len(statement.Content)
+}
+
+func (b *batch) take() []sql.Statement {
+	statements := b.statements
+	b.statements = nil
+	b.bytes = 0
+	return statements
+}
+
+func (b *batch) file() sql.File {
+	return b.statements[0].File
+}
+
+func (b *batch) len() int {
+	return len(b.statements)
+}
+
+func (b *batch) empty() bool {
+	return len(b.statements) == 0
 }
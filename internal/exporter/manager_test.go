@@ -2,6 +2,11 @@ package exporter_test
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -10,6 +15,12 @@ import (
 	"github.com/course-go/sql-processor/internal/sql"
 	"github.com/course-go/sql-processor/internal/test/testexporter"
 	"github.com/course-go/sql-processor/internal/test/testlogger"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestManager(t *testing.T) { //nolint: gocognit
@@ -36,7 +47,10 @@ func TestManager(t *testing.T) { //nolint: gocognit
 			mock := testexporter.New()
 			statementCh := make(chan sql.Statement, 1)
 			logger, _ := testlogger.NewTestErrorLogger()
-			p := exporter.NewManager(logger, statementCh, []exporter.Exporter{mock})
+			p, err := exporter.NewManager(logger, statementCh, []exporter.Exporter{mock})
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
 
 			ctx, cancel := context.WithTimeout(t.Context(), 1*time.Second)
 			defer cancel()
@@ -96,7 +110,10 @@ func TestManager(t *testing.T) { //nolint: gocognit
 			}
 
 			logger, _ := testlogger.NewTestErrorLogger()
-			m := exporter.NewManager(logger, statementCh, exporters)
+			m, err := exporter.NewManager(logger, statementCh, exporters)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
 
 			ctx, cancel := context.WithTimeout(t.Context(), 1*time.Second)
 			defer cancel()
@@ -141,3 +158,807 @@ func TestManager(t *testing.T) { //nolint: gocognit
 		})
 	})
 }
+
+func TestManagerBatching(t *testing.T) { //nolint: gocognit
+	t.Parallel()
+
+	file1 := sql.File{
+		Path: "test1.sql",
+		Type: "mysql",
+	}
+	file2 := sql.File{
+		Path: "test2.sql",
+		Type: "mysql",
+	}
+	statements := []sql.Statement{
+		{Content: "SELECT * FROM users", LineNum: 1, File: file1},
+		{Content: "INSERT INTO users VALUES (1, 'John')", LineNum: 2, File: file1},
+		{Content: "UPDATE users SET name = 'Jane'", LineNum: 3, File: file1},
+		{Content: "DELETE FROM users", LineNum: 1, File: file2},
+	}
+
+	testCases := []struct {
+		name          string
+		config        exporter.BatchConfig
+		expectedSizes []int
+	}{
+		{
+			name:          "MaxCount",
+			config:        exporter.BatchConfig{MaxCount: 3, MaxLinger: time.Minute},
+			expectedSizes: []int{3, 1},
+		},
+		{
+			name:          "MaxBytes",
+			config:        exporter.BatchConfig{MaxBytes: 40, MaxLinger: time.Minute},
+			expectedSizes: []int{2, 2},
+		},
+		{
+			name:          "SplitOnFile",
+			config:        exporter.BatchConfig{MaxCount: 10, MaxLinger: time.Minute, SplitOnFile: true},
+			expectedSizes: []int{3, 1},
+		},
+		{
+			name:          "MaxLinger",
+			config:        exporter.BatchConfig{MaxCount: 10, MaxLinger: time.Minute},
+			expectedSizes: []int{4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			synctest.Test(t, func(t *testing.T) {
+				mock := testexporter.New()
+				statementCh := make(chan sql.Statement, len(statements))
+				logger, _ := testlogger.NewTestErrorLogger()
+				m, err := exporter.NewManager(
+					logger,
+					statementCh,
+					[]exporter.Exporter{mock},
+					exporter.WithBatchConfig(tc.config),
+				)
+				if err != nil {
+					t.Fatalf("failed creating manager: %v", err)
+				}
+
+				ctx, cancel := context.WithCancel(t.Context())
+				defer cancel()
+
+				go m.Run(ctx)
+
+				for _, statement := range statements {
+					statementCh <- statement
+				}
+
+				synctest.Wait()
+
+				// Let the partial batch linger out.
+				time.Sleep(time.Minute)
+				synctest.Wait()
+
+				assertBatchSizes(t, mock, tc.expectedSizes)
+			})
+		})
+	}
+
+	t.Run("FlushOnCancel", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			mock := testexporter.New()
+			statementCh := make(chan sql.Statement, len(statements))
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{mock},
+				exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 10, MaxLinger: time.Hour}),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				m.Run(ctx)
+				close(done)
+			}()
+
+			for _, statement := range statements {
+				statementCh <- statement
+			}
+
+			synctest.Wait()
+
+			if len(mock.Batches()) != 0 {
+				t.Fatalf("unexpected batches before cancellation: %v", mock.Batches())
+			}
+
+			cancel()
+			<-done
+
+			assertBatchSizes(t, mock, []int{len(statements)})
+		})
+	})
+}
+
+func assertBatchSizes(t *testing.T, mock *testexporter.Exporter, expectedSizes []int) {
+	t.Helper()
+
+	batches := mock.Batches()
+	if len(batches) != len(expectedSizes) {
+		t.Fatalf("batch count does not match: expected = %v, got = %v", len(expectedSizes), len(batches))
+	}
+
+	for i, batch := range batches {
+		if len(batch) != expectedSizes[i] {
+			t.Fatalf("batch %d size does not match: expected = %v, got = %v", i, expectedSizes[i], len(batch))
+		}
+	}
+}
+
+func TestManagerQueues(t *testing.T) { //nolint: gocognit
+	t.Parallel()
+
+	file := sql.File{
+		Path: "test.sql",
+		Type: "mysql",
+	}
+	statements := []sql.Statement{
+		{Content: "SELECT * FROM users", LineNum: 1, File: file},
+		{Content: "INSERT INTO users VALUES (1, 'John')", LineNum: 2, File: file},
+		{Content: "UPDATE users SET name = 'Jane'", LineNum: 3, File: file},
+	}
+
+	testCases := []struct {
+		name            string
+		overflow        exporter.OverflowPolicy
+		expectedLines   []int
+		expectedDropped uint64
+	}{
+		{
+			name:            "DropNewest",
+			overflow:        exporter.OverflowDropNewest,
+			expectedLines:   []int{1, 2},
+			expectedDropped: 1,
+		},
+		{
+			name:            "DropOldest",
+			overflow:        exporter.OverflowDropOldest,
+			expectedLines:   []int{1, 3},
+			expectedDropped: 1,
+		},
+		{
+			name:          "Spill",
+			overflow:      exporter.OverflowSpill,
+			expectedLines: []int{1, 2, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			synctest.Test(t, func(t *testing.T) {
+				slow := testexporter.New()
+				slow.Block()
+
+				fast := testexporter.New()
+				statementCh := make(chan sql.Statement)
+				logger, _ := testlogger.NewTestErrorLogger()
+				m, err := exporter.NewManager(
+					logger,
+					statementCh,
+					[]exporter.Exporter{slow, fast},
+					exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 1}),
+					exporter.WithQueueConfig(slow, exporter.QueueConfig{
+						Size:           1,
+						Overflow:       tc.overflow,
+						SpillDirectory: t.TempDir(),
+					}),
+				)
+				if err != nil {
+					t.Fatalf("failed creating manager: %v", err)
+				}
+
+				ctx, cancel := context.WithCancel(t.Context())
+				defer cancel()
+
+				go m.Run(ctx)
+
+				for _, statement := range statements {
+					statementCh <- statement
+					synctest.Wait()
+				}
+
+				// The slow exporter must not stall the fast one.
+				if len(fast.Statements()) != len(statements) {
+					t.Fatalf(
+						"fast exporter statement count does not match: expected = %v, got = %v",
+						len(statements),
+						len(fast.Statements()),
+					)
+				}
+
+				slow.Unblock()
+				synctest.Wait()
+
+				var lines []int
+				for _, statement := range slow.Statements() {
+					lines = append(lines, statement.LineNum)
+				}
+
+				if !slices.Equal(lines, tc.expectedLines) {
+					t.Fatalf("exported lines do not match: expected = %v, got = %v", tc.expectedLines, lines)
+				}
+
+				stats := m.Stats()[0]
+				if stats.DroppedCount != tc.expectedDropped {
+					t.Fatalf(
+						"dropped count does not match: expected = %v, got = %v",
+						tc.expectedDropped,
+						stats.DroppedCount,
+					)
+				}
+
+				if stats.ExportedCount != uint64(len(tc.expectedLines)) {
+					t.Fatalf(
+						"exported count does not match: expected = %v, got = %v",
+						len(tc.expectedLines),
+						stats.ExportedCount,
+					)
+				}
+			})
+		})
+	}
+
+	t.Run("SpillKeepsOrder", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			slow := testexporter.New()
+			slow.Block()
+
+			statementCh := make(chan sql.Statement)
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{slow},
+				exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 1}),
+				exporter.WithQueueConfig(slow, exporter.QueueConfig{
+					Size:           1,
+					Overflow:       exporter.OverflowSpill,
+					SpillDirectory: t.TempDir(),
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			go m.Run(ctx)
+
+			// The first statement is being exported, the second is queued and the third is spilled.
+			traced := file
+			traced.Trace = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+			for _, statement := range statements {
+				statement.File = traced
+				statementCh <- statement
+				synctest.Wait()
+			}
+
+			// The queue has room again while the third statement is still spilled.
+			slow.Step()
+			synctest.Wait()
+
+			statementCh <- sql.Statement{Content: "DELETE FROM users", LineNum: 4, File: traced}
+			synctest.Wait()
+
+			slow.Unblock()
+			synctest.Wait()
+
+			var lines []int
+			for _, statement := range slow.Statements() {
+				if statement.File.Trace != traced.Trace {
+					t.Fatalf("trace does not match: expected = %v, got = %v", traced.Trace, statement.File.Trace)
+				}
+
+				lines = append(lines, statement.LineNum)
+			}
+
+			expected := []int{1, 2, 3, 4}
+			if !slices.Equal(lines, expected) {
+				t.Fatalf("exported lines do not match: expected = %v, got = %v", expected, lines)
+			}
+		})
+	})
+
+	t.Run("SpillKeptUntilExported", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			slow := testexporter.New()
+			slow.Block()
+
+			directory := t.TempDir()
+			statementCh := make(chan sql.Statement)
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{slow},
+				exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 1}),
+				exporter.WithQueueConfig(slow, exporter.QueueConfig{
+					Size:           1,
+					Overflow:       exporter.OverflowSpill,
+					SpillDirectory: directory,
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			go m.Run(ctx)
+
+			// The first statement is being exported, the second is queued and the third is spilled.
+			for _, statement := range statements {
+				statementCh <- statement
+				synctest.Wait()
+			}
+
+			// The first two statements are exported and the spilled one is being exported.
+			slow.Step()
+			synctest.Wait()
+			slow.Step()
+			synctest.Wait()
+
+			entries, err := os.ReadDir(directory)
+			if err != nil || len(entries) != 1 {
+				t.Fatalf("expected the spilled batch to stay on disk while being exported: got = %v (%v)", entries, err)
+			}
+
+			slow.Unblock()
+			synctest.Wait()
+
+			entries, err = os.ReadDir(directory)
+			if err != nil || len(entries) != 0 {
+				t.Fatalf("expected the exported batch to be removed: got = %v (%v)", entries, err)
+			}
+		})
+	})
+
+	t.Run("CorruptSpill", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			directory := t.TempDir()
+			corrupt := filepath.Join(directory, "00000000000000000001.json")
+			err := os.WriteFile(corrupt, []byte("not json"), 0o600)
+			if err != nil {
+				t.Fatalf("failed writing corrupt spill: %v", err)
+			}
+
+			mock := testexporter.New()
+			statementCh := make(chan sql.Statement)
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{mock},
+				exporter.WithQueueConfig(mock, exporter.QueueConfig{
+					Overflow:       exporter.OverflowSpill,
+					SpillDirectory: directory,
+				}),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			go m.Run(ctx)
+
+			statementCh <- statements[0]
+			synctest.Wait()
+
+			if len(mock.Statements()) != 1 {
+				t.Fatalf("statement count does not match: expected = 1, got = %v", len(mock.Statements()))
+			}
+
+			// The corrupt batch is moved aside so it is not read again by the next run.
+			_, err = os.Stat(corrupt + ".corrupt")
+			if err != nil {
+				t.Fatalf("corrupt spill was not moved aside: %v", err)
+			}
+
+			_, err = os.Stat(corrupt)
+			if !errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("expected corrupt spill to be removed: got = %v", err)
+			}
+
+			if stats := m.Stats()[0]; stats.SpillErrorCount != 1 {
+				t.Fatalf("spill error count does not match: expected = 1, got = %v", stats.SpillErrorCount)
+			}
+		})
+	})
+
+	t.Run("DefaultSpillDirectory", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		first, second := testexporter.New(), testexporter.New()
+		logger, _ := testlogger.NewTestErrorLogger()
+		m, err := exporter.NewManager(
+			logger,
+			make(chan sql.Statement),
+			[]exporter.Exporter{first, second},
+			exporter.WithDefaultQueueConfig(exporter.QueueConfig{
+				Overflow:       exporter.OverflowSpill,
+				SpillDirectory: directory,
+			}),
+			exporter.WithName(first, "first"),
+			exporter.WithName(second, "plugin:./second"),
+			exporter.WithQueueConfig(second, exporter.QueueConfig{Size: 1}),
+		)
+		if err != nil {
+			t.Fatalf("failed creating manager: %v", err)
+		}
+
+		// Each exporter spills to its own subdirectory and fills the rest of its configuration from the default.
+		expected := []string{filepath.Join(directory, "first"), filepath.Join(directory, "plugin_._second")}
+		for i, stats := range m.Stats() {
+			if stats.SpillDirectory != expected[i] {
+				t.Fatalf("spill directory does not match: expected = %v, got = %v", expected[i], stats.SpillDirectory)
+			}
+
+			if stats.OverflowPolicy != exporter.OverflowSpill {
+				t.Fatalf("overflow policy does not match: expected = %v, got = %v",
+					exporter.OverflowSpill, stats.OverflowPolicy)
+			}
+		}
+
+		if capacity := m.Stats()[1].QueueCapacity; capacity != 1 {
+			t.Fatalf("queue capacity does not match: expected = 1, got = %v", capacity)
+		}
+	})
+
+	t.Run("SpillDirectoryRequired", func(t *testing.T) {
+		t.Parallel()
+
+		mock := testexporter.New()
+		logger, _ := testlogger.NewTestErrorLogger()
+		_, err := exporter.NewManager(
+			logger,
+			make(chan sql.Statement),
+			[]exporter.Exporter{mock},
+			exporter.WithQueueConfig(mock, exporter.QueueConfig{Overflow: exporter.OverflowSpill}),
+		)
+		if !errors.Is(err, exporter.ErrNoSpillDirectory) {
+			t.Fatalf("expected no spill directory error: got = %v", err)
+		}
+	})
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "test.sql", Type: sql.SQLite},
+		Content: "SELECT * FROM users",
+		LineNum: 1,
+	}
+
+	t.Run("StartFlushClose", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			e := &lifecycleExporter{Exporter: testexporter.New()}
+			statementCh := make(chan sql.Statement, 1)
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(logger, statementCh, []exporter.Exporter{e})
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				m.Run(ctx)
+				close(done)
+			}()
+
+			statementCh <- statement
+			synctest.Wait()
+
+			cancel()
+			<-done
+
+			expected := []string{"start", "export", "flush", "close"}
+			if !slices.Equal(e.calls, expected) {
+				t.Fatalf("lifecycle calls do not match: expected = %v, got = %v", expected, e.calls)
+			}
+		})
+	})
+
+	t.Run("FailedStart", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			e := &lifecycleExporter{Exporter: testexporter.New(), startErr: errExport}
+			queue := exporter.NewDeadLetterQueue(t.TempDir())
+			statementCh := make(chan sql.Statement, 1)
+			logger, loggerWriter := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{e},
+				exporter.WithDeadLetterQueue(queue),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			go m.Run(ctx)
+
+			statementCh <- statement
+			synctest.Wait()
+
+			if len(e.Statements()) != 0 {
+				t.Fatalf("exporter that failed to start exported statements: %v", e.Statements())
+			}
+
+			deadLetters, err := queue.Read("")
+			if err != nil {
+				t.Fatalf("failed reading dead letters: %v", err)
+			}
+
+			if len(deadLetters) != 1 {
+				t.Fatalf("dead letter count does not match: expected = %v, got = %v", 1, len(deadLetters))
+			}
+
+			// Failed start and failed export.
+			loggerWriter.AssertWrites(t, 2)
+
+			stats := m.Stats()[0]
+			if !errors.Is(stats.LastError, errExport) || stats.LastErrorTime.IsZero() || stats.CircuitOpen ||
+				!stats.Failing || !m.Started() {
+				t.Fatalf("unexpected exporter stats: got = %+v", stats)
+			}
+		})
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			mock := testexporter.New()
+			mock.Block()
+
+			queue := exporter.NewDeadLetterQueue(t.TempDir())
+			statementCh := make(chan sql.Statement, 3)
+			logger, _ := testlogger.NewTestErrorLogger()
+			m, err := exporter.NewManager(
+				logger,
+				statementCh,
+				[]exporter.Exporter{mock},
+				exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 1}),
+				exporter.WithDeadLetterQueue(queue),
+			)
+			if err != nil {
+				t.Fatalf("failed creating manager: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				m.Run(ctx)
+				close(done)
+			}()
+
+			for i := range 3 {
+				statementCh <- sql.Statement{File: statement.File, Content: statement.Content, LineNum: i + 1}
+			}
+
+			synctest.Wait()
+			cancel()
+			m.Abort()
+			m.Abort()
+			synctest.Wait()
+			mock.Unblock()
+			<-done
+
+			// The export in flight completes, the queued ones are aborted.
+			if len(mock.Statements()) != 1 {
+				t.Fatalf("exported count does not match: expected = %v, got = %v", 1, len(mock.Statements()))
+			}
+
+			deadLetters, err := queue.Read("")
+			if err != nil {
+				t.Fatalf("failed reading dead letters: %v", err)
+			}
+
+			if len(deadLetters) != 2 {
+				t.Fatalf("dead letter count does not match: expected = %v, got = %v", 2, len(deadLetters))
+			}
+		})
+	})
+}
+
+// lifecycleExporter records calls of its lifecycle methods.
+type lifecycleExporter struct {
+	*testexporter.Exporter
+
+	startErr error
+	calls    []string
+}
+
+func (e *lifecycleExporter) Start(_ context.Context) error {
+	e.calls = append(e.calls, "start")
+	return e.startErr
+}
+
+func (e *lifecycleExporter) ExportBatchContext(_ context.Context, statements []sql.Statement) error {
+	e.calls = append(e.calls, "export")
+	return e.ExportBatch(statements)
+}
+
+func (e *lifecycleExporter) ExportContext(ctx context.Context, statement sql.Statement) error {
+	return e.ExportBatchContext(ctx, []sql.Statement{statement})
+}
+
+func (e *lifecycleExporter) Flush(_ context.Context) error {
+	e.calls = append(e.calls, "flush")
+	return nil
+}
+
+func (e *lifecycleExporter) Close() error {
+	e.calls = append(e.calls, "close")
+	return nil
+}
+
+func TestManagerMetrics(t *testing.T) {
+	t.Parallel()
+
+	synctest.Test(t, func(t *testing.T) {
+		file := sql.File{Path: "test.sql", Type: "mysql"}
+		ok, failing, slow := testexporter.New(), testexporter.New(), testexporter.New()
+		failing.Fail(errors.New("connection refused"))
+		slow.Block()
+
+		registry := prometheus.NewRegistry()
+		statementCh := make(chan sql.Statement)
+		logger, _ := testlogger.NewTestErrorLogger()
+		m, err := exporter.NewManager(
+			logger,
+			statementCh,
+			[]exporter.Exporter{ok, failing, slow},
+			exporter.WithName(ok, "ok"),
+			exporter.WithName(failing, "failing"),
+			exporter.WithName(slow, "slow"),
+			exporter.WithBatchConfig(exporter.BatchConfig{MaxCount: 1}),
+			exporter.WithQueueConfig(slow, exporter.QueueConfig{Size: 1, Overflow: exporter.OverflowDropNewest}),
+			exporter.WithMetrics(exporter.NewMetrics(registry)),
+		)
+		if err != nil {
+			t.Fatalf("failed creating manager: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		go m.Run(ctx)
+
+		for i := range 3 {
+			statementCh <- sql.Statement{Content: "SELECT 1", LineNum: i + 1, File: file}
+			synctest.Wait()
+		}
+
+		// The slow exporter is exporting the first batch, the second one is queued and the third one dropped.
+		expected := `
+# HELP sql_processor_exporter_dropped_statements_total Number of statements dropped because of a full exporter queue.
+# TYPE sql_processor_exporter_dropped_statements_total counter
+sql_processor_exporter_dropped_statements_total{exporter="slow"} 1
+# HELP sql_processor_exporter_export_errors_total Number of batches that failed to export.
+# TYPE sql_processor_exporter_export_errors_total counter
+sql_processor_exporter_export_errors_total{exporter="failing"} 3
+# HELP sql_processor_exporter_exported_statements_total Number of successfully exported statements.
+# TYPE sql_processor_exporter_exported_statements_total counter
+sql_processor_exporter_exported_statements_total{exporter="ok"} 3
+# HELP sql_processor_exporter_failed_statements_total Number of statements that failed to export.
+# TYPE sql_processor_exporter_failed_statements_total counter
+sql_processor_exporter_failed_statements_total{exporter="failing"} 3
+# HELP sql_processor_exporter_queue_depth Number of batches waiting in the exporter queue.
+# TYPE sql_processor_exporter_queue_depth gauge
+sql_processor_exporter_queue_depth{exporter="failing"} 0
+sql_processor_exporter_queue_depth{exporter="ok"} 0
+sql_processor_exporter_queue_depth{exporter="slow"} 1
+`
+		err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+			"sql_processor_exporter_dropped_statements_total",
+			"sql_processor_exporter_export_errors_total",
+			"sql_processor_exporter_exported_statements_total",
+			"sql_processor_exporter_failed_statements_total",
+			"sql_processor_exporter_queue_depth",
+		)
+		if err != nil {
+			t.Fatalf("unexpected metrics: %v", err)
+		}
+
+		count, err := testutil.GatherAndCount(registry, "sql_processor_exporter_batch_size")
+		if err != nil || count != 1 {
+			t.Fatalf("expected = %v, got = %v (%v)", 1, count, err)
+		}
+
+		slow.Unblock()
+		cancel()
+		synctest.Wait()
+	})
+}
+
+func TestManagerTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+	ctx1, span1 := tracer.Start(t.Context(), "observe file")
+	ctx2, span2 := tracer.Start(t.Context(), "observe file")
+	span1.End()
+	span2.End()
+
+	file1 := sql.File{Path: "test1.sql", Type: "mysql", Trace: tracing.TraceParent(ctx1)}
+	file2 := sql.File{Path: "test2.sql", Type: "mysql", Trace: tracing.TraceParent(ctx2)}
+	statementCh := make(chan sql.Statement, 3)
+	statementCh <- sql.Statement{Content: "SELECT 1", LineNum: 1, File: file1}
+	statementCh <- sql.Statement{Content: "SELECT 2", LineNum: 2, File: file1}
+	statementCh <- sql.Statement{Content: "SELECT 3", LineNum: 1, File: file2}
+	close(statementCh)
+
+	failing := testexporter.New()
+	failing.Fail(errors.New("connection refused"))
+	logger, _ := testlogger.NewTestErrorLogger()
+	m, err := exporter.NewManager(
+		logger,
+		statementCh,
+		[]exporter.Exporter{failing},
+		exporter.WithTracerProvider(provider),
+	)
+	if err != nil {
+		t.Fatalf("failed creating manager: %v", err)
+	}
+
+	m.Run(t.Context())
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected = %v, got = %v", 3, len(spans))
+	}
+
+	// The batch is exported in the trace of its first file and links the traces of both files.
+	export := spans[2]
+	if export.Name() != "export" || export.Parent().SpanID() != span1.SpanContext().SpanID() {
+		t.Fatalf("unexpected span: got = %v, parent = %v", export.Name(), export.Parent())
+	}
+
+	links := export.Links()
+	if len(links) != 2 || links[0].SpanContext.SpanID() != span1.SpanContext().SpanID() ||
+		links[1].SpanContext.SpanID() != span2.SpanContext().SpanID() {
+		t.Fatalf("unexpected links: got = %v", links)
+	}
+
+	if export.Status().Code != codes.Error {
+		t.Fatalf("expected = %v, got = %v", codes.Error, export.Status().Code)
+	}
+}
@@ -0,0 +1,119 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the Prometheus metrics of the [Manager].
+// A nil [*Metrics] records nothing.
+type Metrics struct {
+	batchSize          prometheus.Histogram
+	exportDuration     *prometheus.HistogramVec
+	exportErrors       *prometheus.CounterVec
+	exportedStatements *prometheus.CounterVec
+	failedStatements   *prometheus.CounterVec
+	droppedStatements  *prometheus.CounterVec
+	queueDepth         *prometheus.GaugeVec
+}
+
+// NewMetrics creates the [Manager] metrics and registers them with the registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	labels := []string{"exporter"}
+	m := &Metrics{
+		batchSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "batch_size",
+			Help:      "Number of statements in a batch passed to the exporters.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 11), //nolint: mnd
+		}),
+		exportDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "export_duration_seconds",
+			Help:      "Time of exporting a single batch.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		exportErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "export_errors_total",
+			Help:      "Number of batches that failed to export.",
+		}, labels),
+		exportedStatements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "exported_statements_total",
+			Help:      "Number of successfully exported statements.",
+		}, labels),
+		failedStatements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "failed_statements_total",
+			Help:      "Number of statements that failed to export.",
+		}, labels),
+		droppedStatements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "dropped_statements_total",
+			Help:      "Number of statements dropped because of a full exporter queue.",
+		}, labels),
+		queueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "sql_processor",
+			Subsystem: "exporter",
+			Name:      "queue_depth",
+			Help:      "Number of batches waiting in the exporter queue.",
+		}, labels),
+	}
+	registerer.MustRegister(
+		m.batchSize,
+		m.exportDuration,
+		m.exportErrors,
+		m.exportedStatements,
+		m.failedStatements,
+		m.droppedStatements,
+		m.queueDepth,
+	)
+	return m
+}
+
+func (m *Metrics) batched(statements int) {
+	if m == nil {
+		return
+	}
+
+	m.batchSize.Observe(float64(statements))
+}
+
+func (m *Metrics) exported(exporter string, statements int, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	m.exportDuration.WithLabelValues(exporter).Observe(duration.Seconds())
+	if err != nil {
+		m.exportErrors.WithLabelValues(exporter).Inc()
+		m.failedStatements.WithLabelValues(exporter).Add(float64(statements))
+		return
+	}
+
+	m.exportedStatements.WithLabelValues(exporter).Add(float64(statements))
+}
+
+func (m *Metrics) dropped(exporter string, statements int) {
+	if m == nil {
+		return
+	}
+
+	m.droppedStatements.WithLabelValues(exporter).Add(float64(statements))
+}
+
+func (m *Metrics) queued(exporter string, depth int) {
+	if m == nil {
+		return
+	}
+
+	m.queueDepth.WithLabelValues(exporter).Set(float64(depth))
+}
@@ -0,0 +1,201 @@
+package exporter
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const paramsTag = "param"
+
+var (
+	ErrUnknownParam = errors.New("unknown parameter")
+	ErrInvalidParam = errors.New("invalid parameter")
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Params holds exporter configuration as key-value pairs. A key may hold multiple values.
+type Params map[string][]string
+
+// DecodeParams decodes the parameters into the configuration struct pointed to by config.
+//
+// Each exported field is set by the parameter named after the field in kebab case,
+// so the BatchSize field is set by "batch-size". The name can be overridden by the
+// `param:"name"` field tag and the field can be skipped by `param:"-"`. Supported field
+// kinds are strings, booleans, numbers, [time.Duration]s, [encoding.TextUnmarshaler]s
+// and slices of them. Fields of other kinds are skipped. Unknown parameters are an error.
+func DecodeParams(params Params, config any) (err error) {
+	target := reflect.ValueOf(config)
+	if target.Kind() != reflect.Pointer || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: config must be a pointer to a struct, got %T", ErrInvalidParam, config)
+	}
+
+	target = target.Elem()
+	known := make(map[string]struct{})
+	for i := range target.NumField() {
+		field := target.Type().Field(i)
+		name := paramName(field)
+		if name == "" || !decodable(field.Type) {
+			continue
+		}
+
+		known[name] = struct{}{}
+		values, ok := params[name]
+		if !ok {
+			continue
+		}
+
+		err = setField(target.Field(i), values)
+		if err != nil {
+			return fmt.Errorf("%w %s: %w", ErrInvalidParam, name, err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(params)) {
+		if _, ok := known[name]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownParam, name)
+		}
+	}
+
+	return nil
+}
+
+// paramName returns the parameter name of the field or an empty string when the field is skipped.
+func paramName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	tag := field.Tag.Get(paramsTag)
+	switch tag {
+	case "-":
+		return ""
+	case "":
+		return kebabCase(field.Name)
+	default:
+		return tag
+	}
+}
+
+// kebabCase converts a Go identifier to kebab case keeping acronyms together,
+// so "OriginPatterns" becomes "origin-patterns" and "DSNTimeout" becomes "dsn-timeout".
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				builder.WriteByte('-')
+			}
+		}
+
+		builder.WriteRune(unicode.ToLower(r))
+	}
+
+	return builder.String()
+}
+
+func decodable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) || t == durationType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Slice && decodable(t.Elem())
+	default:
+		return false
+	}
+}
+
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && !field.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setValue(slice.Index(i), value)
+			if err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+		return nil
+	}
+
+	if len(values) != 1 {
+		return fmt.Errorf("expected a single value, got %d", len(values))
+	}
+
+	return setValue(field, values[0])
+}
+
+func setValue(field reflect.Value, value string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
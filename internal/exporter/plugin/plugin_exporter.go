@@ -0,0 +1,430 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	defaultAckTimeout      = 10 * time.Second
+	defaultShutdownTimeout = 5 * time.Second
+	defaultInitialBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff      = 30 * time.Second
+	// stableAfter is the run time after which a crash no longer counts as repeated.
+	stableAfter         = 10 * time.Second
+	maxResponseSize     = 1 << 20
+	responseBufferSize  = 16
+	messageTypeExport   = "export"
+	messageTypeBatch    = "batch"
+	messageTypeShutdown = "shutdown"
+	responseTypeAck     = "ack"
+	responseTypeError   = "error"
+)
+
+var (
+	ErrNoCommand    = errors.New("no plugin command configured")
+	ErrNotRunning   = errors.New("plugin not running")
+	ErrPluginFailed = errors.New("plugin failed exporting")
+	ErrPluginExited = errors.New("plugin exited")
+	ErrAckTimeout   = errors.New("timed out waiting for plugin acknowledgement")
+)
+
+var (
+	_ exporter.Exporter        = &Exporter{}
+	_ exporter.ContextExporter = &Exporter{}
+	_ exporter.Starter         = &Exporter{}
+	_ exporter.Closer          = &Exporter{}
+	_ exporter.Namer           = &Exporter{}
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Command is the plugin executable.
+	Command string
+	// Args are the arguments passed to the plugin.
+	Args []string
+	// Env is appended to the environment of the processor.
+	Env []string
+	// Dir is the working directory of the plugin. Defaults to the working directory of the processor.
+	Dir string
+	// Stderr receives the standard error of the plugin. Defaults to [os.Stderr].
+	Stderr io.Writer
+	// AckTimeout limits waiting for an acknowledgement. Defaults to 10 seconds.
+	AckTimeout time.Duration
+	// ShutdownTimeout is the time given to the plugin to exit before it gets killed. Defaults to 5 seconds.
+	ShutdownTimeout time.Duration
+	// InitialBackoff is the wait time before restarting a crashed plugin. Defaults to 100 milliseconds.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between restarts of a repeatedly crashing plugin. Defaults to 30 seconds.
+	MaxBackoff time.Duration
+}
+
+// Exporter implements [exporter.Exporter] and exports given [sql.Statement]s to an external
+// plugin process speaking the line-delimited JSON protocol described in PROTOCOL.md.
+//
+// The plugin is launched by Start and restarted with exponential backoff whenever it exits.
+// Exports fail with [ErrNotRunning] while the plugin is restarting.
+type Exporter struct {
+	config Config
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	exportMu sync.Mutex
+	nextID   uint64
+
+	mu       sync.Mutex
+	process  *process
+	lastErr  error
+	restarts int
+	started  bool
+
+	closeOnce sync.Once
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Command == "" {
+		return nil, ErrNoCommand
+	}
+
+	if config.Stderr == nil {
+		config.Stderr = os.Stderr
+	}
+
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = defaultAckTimeout
+	}
+
+	if config.ShutdownTimeout <= 0 {
+		config.ShutdownTimeout = defaultShutdownTimeout
+	}
+
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaultInitialBackoff
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaultMaxBackoff
+	}
+
+	return &Exporter{
+		config: config,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}, nil
+}
+
+// Register registers the "plugin" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "plugin", func(config Config) (exporter.Exporter, error) {
+		e, err := NewExporter(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+// Name implements exporter.Namer.
+func (e *Exporter) Name() (name string) {
+	return "plugin:" + e.config.Command
+}
+
+// Start implements exporter.Starter. It launches the plugin and keeps supervising it until Close.
+func (e *Exporter) Start(_ context.Context) (err error) {
+	p, err := e.launch()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.started = true
+	e.mu.Unlock()
+
+	go e.supervise(p)
+	return nil
+}
+
+// Restarts returns the number of times the plugin was restarted.
+func (e *Exporter) Restarts() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.restarts
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportContext(context.Background(), statement)
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+// ExportContext implements exporter.ContextExporter.
+func (e *Exporter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	return e.send(ctx, message{Type: messageTypeExport, Statement: &statement})
+}
+
+// ExportBatchContext implements exporter.ContextExporter.
+func (e *Exporter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	return e.send(ctx, message{Type: messageTypeBatch, Statements: statements})
+}
+
+// Close implements exporter.Closer. It asks the plugin to shut down and stops supervising it.
+// Closing the exporter again does nothing.
+func (e *Exporter) Close() (err error) {
+	e.closeOnce.Do(func() {
+		e.mu.Lock()
+		started := e.started
+		e.mu.Unlock()
+
+		if !started {
+			return
+		}
+
+		close(e.stopCh)
+		<-e.doneCh
+	})
+
+	return nil
+}
+
+// send sends the message to the running plugin and waits for its response.
+func (e *Exporter) send(ctx context.Context, m message) error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	e.mu.Lock()
+	p := e.process
+	lastErr := e.lastErr
+	e.mu.Unlock()
+
+	if p == nil {
+		if lastErr != nil {
+			return fmt.Errorf("%w: %w", ErrNotRunning, lastErr)
+		}
+
+		return ErrNotRunning
+	}
+
+	e.nextID++
+	m.ID = e.nextID
+	err := p.write(m)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(e.config.AckTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case r := <-p.responseCh:
+			if r.ID != m.ID {
+				continue
+			}
+
+			if r.Type == responseTypeError {
+				return fmt.Errorf("%w: %s", ErrPluginFailed, r.Error)
+			}
+
+			return nil
+		case <-p.exitedCh:
+			return p.exitErr()
+		case <-timer.C:
+			return ErrAckTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// supervise restarts the plugin whenever it exits until the exporter gets closed.
+func (e *Exporter) supervise(p *process) {
+	defer close(e.doneCh)
+
+	backoff := e.config.InitialBackoff
+	for {
+		started := time.Now()
+		select {
+		case <-p.exitedCh:
+		case <-e.stopCh:
+			p.shutdown(e.config.ShutdownTimeout)
+			e.setProcess(nil, nil)
+			return
+		}
+
+		e.setProcess(nil, p.exitErr())
+		if time.Since(started) >= stableAfter {
+			backoff = e.config.InitialBackoff
+		}
+
+		for {
+			select {
+			case <-time.After(backoff):
+			case <-e.stopCh:
+				return
+			}
+
+			backoff = min(2*backoff, e.config.MaxBackoff)
+
+			var err error
+			p, err = e.launch()
+			if err == nil {
+				break
+			}
+
+			e.setProcess(nil, err)
+		}
+
+		e.mu.Lock()
+		e.restarts++
+		e.mu.Unlock()
+	}
+}
+
+func (e *Exporter) setProcess(p *process, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.process = p
+	e.lastErr = err
+}
+
+// launch starts the plugin process.
+func (e *Exporter) launch() (*process, error) {
+	cmd := exec.Command(e.config.Command, e.config.Args...)
+	cmd.Env = append(os.Environ(), e.config.Env...)
+	cmd.Dir = e.config.Dir
+	cmd.Stderr = e.config.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed creating plugin stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed creating plugin stdout: %w", err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("failed starting plugin: %w", err)
+	}
+
+	p := &process{
+		cmd:        cmd,
+		stdin:      stdin,
+		encoder:    json.NewEncoder(stdin),
+		responseCh: make(chan response, responseBufferSize),
+		exitedCh:   make(chan struct{}),
+	}
+	go p.read(stdout)
+
+	e.setProcess(p, nil)
+	return p, nil
+}
+
+// message is sent to the plugin.
+type message struct {
+	Type       string          `json:"type"`
+	ID         uint64          `json:"id,omitempty"`
+	Statement  *sql.Statement  `json:"statement,omitempty"`
+	Statements []sql.Statement `json:"statements,omitempty"`
+}
+
+// response is received from the plugin.
+type response struct {
+	Type  string `json:"type"`
+	ID    uint64 `json:"id"`
+	Error string `json:"error"`
+}
+
+// process is a single run of the plugin.
+type process struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	writeMu sync.Mutex
+	encoder *json.Encoder
+
+	responseCh chan response
+	exitedCh   chan struct{}
+	err        error
+}
+
+// read reads responses until the plugin closes its standard output and then waits for it to exit.
+func (p *process) read(stdout io.Reader) {
+	defer close(p.exitedCh)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(nil, maxResponseSize)
+	for scanner.Scan() {
+		var r response
+		err := json.Unmarshal(scanner.Bytes(), &r)
+		if err != nil || (r.Type != responseTypeAck && r.Type != responseTypeError) {
+			continue
+		}
+
+		select {
+		case p.responseCh <- r:
+		default:
+		}
+	}
+
+	// Drain the output so the plugin does not block on writing.
+	_, _ = io.Copy(io.Discard, stdout)
+	p.err = p.cmd.Wait()
+}
+
+// exitErr returns the reason the plugin exited. It may only be called after exitedCh is closed.
+func (p *process) exitErr() error {
+	if p.err == nil {
+		return ErrPluginExited
+	}
+
+	return fmt.Errorf("%w: %w", ErrPluginExited, p.err)
+}
+
+func (p *process) write(m message) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	err := p.encoder.Encode(m)
+	if err != nil {
+		return fmt.Errorf("failed writing to plugin: %w", err)
+	}
+
+	return nil
+}
+
+// shutdown asks the plugin to exit and kills it if it does not exit in time.
+func (p *process) shutdown(timeout time.Duration) {
+	_ = p.write(message{Type: messageTypeShutdown})
+	_ = p.stdin.Close()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.exitedCh:
+	case <-timer.C:
+		_ = p.cmd.Process.Kill()
+		<-p.exitedCh
+	}
+}
@@ -0,0 +1,237 @@
+package plugin_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter/plugin"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const helperEnv = "PLUGIN_HELPER_DIR"
+
+var statement = sql.Statement{
+	File:    sql.File{Path: "/var/sql/users.sql", Type: sql.PostgresType},
+	Content: "SELECT *\nFROM users",
+	LineNum: 3,
+}
+
+// TestHelperProcess is not a real test. It is the plugin launched by the other tests.
+// It appends received statements to a file in the directory given by the environment,
+// fails statements containing FAIL and crashes on the first request when asked to.
+func TestHelperProcess(t *testing.T) {
+	t.Parallel()
+
+	dir := os.Getenv(helperEnv)
+	if dir == "" {
+		t.Skip("helper process")
+	}
+
+	crashMarker := filepath.Join(dir, "crashed")
+	_, err := os.Stat(crashMarker)
+	crash := os.Getenv("PLUGIN_HELPER_CRASH") != "" && errors.Is(err, os.ErrNotExist)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var request struct {
+			Type       string          `json:"type"`
+			ID         uint64          `json:"id"`
+			Statement  *sql.Statement  `json:"statement"`
+			Statements []sql.Statement `json:"statements"`
+		}
+		_ = json.Unmarshal(scanner.Bytes(), &request)
+		if request.Type == "shutdown" {
+			_ = os.WriteFile(filepath.Join(dir, "shutdown"), nil, 0o600)
+			break
+		}
+
+		if crash {
+			_ = os.WriteFile(crashMarker, nil, 0o600)
+			os.Exit(1)
+		}
+
+		statements := request.Statements
+		if request.Statement != nil {
+			statements = append(statements, *request.Statement)
+		}
+
+		response := map[string]any{"type": "ack", "id": request.ID}
+		for _, s := range statements {
+			if strings.Contains(s.Content, "FAIL") {
+				response = map[string]any{"type": "error", "id": request.ID, "error": "refused " + s.Content}
+			}
+		}
+
+		if response["type"] == "ack" {
+			appendStatements(dir, statements)
+		}
+
+		fmt.Println("not a response")
+		_ = json.NewEncoder(os.Stdout).Encode(response)
+	}
+
+	os.Exit(0)
+}
+
+func appendStatements(dir string, statements []sql.Statement) {
+	file, err := os.OpenFile(filepath.Join(dir, "statements"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	for _, s := range statements {
+		_ = json.NewEncoder(file).Encode(s)
+	}
+}
+
+func newExporter(t *testing.T, env ...string) (*plugin.Exporter, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	e, err := plugin.NewExporter(plugin.Config{
+		Command:        os.Args[0],
+		Args:           []string{"-test.run=^TestHelperProcess$"},
+		Env:            append(env, helperEnv+"="+dir),
+		Stderr:         io.Discard,
+		InitialBackoff: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	err = e.Start(t.Context())
+	if err != nil {
+		t.Fatalf("failed starting exporter: %v", err)
+	}
+
+	return e, dir
+}
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoCommand", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := plugin.NewExporter(plugin.Config{})
+		if !errors.Is(err, plugin.ErrNoCommand) {
+			t.Fatalf("expected = %v, got = %v", plugin.ErrNoCommand, err)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		t.Parallel()
+
+		e, dir := newExporter(t)
+		err := e.Export(statement)
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		err = e.ExportBatch([]sql.Statement{statement, statement})
+		if err != nil {
+			t.Fatalf("failed exporting statements: %v", err)
+		}
+
+		err = e.Close()
+		if err != nil {
+			t.Fatalf("failed closing exporter: %v", err)
+		}
+
+		// The manager and the dlq replay command may both close the exporter.
+		err = e.Close()
+		if err != nil {
+			t.Fatalf("failed closing exporter again: %v", err)
+		}
+
+		assertStatements(t, dir, 3)
+		_, err = os.Stat(filepath.Join(dir, "shutdown"))
+		if err != nil {
+			t.Fatalf("expected plugin to shut down: %v", err)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Parallel()
+
+		e, _ := newExporter(t)
+		defer func() {
+			_ = e.Close()
+		}()
+
+		failing := statement
+		failing.Content = "FAIL"
+		err := e.ExportBatch([]sql.Statement{statement, failing})
+		if !errors.Is(err, plugin.ErrPluginFailed) {
+			t.Fatalf("expected = %v, got = %v", plugin.ErrPluginFailed, err)
+		}
+	})
+
+	t.Run("Restart", func(t *testing.T) {
+		t.Parallel()
+
+		e, dir := newExporter(t, "PLUGIN_HELPER_CRASH=1")
+		defer func() {
+			_ = e.Close()
+		}()
+
+		err := e.Export(statement)
+		if !errors.Is(err, plugin.ErrPluginExited) {
+			t.Fatalf("expected = %v, got = %v", plugin.ErrPluginExited, err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for e.Restarts() == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("expected plugin to restart")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		err = e.Export(statement)
+		if err != nil {
+			t.Fatalf("failed exporting statement after restart: %v", err)
+		}
+
+		assertStatements(t, dir, 1)
+	})
+}
+
+func assertStatements(t *testing.T, dir string, count int) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "statements"))
+	if err != nil {
+		t.Fatalf("failed reading exported statements: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != count {
+		t.Fatalf("expected = %d statements, got = %d", count, len(lines))
+	}
+
+	for _, line := range lines {
+		var got sql.Statement
+		err = json.Unmarshal([]byte(line), &got)
+		if err != nil {
+			t.Fatalf("failed decoding statement: %v", err)
+		}
+
+		if got != statement {
+			t.Fatalf("expected = %v, got = %v", statement, got)
+		}
+	}
+}
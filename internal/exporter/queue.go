@@ -0,0 +1,608 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultQueueSize   = 64
+	spillFileSuffix    = ".json"
+	corruptSpillSuffix = ".corrupt"
+
+	spillDirectoryPermissions = 0o700
+	spillFilePermissions      = 0o600
+)
+
+var (
+	ErrUnknownOverflowPolicy = errors.New("unknown overflow policy")
+	ErrNoSpillDirectory      = errors.New("spill overflow policy requires a spill directory")
+	ErrNotStarted            = errors.New("exporter failed to start")
+)
+
+var errCorruptSpill = errors.New("corrupt spilled batch")
+
+// OverflowPolicy represents what happens to a batch when an exporter queue is full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock waits until there is room in the queue.
+	// It stalls all exporters managed by the same [Manager].
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest drops the oldest queued batch to make room for the new one.
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowDropNewest drops the new batch.
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowSpill writes the new batch to disk and exports it once the queue drains.
+	// New batches keep being spilled until all spilled batches are exported so the order is kept.
+	OverflowSpill OverflowPolicy = "spill"
+)
+
+// ParseOverflowPolicy parses the [OverflowPolicy] from the given input.
+func ParseOverflowPolicy(input string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(input); policy {
+	case OverflowBlock, OverflowDropOldest, OverflowDropNewest, OverflowSpill:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnknownOverflowPolicy, input)
+	}
+}
+
+// QueueConfig represents configuration of a queue in front of a single [Exporter].
+type QueueConfig struct {
+	// Size is the queue capacity in batches.
+	Size int
+	// Overflow decides what happens to a batch when the queue is full.
+	Overflow OverflowPolicy
+	// SpillDirectory is the directory batches are spilled to with [OverflowSpill].
+	// Spilled batches which cannot be decoded are moved aside with the ".corrupt" suffix.
+	SpillDirectory string
+}
+
+// DefaultQueueConfig returns the default [QueueConfig].
+func DefaultQueueConfig() QueueConfig {
+	return QueueConfig{
+		Size:     defaultQueueSize,
+		Overflow: OverflowBlock,
+	}
+}
+
+// ExporterStats represents statistics of a single [Exporter] managed by [Manager].
+//
+// Statement counters are cumulative since the [Manager] creation.
+type ExporterStats struct {
+	Name            string
+	QueueDepth      int
+	QueueCapacity   int
+	SpilledBatches  int
+	ExportedCount   uint64
+	FailedCount     uint64
+	DroppedCount    uint64
+	DeadLetterCount uint64
+	OverflowPolicy  OverflowPolicy
+	SpillDirectory  string
+	SpillErrorCount uint64
+	// CircuitOpen reports whether the exporter implements [CircuitBreaker] and its circuit is open.
+	CircuitOpen bool
+	// LastError is the error of the last failed export, if any.
+	LastError error
+	// LastErrorTime is the time of the last failed export.
+	LastErrorTime time.Time
+	// Failing reports whether the last export failed.
+	Failing bool
+}
+
+// worker exports batches of a single [Exporter] from its own bounded queue.
+// It also drives the exporter lifecycle.
+type worker struct {
+	logger          *slog.Logger
+	name            string
+	exporter        Exporter
+	contextExporter ContextExporter
+	startErr        error
+	route           Route
+	config          QueueConfig
+	queue           chan []sql.Statement
+	spill           *spill
+	wakeCh          chan struct{}
+	dlq             *DeadLetterQueue
+	metrics         *Metrics
+	tracer          trace.Tracer
+
+	exported    atomic.Uint64
+	failed      atomic.Uint64
+	dropped     atomic.Uint64
+	deadLetters atomic.Uint64
+	spillErrors atomic.Uint64
+
+	mu            sync.Mutex
+	lastErr       error
+	lastErrorTime time.Time
+	failing       bool
+}
+
+func newWorker(
+	logger *slog.Logger,
+	name string,
+	exporter Exporter,
+	config QueueConfig,
+	route Route,
+	dlq *DeadLetterQueue,
+	metrics *Metrics,
+	tracer trace.Tracer,
+) (w *worker, err error) {
+	if config.Size <= 0 {
+		config.Size = defaultQueueSize
+	}
+
+	if config.Overflow == "" {
+		config.Overflow = OverflowBlock
+	}
+
+	_, err = ParseOverflowPolicy(string(config.Overflow))
+	if err != nil {
+		return nil, err
+	}
+
+	w = &worker{
+		logger:          logger.With("exporter", name),
+		name:            name,
+		exporter:        exporter,
+		contextExporter: Adapt(exporter),
+		route:           route,
+		config:          config,
+		queue:           make(chan []sql.Statement, config.Size),
+		wakeCh:          make(chan struct{}, 1),
+		dlq:             dlq,
+		metrics:         metrics,
+		tracer:          tracer,
+	}
+	if config.Overflow == OverflowSpill {
+		if config.SpillDirectory == "" {
+			return nil, ErrNoSpillDirectory
+		}
+
+		w.spill, err = openSpill(config.SpillDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening spill directory: %w", err)
+		}
+
+		// Batches spilled by a previous run are waiting.
+		w.wake()
+	}
+
+	return w, nil
+}
+
+// enqueue puts statements of the batch passing the [Route] to the queue respecting the [OverflowPolicy].
+func (w *worker) enqueue(statements []sql.Statement) {
+	statements = w.route.filter(statements)
+	if len(statements) == 0 {
+		return
+	}
+
+	defer func() {
+		w.metrics.queued(w.name, len(w.queue))
+	}()
+
+	switch w.config.Overflow {
+	case OverflowDropNewest:
+		select {
+		case w.queue <- statements:
+		default:
+			w.drop(statements)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- statements:
+				return
+			default:
+			}
+
+			select {
+			case oldest := <-w.queue:
+				w.drop(oldest)
+			default:
+			}
+		}
+	case OverflowSpill:
+		// Batches queued while older ones wait in the spill would overtake them.
+		if w.spill.len() == 0 {
+			select {
+			case w.queue <- statements:
+				return
+			default:
+			}
+		}
+
+		err := w.spill.push(statements)
+		if err != nil {
+			w.spillErrors.Add(1)
+			w.logger.Error("failed spilling statements to disk", "error", err)
+			w.drop(statements)
+			return
+		}
+
+		w.wake()
+	default:
+		w.queue <- statements
+	}
+}
+
+// run starts the exporter, exports queued batches until the queue gets closed
+// and then flushes and closes the exporter.
+// Spilled batches are exported whenever the queue is empty.
+func (w *worker) run(ctx context.Context) {
+	w.start(ctx)
+	defer w.stop(ctx)
+
+	for {
+		select {
+		case statements, ok := <-w.queue:
+			if !ok {
+				w.unspillAll(ctx)
+				return
+			}
+
+			w.metrics.queued(w.name, len(w.queue))
+			w.export(ctx, statements)
+		case <-w.wakeCh:
+		}
+
+		if len(w.queue) == 0 && w.unspill(ctx) {
+			w.wake()
+		}
+	}
+}
+
+func (w *worker) start(ctx context.Context) {
+	starter, ok := w.exporter.(Starter)
+	if !ok {
+		return
+	}
+
+	err := starter.Start(ctx)
+	if err != nil {
+		w.startErr = fmt.Errorf("%w: %w", ErrNotStarted, err)
+		w.logger.Error("failed starting exporter", "error", err)
+	}
+}
+
+func (w *worker) stop(ctx context.Context) {
+	flusher, ok := w.exporter.(Flusher)
+	if ok && w.startErr == nil {
+		err := flusher.Flush(ctx)
+		if err != nil {
+			w.logger.Error("failed flushing exporter", "error", err)
+		}
+	}
+
+	closer, ok := w.exporter.(Closer)
+	if ok {
+		err := closer.Close()
+		if err != nil {
+			w.logger.Error("failed closing exporter", "error", err)
+		}
+	}
+}
+
+func (w *worker) export(ctx context.Context, statements []sql.Statement) {
+	start := time.Now()
+	err := w.startErr
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if err == nil {
+		err = w.exportTraced(ctx, statements)
+	}
+
+	w.metrics.exported(w.name, len(statements), time.Since(start), err)
+
+	if err != nil {
+		w.failed.Add(uint64(len(statements)))
+		w.mu.Lock()
+		w.lastErr, w.lastErrorTime, w.failing = err, time.Now(), true
+		w.mu.Unlock()
+
+		w.logger.Error("failed exporting statements",
+			"error", err,
+			"statements", len(statements),
+		)
+
+		w.deadLetter(statements, err)
+		return
+	}
+
+	w.exported.Add(uint64(len(statements)))
+	w.mu.Lock()
+	w.failing = false
+	w.mu.Unlock()
+}
+
+// exportTraced exports the statements within the span of the exporter call.
+func (w *worker) exportTraced(ctx context.Context, statements []sql.Statement) error {
+	var (
+		traces []string
+		links  []trace.Link
+	)
+	for _, statement := range statements {
+		if statement.File.Trace == "" || slices.Contains(traces, statement.File.Trace) {
+			continue
+		}
+
+		traces = append(traces, statement.File.Trace)
+		links = append(links, trace.Link{SpanContext: tracing.SpanContext(statement.File.Trace)})
+	}
+
+	ctx, span := w.tracer.Start(tracing.ContextWithTraceParent(ctx, statements[0].File.Trace), "export",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.String("exporter.name", w.name),
+			attribute.Int("sql.statements", len(statements)),
+		),
+	)
+	defer span.End()
+
+	err := w.contextExporter.ExportBatchContext(ctx, statements)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
+
+func (w *worker) deadLetter(statements []sql.Statement, exportErr error) {
+	if w.dlq == nil {
+		return
+	}
+
+	err := w.dlq.Write(w.name, statements, exportErr)
+	if err != nil {
+		w.logger.Error("failed writing dead letters",
+			"error", err,
+			"statements", len(statements),
+		)
+
+		return
+	}
+
+	w.deadLetters.Add(uint64(len(statements)))
+}
+
+func (w *worker) drop(statements []sql.Statement) {
+	w.dropped.Add(uint64(len(statements)))
+	w.metrics.dropped(w.name, len(statements))
+	w.logger.Warn("exporter queue is full, dropping statements",
+		"statements", len(statements),
+		"policy", w.config.Overflow,
+	)
+}
+
+// unspill exports a single spilled batch.
+// It reports whether the spill got shorter, either by the exported batch or by a corrupt one moved aside.
+//
+// The batch is removed from the spill only once exported, so batches spilled
+// when the process crashes are exported by the next run.
+func (w *worker) unspill(ctx context.Context) bool {
+	if w.spill == nil {
+		return false
+	}
+
+	name, statements, ok, err := w.spill.peek()
+	if err != nil {
+		w.spillErrors.Add(1)
+		w.logger.Error("failed reading spilled statements", "error", err)
+		return errors.Is(err, errCorruptSpill)
+	}
+
+	if !ok {
+		return false
+	}
+
+	w.export(ctx, statements)
+
+	err = w.spill.remove(name)
+	if err != nil {
+		w.spillErrors.Add(1)
+		w.logger.Error("failed removing exported spilled statements", "error", err)
+	}
+
+	return true
+}
+
+func (w *worker) unspillAll(ctx context.Context) {
+	for w.unspill(ctx) {
+	}
+}
+
+func (w *worker) wake() {
+	select {
+	case w.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+func (w *worker) close() {
+	close(w.queue)
+}
+
+func (w *worker) stats() ExporterStats {
+	w.mu.Lock()
+	lastErr, lastErrorTime, failing := w.lastErr, w.lastErrorTime, w.failing
+	w.mu.Unlock()
+
+	circuitBreaker, ok := w.exporter.(CircuitBreaker)
+	return ExporterStats{
+		Name:            w.name,
+		QueueDepth:      len(w.queue),
+		QueueCapacity:   cap(w.queue),
+		SpilledBatches:  w.spill.len(),
+		ExportedCount:   w.exported.Load(),
+		FailedCount:     w.failed.Load(),
+		DroppedCount:    w.dropped.Load(),
+		DeadLetterCount: w.deadLetters.Load(),
+		OverflowPolicy:  w.config.Overflow,
+		SpillDirectory:  w.config.SpillDirectory,
+		SpillErrorCount: w.spillErrors.Load(),
+		CircuitOpen:     ok && circuitBreaker.CircuitOpen(),
+		LastError:       lastErr,
+		LastErrorTime:   lastErrorTime,
+		Failing:         failing,
+	}
+}
+
+// spill stores batches in a directory, one file per batch.
+// Batches are popped in the order they were pushed, including the ones left by previous runs.
+type spill struct {
+	mu        sync.Mutex
+	directory string
+	sequence  uint64
+	files     []string
+}
+
+func openSpill(directory string) (s *spill, err error) {
+	err = os.MkdirAll(directory, spillDirectoryPermissions)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	s = &spill{directory: directory}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), spillFileSuffix) {
+			continue
+		}
+
+		s.files = append(s.files, entry.Name())
+
+		var sequence uint64
+		_, err := fmt.Sscanf(entry.Name(), "%d", &sequence)
+		if err == nil {
+			s.sequence = max(s.sequence, sequence)
+		}
+	}
+
+	slices.Sort(s.files)
+	return s, nil
+}
+
+// spilledStatement is the encoding of a spilled statement.
+// It carries the trace of the file, which is not part of the statement encoding.
+type spilledStatement struct {
+	sql.Statement
+
+	Trace string `json:"trace,omitempty"`
+}
+
+func (s *spill) push(statements []sql.Statement) error {
+	spilled := make([]spilledStatement, 0, len(statements))
+	for _, statement := range statements {
+		spilled = append(spilled, spilledStatement{Statement: statement, Trace: statement.File.Trace})
+	}
+
+	bytes, err := json.Marshal(spilled)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	name := fmt.Sprintf("%020d%s", s.sequence, spillFileSuffix)
+	err = os.WriteFile(filepath.Join(s.directory, name), bytes, spillFilePermissions)
+	if err != nil {
+		return err
+	}
+
+	s.files = append(s.files, name)
+	return nil
+}
+
+// peek reads the oldest batch and returns it along with the name of its file.
+// The batch stays in the spill until removed by [spill.remove].
+// A corrupt batch is moved aside and an error wrapping errCorruptSpill is returned.
+func (s *spill) peek() (name string, statements []sql.Statement, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return "", nil, false, nil
+	}
+
+	name = s.files[0]
+	path := filepath.Join(s.directory, name)
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	var spilled []spilledStatement
+	err = json.Unmarshal(bytes, &spilled)
+	if err != nil {
+		// The batch is kept for inspection but never read again.
+		renameErr := os.Rename(path, path+corruptSpillSuffix)
+		if renameErr != nil {
+			return "", nil, false, errors.Join(fmt.Errorf("failed decoding %s: %w", path, err), renameErr)
+		}
+
+		s.files = s.files[1:]
+		return "", nil, false, fmt.Errorf("%w: %s: %w", errCorruptSpill, path, err)
+	}
+
+	statements = make([]sql.Statement, 0, len(spilled))
+	for _, statement := range spilled {
+		statement.File.Trace = statement.Trace
+		statements = append(statements, statement.Statement)
+	}
+
+	return name, statements, true, nil
+}
+
+// remove removes the exported batch of the given name. The batch is removed from the spill
+// even when its file fails to be removed, so it is exported again only by the next run.
+func (s *spill) remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files = slices.DeleteFunc(s.files, func(file string) bool {
+		return file == name
+	})
+
+	return os.Remove(filepath.Join(s.directory, name))
+}
+
+func (s *spill) len() int {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.files)
+}
@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownExporterType = errors.New("unknown exporter type")
+	ErrInvalidSpec         = errors.New("invalid exporter spec")
+)
+
+// Factory creates an [Exporter] from its typed configuration.
+type Factory[C any] func(config C) (e Exporter, err error)
+
+// Registry maps exporter type names to factories creating them from [Params].
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]func(params Params) (Exporter, error)
+}
+
+// NewRegistry creates a new empty [Registry].
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]func(params Params) (Exporter, error)),
+	}
+}
+
+// Register registers the factory under the exporter type name.
+// Parameters are decoded into the configuration type C by [DecodeParams] before calling the factory.
+// It panics when the name is already registered.
+func Register[C any](r *Registry, name string, factory Factory[C]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.factories[name]; ok {
+		panic("exporter type " + name + " registered twice")
+	}
+
+	r.factories[name] = func(params Params) (Exporter, error) {
+		var config C
+		err := DecodeParams(params, &config)
+		if err != nil {
+			return nil, err
+		}
+
+		return factory(config)
+	}
+}
+
+// Names returns the sorted names of the registered exporter types.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.factories))
+}
+
+// Create creates an exporter of the named type from the parameters.
+func (r *Registry) Create(name string, params Params) (Exporter, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s, expected one of %s",
+			ErrUnknownExporterType, name, strings.Join(r.Names(), ", "))
+	}
+
+	e, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating %s exporter: %w", name, err)
+	}
+
+	return e, nil
+}
+
+// CreateFromSpec creates an exporter from a spec parsed by [ParseSpec].
+func (r *Registry) CreateFromSpec(spec string) (Exporter, error) {
+	name, params, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Create(name, params)
+}
+
+// ParseSpec parses an exporter spec in the "TYPE[:KEY=VALUE[,KEY=VALUE...]]" form,
+// such as "jsonl:path=/var/log/sql.jsonl". Keys may repeat to set list values.
+// Commas within values are escaped as "\,", such as "plugin:command=./sink,args=--tables=users\,orders".
+func ParseSpec(spec string) (name string, params Params, err error) {
+	name, rest, _ := strings.Cut(spec, ":")
+	if name == "" {
+		return "", nil, fmt.Errorf("%w: %q: missing exporter type", ErrInvalidSpec, spec)
+	}
+
+	params = make(Params)
+	if rest == "" {
+		return name, params, nil
+	}
+
+	for _, pair := range splitPairs(rest) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("%w: %q: expected KEY=VALUE, got %q", ErrInvalidSpec, spec, pair)
+		}
+
+		params[key] = append(params[key], value)
+	}
+
+	return name, params, nil
+}
+
+// splitPairs splits the pairs of a spec at the commas which are not escaped as "\,".
+func splitPairs(s string) []string {
+	var (
+		pairs []string
+		pair  strings.Builder
+	)
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			pair.WriteByte(',')
+			i++
+		case s[i] == ',':
+			pairs = append(pairs, pair.String())
+			pair.Reset()
+		default:
+			pair.WriteByte(s[i])
+		}
+	}
+
+	return append(pairs, pair.String())
+}
@@ -0,0 +1,206 @@
+package exporter_test
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+)
+
+type testConfig struct {
+	Path          string
+	BatchSize     int
+	AckTimeout    time.Duration
+	Origins       []string
+	Level         slog.Level
+	Ratio         float64
+	Enabled       bool
+	Renamed       string `param:"name"`
+	Skipped       string `param:"-"`
+	Unsupported   map[string]string
+	DSNTimeout    uint8
+	MaxAttempts32 int32
+}
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		spec   string
+		name   string
+		params exporter.Params
+		err    error
+	}{
+		{spec: "stdout", name: "stdout", params: exporter.Params{}},
+		{spec: "stdout:", name: "stdout", params: exporter.Params{}},
+		{
+			spec:   "jsonl:path=/var/log/sql.jsonl,sync=true",
+			name:   "jsonl",
+			params: exporter.Params{"path": {"/var/log/sql.jsonl"}, "sync": {"true"}},
+		},
+		{
+			spec:   "plugin:command=./sink,args=-v,args=--dry-run=true",
+			name:   "plugin",
+			params: exporter.Params{"command": {"./sink"}, "args": {"-v", "--dry-run=true"}},
+		},
+		{
+			spec:   `stdout:template={{.Content}}\, {{.LineNum}},format=text`,
+			name:   "stdout",
+			params: exporter.Params{"template": {"{{.Content}}, {{.LineNum}}"}, "format": {"text"}},
+		},
+		{
+			spec:   `plugin:command=C:\sink.exe,args=\,\,`,
+			name:   "plugin",
+			params: exporter.Params{"command": {`C:\sink.exe`}, "args": {",,"}},
+		},
+		{spec: ":path=x", err: exporter.ErrInvalidSpec},
+		{spec: "jsonl:path", err: exporter.ErrInvalidSpec},
+		{spec: "jsonl:=x", err: exporter.ErrInvalidSpec},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			t.Parallel()
+
+			name, params, err := exporter.ParseSpec(test.spec)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected = %v, got = %v", test.err, err)
+			}
+
+			if name != test.name || !reflect.DeepEqual(params, test.params) {
+				t.Fatalf("expected = %s %v, got = %s %v", test.name, test.params, name, params)
+			}
+		})
+	}
+}
+
+func TestDecodeParams(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Valid", func(t *testing.T) {
+		t.Parallel()
+
+		params := exporter.Params{
+			"path":           {"/tmp/x"},
+			"batch-size":     {"10"},
+			"ack-timeout":    {"1m30s"},
+			"origins":        {"a.com", "b.com"},
+			"level":          {"warn"},
+			"ratio":          {"0.5"},
+			"enabled":        {"true"},
+			"name":           {"renamed"},
+			"dsn-timeout":    {"7"},
+			"max-attempts32": {"3"},
+		}
+		expected := testConfig{
+			Path:          "/tmp/x",
+			BatchSize:     10,
+			AckTimeout:    90 * time.Second,
+			Origins:       []string{"a.com", "b.com"},
+			Level:         slog.LevelWarn,
+			Ratio:         0.5,
+			Enabled:       true,
+			Renamed:       "renamed",
+			DSNTimeout:    7,
+			MaxAttempts32: 3,
+		}
+
+		var config testConfig
+		err := exporter.DecodeParams(params, &config)
+		if err != nil {
+			t.Fatalf("failed decoding params: %v", err)
+		}
+
+		if !reflect.DeepEqual(config, expected) {
+			t.Fatalf("expected = %+v, got = %+v", expected, config)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		params exporter.Params
+		err    error
+	}{
+		{name: "Unknown", params: exporter.Params{"colour": {"red"}}, err: exporter.ErrUnknownParam},
+		{name: "Skipped", params: exporter.Params{"skipped": {"x"}}, err: exporter.ErrUnknownParam},
+		{name: "Unsupported", params: exporter.Params{"unsupported": {"x"}}, err: exporter.ErrUnknownParam},
+		{name: "InvalidInt", params: exporter.Params{"batch-size": {"ten"}}, err: exporter.ErrInvalidParam},
+		{name: "Overflow", params: exporter.Params{"dsn-timeout": {"300"}}, err: exporter.ErrInvalidParam},
+		{name: "InvalidDuration", params: exporter.Params{"ack-timeout": {"soon"}}, err: exporter.ErrInvalidParam},
+		{name: "InvalidLevel", params: exporter.Params{"level": {"loud"}}, err: exporter.ErrInvalidParam},
+		{name: "Repeated", params: exporter.Params{"path": {"a", "b"}}, err: exporter.ErrInvalidParam},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var config testConfig
+			err := exporter.DecodeParams(test.params, &config)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected = %v, got = %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Fail bool
+	}
+
+	errFactory := errors.New("factory failed")
+	registry := exporter.NewRegistry()
+	exporter.Register(registry, "test", func(config config) (exporter.Exporter, error) {
+		if config.Fail {
+			return nil, errFactory
+		}
+
+		return testexporter.New(), nil
+	})
+	exporter.Register(registry, "another", func(struct{}) (exporter.Exporter, error) {
+		return testexporter.New(), nil
+	})
+
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"another", "test"}) {
+		t.Fatalf("expected = %v, got = %v", []string{"another", "test"}, names)
+	}
+
+	e, err := registry.CreateFromSpec("test")
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	if _, ok := e.(*testexporter.Exporter); !ok {
+		t.Fatalf("expected = %T, got = %T", &testexporter.Exporter{}, e)
+	}
+
+	_, err = registry.CreateFromSpec("test:fail=true")
+	if !errors.Is(err, errFactory) {
+		t.Fatalf("expected = %v, got = %v", errFactory, err)
+	}
+
+	_, err = registry.CreateFromSpec("test:fail=maybe")
+	if !errors.Is(err, exporter.ErrInvalidParam) {
+		t.Fatalf("expected = %v, got = %v", exporter.ErrInvalidParam, err)
+	}
+
+	_, err = registry.CreateFromSpec("missing")
+	if !errors.Is(err, exporter.ErrUnknownExporterType) {
+		t.Fatalf("expected = %v, got = %v", exporter.ErrUnknownExporterType, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering a duplicate name to panic")
+		}
+	}()
+
+	exporter.Register(registry, "test", func(struct{}) (exporter.Exporter, error) {
+		return testexporter.New(), nil
+	})
+}
@@ -0,0 +1,384 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	defaultMaxAttempts      = 5
+	defaultInitialBackoff   = 100 * time.Millisecond
+	defaultMaxBackoff       = 10 * time.Second
+	defaultMultiplier       = 2
+	defaultJitter           = 0.2
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+var (
+	_ exporter.Exporter        = &Exporter{}
+	_ exporter.ContextExporter = &Exporter{}
+	_ exporter.Starter         = &Exporter{}
+	_ exporter.Flusher         = &Exporter{}
+	_ exporter.Closer          = &Exporter{}
+	_ exporter.Namer           = &Exporter{}
+	_ exporter.CircuitBreaker  = &Exporter{}
+)
+
+// State represents the circuit breaker state.
+type State string
+
+const (
+	// StateClosed lets all exports through.
+	StateClosed State = "closed"
+	// StateOpen rejects all exports with [ErrCircuitOpen].
+	StateOpen State = "open"
+	// StateHalfOpen lets a single probing export through.
+	StateHalfOpen State = "half-open"
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// MaxAttempts is the maximum number of attempts of a single export, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between retries.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after each retry.
+	Multiplier float64
+	// Jitter randomizes the backoff by the given fraction in both directions.
+	Jitter float64
+	// FailureThreshold is the number of consecutive failed exports that opens the circuit.
+	FailureThreshold int
+	// OpenTimeout is the time the circuit stays open before it half-opens to probe recovery.
+	OpenTimeout time.Duration
+	// Retryable reports whether the error is transient. Defaults to all errors except [Permanent] ones.
+	Retryable func(err error) bool
+}
+
+// DefaultConfig returns the default [Config].
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:      defaultMaxAttempts,
+		InitialBackoff:   defaultInitialBackoff,
+		MaxBackoff:       defaultMaxBackoff,
+		Multiplier:       defaultMultiplier,
+		Jitter:           defaultJitter,
+		FailureThreshold: defaultFailureThreshold,
+		OpenTimeout:      defaultOpenTimeout,
+		Retryable: func(err error) bool {
+			return !IsPermanent(err)
+		},
+	}
+}
+
+// Exporter implements [exporter.Exporter] and decorates another exporter
+// with retries and a circuit breaker.
+//
+// Failed exports are retried with jittered exponential backoff. After
+// [Config.FailureThreshold] consecutive failed exports the circuit opens and
+// exports fail fast until [Config.OpenTimeout] passes. Then a single export
+// probes the wrapped exporter and closes the circuit on success.
+//
+// Only retryable failures count towards opening the circuit. Exports failing
+// with errors which are not retryable, or interrupted by their context, say
+// nothing about the health of the wrapped exporter and leave the circuit as it is.
+//
+// Lifecycle methods and the name are forwarded to the wrapped exporter.
+type Exporter struct {
+	exporter        exporter.Exporter
+	contextExporter exporter.ContextExporter
+	config          Config
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewExporter creates a new [Exporter] wrapping the given exporter.
+// Zero [Config] fields, apart from Jitter, fall back to [DefaultConfig] values.
+func NewExporter(e exporter.Exporter, config Config) *Exporter {
+	defaults := DefaultConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+
+	if config.Multiplier < 1 {
+		config.Multiplier = defaults.Multiplier
+	}
+
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = defaults.OpenTimeout
+	}
+
+	if config.Retryable == nil {
+		config.Retryable = defaults.Retryable
+	}
+
+	return &Exporter{
+		exporter:        e,
+		contextExporter: exporter.Adapt(e),
+		config:          config,
+		state:           StateClosed,
+	}
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportContext(context.Background(), statement)
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	return e.ExportBatchContext(context.Background(), statements)
+}
+
+// ExportContext implements exporter.ContextExporter.
+func (e *Exporter) ExportContext(ctx context.Context, statement sql.Statement) (err error) {
+	return e.do(ctx, func() error {
+		return e.contextExporter.ExportContext(ctx, statement)
+	})
+}
+
+// ExportBatchContext implements exporter.ContextExporter.
+func (e *Exporter) ExportBatchContext(ctx context.Context, statements []sql.Statement) (err error) {
+	return e.do(ctx, func() error {
+		return e.contextExporter.ExportBatchContext(ctx, statements)
+	})
+}
+
+// Start implements exporter.Starter.
+func (e *Exporter) Start(ctx context.Context) (err error) {
+	starter, ok := e.exporter.(exporter.Starter)
+	if !ok {
+		return nil
+	}
+
+	return starter.Start(ctx)
+}
+
+// Flush implements exporter.Flusher.
+func (e *Exporter) Flush(ctx context.Context) (err error) {
+	flusher, ok := e.exporter.(exporter.Flusher)
+	if !ok {
+		return nil
+	}
+
+	return flusher.Flush(ctx)
+}
+
+// Close implements exporter.Closer.
+func (e *Exporter) Close() (err error) {
+	closer, ok := e.exporter.(exporter.Closer)
+	if !ok {
+		return nil
+	}
+
+	return closer.Close()
+}
+
+// Name implements exporter.Namer.
+func (e *Exporter) Name() string {
+	return exporter.Name(e.exporter)
+}
+
+// CircuitOpen implements exporter.CircuitBreaker.
+// A half-open circuit is not open as it lets the probing export through.
+func (e *Exporter) CircuitOpen() bool {
+	return e.State() == StateOpen
+}
+
+// State returns the current circuit breaker [State].
+func (e *Exporter) State() State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.state == StateOpen && time.Since(e.openedAt) >= e.config.OpenTimeout {
+		return StateHalfOpen
+	}
+
+	return e.state
+}
+
+func (e *Exporter) do(ctx context.Context, export func() error) error {
+	probe, err := e.allow()
+	if err != nil {
+		return err
+	}
+
+	attempt := 1
+	for ; ; attempt++ {
+		err = export()
+		if err == nil {
+			e.succeed()
+			return nil
+		}
+
+		if !e.config.Retryable(err) {
+			e.release()
+			return &Error{attempts: attempt, err: err}
+		}
+
+		// A probe gets a single attempt so the circuit reopens quickly.
+		if probe || attempt >= e.config.MaxAttempts {
+			break
+		}
+
+		timer := time.NewTimer(e.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			e.release()
+			return &Error{attempts: attempt, err: errors.Join(err, ctx.Err())}
+		}
+	}
+
+	if ctx.Err() != nil {
+		e.release()
+		return &Error{attempts: attempt, err: err}
+	}
+
+	e.fail()
+	return &Error{attempts: attempt, err: err}
+}
+
+// allow reports whether the export may proceed and whether it is a probe.
+func (e *Exporter) allow() (probe bool, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch e.state {
+	case StateOpen:
+		if time.Since(e.openedAt) < e.config.OpenTimeout {
+			return false, ErrCircuitOpen
+		}
+
+		e.state = StateHalfOpen
+		e.probing = true
+		return true, nil
+	case StateHalfOpen:
+		if e.probing {
+			return false, ErrCircuitOpen
+		}
+
+		e.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (e *Exporter) succeed() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.state = StateClosed
+	e.failures = 0
+	e.probing = false
+}
+
+// release ends the probe, if any, without changing the circuit state.
+func (e *Exporter) release() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.probing = false
+}
+
+func (e *Exporter) fail() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.failures++
+	e.probing = false
+	if e.state == StateHalfOpen || e.failures >= e.config.FailureThreshold {
+		e.state = StateOpen
+		e.openedAt = time.Now()
+	}
+}
+
+// backoff returns the jittered wait time after the given attempt.
+func (e *Exporter) backoff(attempt int) time.Duration {
+	backoff := float64(e.config.InitialBackoff)
+	for range attempt - 1 {
+		backoff *= e.config.Multiplier
+	}
+
+	backoff = min(backoff, float64(e.config.MaxBackoff))
+	if e.config.Jitter > 0 {
+		backoff *= 1 + e.config.Jitter*(2*rand.Float64()-1) //nolint: gosec
+	}
+
+	return time.Duration(backoff)
+}
+
+// Error is returned when all attempts of an export fail.
+type Error struct {
+	attempts int
+	err      error
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("export failed after %d attempts: %v", e.attempts, e.err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Attempts returns the number of attempts made.
+func (e *Error) Attempts() int {
+	return e.attempts
+}
+
+type permanentError struct {
+	err error
+}
+
+// Permanent marks the error as permanent so the [Exporter] does not retry it.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error was marked using [Permanent].
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Error implements error.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
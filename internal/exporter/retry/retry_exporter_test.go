@@ -0,0 +1,250 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter/retry"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+)
+
+var errTransient = errors.New("transient failure")
+
+func TestExporter(t *testing.T) { //nolint: gocognit, cyclop
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "test.sql", Type: sql.PostgresType},
+		Content: "SELECT * FROM users",
+		LineNum: 1,
+	}
+	config := retry.Config{
+		MaxAttempts:      3,
+		InitialBackoff:   time.Second,
+		MaxBackoff:       time.Minute,
+		Multiplier:       2,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+	}
+
+	t.Run("RetriesTransientFailures", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			flaky := newFlakyExporter(2, errTransient)
+			e := retry.NewExporter(flaky, config)
+
+			start := time.Now()
+			err := e.Export(statement)
+			if err != nil {
+				t.Fatalf("failed exporting statement: %v", err)
+			}
+
+			// Backoffs of 1s and 2s without jitter.
+			elapsed := time.Since(start)
+			if elapsed != 3*time.Second {
+				t.Fatalf("unexpected backoff: expected = %v, got = %v", 3*time.Second, elapsed)
+			}
+
+			if flaky.attempts() != 3 {
+				t.Fatalf("attempt count does not match: expected = %v, got = %v", 3, flaky.attempts())
+			}
+		})
+	})
+
+	t.Run("GivesUpAfterMaxAttempts", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			flaky := newFlakyExporter(10, errTransient)
+			e := retry.NewExporter(flaky, config)
+
+			err := e.Export(statement)
+			if !errors.Is(err, errTransient) {
+				t.Fatalf("expected transient error: got = %v", err)
+			}
+
+			var retryErr *retry.Error
+			if !errors.As(err, &retryErr) || retryErr.Attempts() != config.MaxAttempts {
+				t.Fatalf("expected error with %d attempts: got = %v", config.MaxAttempts, err)
+			}
+		})
+	})
+
+	t.Run("CancelsBackoff", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			flaky := newFlakyExporter(10, errTransient)
+			e := retry.NewExporter(flaky, config)
+
+			ctx, cancel := context.WithTimeout(t.Context(), config.InitialBackoff/2)
+			defer cancel()
+
+			err := e.ExportContext(ctx, statement)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected deadline exceeded error: got = %v", err)
+			}
+
+			if flaky.attempts() != 1 {
+				t.Fatalf("attempt count does not match: expected = %v, got = %v", 1, flaky.attempts())
+			}
+		})
+	})
+
+	t.Run("DoesNotRetryPermanentFailures", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			flaky := newFlakyExporter(10, retry.Permanent(errTransient))
+			e := retry.NewExporter(flaky, config)
+
+			err := e.Export(statement)
+			if !retry.IsPermanent(err) {
+				t.Fatalf("expected permanent error: got = %v", err)
+			}
+
+			if flaky.attempts() != 1 {
+				t.Fatalf("attempt count does not match: expected = %v, got = %v", 1, flaky.attempts())
+			}
+		})
+	})
+
+	t.Run("CountsOnlyRetryableFailures", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			permanent := newFlakyExporter(10, retry.Permanent(errTransient))
+			e := retry.NewExporter(permanent, config)
+			for range config.FailureThreshold + 1 {
+				_ = e.Export(statement)
+			}
+
+			if e.State() != retry.StateClosed {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateClosed, e.State())
+			}
+
+			flaky := newFlakyExporter(10, errTransient)
+			e = retry.NewExporter(flaky, config)
+			for range config.FailureThreshold + 1 {
+				ctx, cancel := context.WithTimeout(t.Context(), config.InitialBackoff/2)
+				err := e.ExportContext(ctx, statement)
+				cancel()
+				if !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("expected deadline exceeded error: got = %v", err)
+				}
+			}
+
+			if e.State() != retry.StateClosed {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateClosed, e.State())
+			}
+		})
+	})
+
+	t.Run("CircuitBreaker", func(t *testing.T) {
+		t.Parallel()
+
+		synctest.Test(t, func(t *testing.T) {
+			flaky := newFlakyExporter(2*config.MaxAttempts+1, errTransient)
+			e := retry.NewExporter(flaky, config)
+
+			for range config.FailureThreshold {
+				err := e.Export(statement)
+				if !errors.Is(err, errTransient) {
+					t.Fatalf("expected transient error: got = %v", err)
+				}
+			}
+
+			if e.State() != retry.StateOpen || !e.CircuitOpen() {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateOpen, e.State())
+			}
+
+			err := e.Export(statement)
+			if !errors.Is(err, retry.ErrCircuitOpen) {
+				t.Fatalf("expected circuit open error: got = %v", err)
+			}
+
+			time.Sleep(config.OpenTimeout)
+			if e.State() != retry.StateHalfOpen || e.CircuitOpen() {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateHalfOpen, e.State())
+			}
+
+			// The failing probe reopens the circuit without retrying.
+			attempts := flaky.attempts()
+			err = e.Export(statement)
+			if !errors.Is(err, errTransient) {
+				t.Fatalf("expected transient error: got = %v", err)
+			}
+
+			if flaky.attempts() != attempts+1 {
+				t.Fatalf("probe attempt count does not match: expected = %v, got = %v", attempts+1, flaky.attempts())
+			}
+
+			if e.State() != retry.StateOpen {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateOpen, e.State())
+			}
+
+			// The successful probe closes the circuit.
+			time.Sleep(config.OpenTimeout)
+			err = e.ExportBatch([]sql.Statement{statement})
+			if err != nil {
+				t.Fatalf("failed exporting batch: %v", err)
+			}
+
+			if e.State() != retry.StateClosed {
+				t.Fatalf("circuit state does not match: expected = %v, got = %v", retry.StateClosed, e.State())
+			}
+
+			if len(flaky.Statements()) != 1 {
+				t.Fatalf("statement count does not match: expected = %v, got = %v", 1, len(flaky.Statements()))
+			}
+		})
+	})
+}
+
+// flakyExporter fails the given number of exports before it starts exporting.
+type flakyExporter struct {
+	*testexporter.Exporter
+
+	mu       sync.Mutex
+	failures int
+	calls    int
+	err      error
+}
+
+func newFlakyExporter(failures int, err error) *flakyExporter {
+	return &flakyExporter{
+		Exporter: testexporter.New(),
+		failures: failures,
+		err:      err,
+	}
+}
+
+func (e *flakyExporter) Export(statement sql.Statement) error {
+	return e.ExportBatch([]sql.Statement{statement})
+}
+
+func (e *flakyExporter) ExportBatch(statements []sql.Statement) error {
+	e.mu.Lock()
+	e.calls++
+	failing := e.calls <= e.failures
+	e.mu.Unlock()
+
+	if failing {
+		return e.err
+	}
+
+	return e.Exporter.ExportBatch(statements)
+}
+
+func (e *flakyExporter) attempts() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.calls
+}
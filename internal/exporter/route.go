@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+// Route decides whether the [sql.Statement] is passed to an exporter.
+type Route func(statement sql.Statement) bool
+
+// ByType routes statements of the given [sql.Type]s.
+func ByType(types ...sql.Type) Route {
+	return func(statement sql.Statement) bool {
+		return slices.Contains(types, statement.File.Type)
+	}
+}
+
+// ByDirectory routes statements of files in the given directories or their subdirectories.
+func ByDirectory(directories ...string) Route {
+	cleaned := make([]string, 0, len(directories))
+	for _, directory := range directories {
+		cleaned = append(cleaned, filepath.Clean(directory))
+	}
+
+	return func(statement sql.Statement) bool {
+		directory := filepath.Dir(statement.File.Path)
+		for _, d := range cleaned {
+			if directory == d || strings.HasPrefix(directory, d+string(filepath.Separator)) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// ByGlob routes statements of files matching the glob pattern.
+// Patterns containing a path separator are matched against the whole path, others against the file name.
+func ByGlob(pattern string) (Route, error) {
+	_, err := filepath.Match(pattern, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob pattern %s: %w", pattern, err)
+	}
+
+	matchPath := strings.ContainsRune(pattern, filepath.Separator)
+	return func(statement sql.Statement) bool {
+		name := statement.File.Path
+		if !matchPath {
+			name = filepath.Base(name)
+		}
+
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// ByKind routes statements of the given [sql.Kind]s.
+func ByKind(kinds ...sql.Kind) Route {
+	return func(statement sql.Statement) bool {
+		return slices.Contains(kinds, statement.Kind())
+	}
+}
+
+// ByContent routes statements whose content matches the regular expression.
+func ByContent(pattern *regexp.Regexp) Route {
+	return func(statement sql.Statement) bool {
+		return pattern.MatchString(statement.Content)
+	}
+}
+
+// All routes statements matching all the given routes.
+func All(routes ...Route) Route {
+	return func(statement sql.Statement) bool {
+		for _, route := range routes {
+			if !route(statement) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// Any routes statements matching any of the given routes.
+func Any(routes ...Route) Route {
+	return func(statement sql.Statement) bool {
+		for _, route := range routes {
+			if route(statement) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Not routes statements not matching the given route.
+func Not(route Route) Route {
+	return func(statement sql.Statement) bool {
+		return !route(statement)
+	}
+}
+
+// filter returns statements passing the route.
+func (r Route) filter(statements []sql.Statement) []sql.Statement {
+	if r == nil {
+		return statements
+	}
+
+	var routed []sql.Statement
+	for _, statement := range statements {
+		if r(statement) {
+			routed = append(routed, statement)
+		}
+	}
+
+	return routed
+}
@@ -0,0 +1,140 @@
+package exporter_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/synctest"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/test/testexporter"
+	"github.com/course-go/sql-processor/internal/test/testlogger"
+)
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "/var/sql/postgres/migrations/001-users.sql", Type: sql.PostgresType},
+		Content: "CREATE TABLE users (id INT)",
+		LineNum: 1,
+	}
+
+	glob, err := exporter.ByGlob("001-*.sql")
+	if err != nil {
+		t.Fatalf("failed creating glob route: %v", err)
+	}
+
+	pathGlob, err := exporter.ByGlob("/var/sql/*/migrations/*.sql")
+	if err != nil {
+		t.Fatalf("failed creating glob route: %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		route    exporter.Route
+		expected bool
+	}{
+		{name: "Type", route: exporter.ByType(sql.MySQL, sql.PostgresType), expected: true},
+		{name: "OtherType", route: exporter.ByType(sql.MySQL), expected: false},
+		{name: "Directory", route: exporter.ByDirectory("/var/sql/postgres/"), expected: true},
+		{name: "OtherDirectory", route: exporter.ByDirectory("/var/sql/post"), expected: false},
+		{name: "Glob", route: glob, expected: true},
+		{name: "PathGlob", route: pathGlob, expected: true},
+		{name: "Kind", route: exporter.ByKind(sql.DDLKind), expected: true},
+		{name: "OtherKind", route: exporter.ByKind(sql.DMLKind, sql.QueryKind), expected: false},
+		{name: "Content", route: exporter.ByContent(regexp.MustCompile(`(?i)create\s+table`)), expected: true},
+		{
+			name:     "All",
+			route:    exporter.All(exporter.ByType(sql.PostgresType), exporter.ByKind(sql.DMLKind)),
+			expected: false,
+		},
+		{
+			name:     "Any",
+			route:    exporter.Any(exporter.ByType(sql.MySQL), exporter.ByKind(sql.DDLKind)),
+			expected: true,
+		},
+		{name: "Not", route: exporter.Not(exporter.ByType(sql.MySQL)), expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tc.route(statement) != tc.expected {
+				t.Fatalf("route result does not match: expected = %v, got = %v", tc.expected, !tc.expected)
+			}
+		})
+	}
+
+	t.Run("InvalidGlob", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := exporter.ByGlob("[")
+		if err == nil {
+			t.Fatal("expected invalid glob error")
+		}
+	})
+}
+
+func TestManagerRouting(t *testing.T) {
+	t.Parallel()
+
+	postgresFile := sql.File{Path: "postgres/test.sql", Type: sql.PostgresType}
+	mysqlFile := sql.File{Path: "mysql/test.sql", Type: sql.MySQL}
+	statements := []sql.Statement{
+		{Content: "CREATE TABLE users (id INT)", LineNum: 1, File: postgresFile},
+		{Content: "SELECT * FROM users", LineNum: 2, File: postgresFile},
+		{Content: "SELECT * FROM users", LineNum: 1, File: mysqlFile},
+	}
+
+	synctest.Test(t, func(t *testing.T) {
+		review := testexporter.New()
+		archive := testexporter.New()
+		legacy := testexporter.New()
+
+		statementCh := make(chan sql.Statement, len(statements))
+		logger, _ := testlogger.NewTestErrorLogger()
+		m, err := exporter.NewManager(
+			logger,
+			statementCh,
+			[]exporter.Exporter{review, archive, legacy},
+			exporter.WithRoute(review, exporter.ByKind(sql.DDLKind)),
+			exporter.WithRoute(legacy, exporter.ByType(sql.MySQL)),
+		)
+		if err != nil {
+			t.Fatalf("failed creating manager: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		go m.Run(ctx)
+
+		for _, statement := range statements {
+			statementCh <- statement
+		}
+
+		synctest.Wait()
+
+		assertStatements(t, "review", review, statements[:1])
+		assertStatements(t, "archive", archive, statements)
+		assertStatements(t, "legacy", legacy, statements[2:])
+	})
+}
+
+func assertStatements(t *testing.T, name string, mock *testexporter.Exporter, expected []sql.Statement) {
+	t.Helper()
+
+	statements := mock.Statements()
+	if len(statements) != len(expected) {
+		t.Fatalf("%s statement count does not match: expected = %v, got = %v", name, len(expected), len(statements))
+	}
+
+	for i := range expected {
+		if statements[i] != expected[i] {
+			t.Fatalf("%s statement does not match: expected = %v, got = %v", name, expected[i], statements[i])
+		}
+	}
+}
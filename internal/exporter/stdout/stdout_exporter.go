@@ -1,33 +1,195 @@
 package stdout
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/template"
 
 	"github.com/course-go/sql-processor/internal/exporter"
 	"github.com/course-go/sql-processor/internal/sql"
 )
 
-var _ exporter.Exporter = &Exporter{}
+var ErrUnknownColorMode = errors.New("unknown color mode")
+
+var (
+	_ exporter.Exporter = &Exporter{}
+	_ exporter.Flusher  = &Exporter{}
+)
+
+// Option configures the [Exporter].
+type Option func(e *Exporter)
+
+// WithWriter sets the writer the [Exporter] writes to. Defaults to [os.Stdout].
+func WithWriter(w io.Writer) Option {
+	return func(e *Exporter) {
+		e.out = w
+	}
+}
+
+// WithTemplate sets the template executed for each exported [sql.Statement].
+// Use [ParseTemplate] or [PresetTemplate] to create it. Defaults to [PresetCompact].
+func WithTemplate(tmpl *template.Template) Option {
+	return func(e *Exporter) {
+		e.template = tmpl
+	}
+}
+
+// WithColors forces colored output on or off.
+// By default, colors are used when the writer is a terminal and NO_COLOR is not set.
+func WithColors(colored bool) Option {
+	return func(e *Exporter) {
+		e.colored = &colored
+	}
+}
 
 // Exporter implements [exporter.Exporter] and exports given [sql.Statement]s to stdout.
-type Exporter struct{}
+//
+// The output is buffered and written once per export call.
+// Concurrent exports are serialized.
+type Exporter struct {
+	mu       sync.Mutex
+	out      io.Writer
+	writer   *bufio.Writer
+	template *template.Template
+	colored  *bool
+}
+
+func NewExporter(opts ...Option) *Exporter {
+	compact, _ := PresetTemplate(PresetCompact)
+	e := &Exporter{
+		out:      os.Stdout,
+		template: compact,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.writer = bufio.NewWriter(e.out)
+
+	colored := isTerminal(e.out) && os.Getenv("NO_COLOR") == ""
+	if e.colored != nil {
+		colored = *e.colored
+	}
+
+	// Rebind the functions so they respect the color setting.
+	clone, err := e.template.Clone()
+	if err == nil {
+		e.template = clone.Funcs(templateFuncs(colored))
+	}
+
+	return e
+}
+
+// Config represents [Exporter] configuration used by [Register].
+type Config struct {
+	// Preset is the template preset. Defaults to [PresetCompact].
+	Preset Preset
+	// Template is a template parsed by [ParseTemplate]. It takes precedence over Preset.
+	Template string
+	// Colors is one of "auto", "always" or "never". Defaults to "auto".
+	Colors string
+}
+
+// Register registers the "stdout" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "stdout", func(config Config) (exporter.Exporter, error) {
+		opts, err := config.options()
+		if err != nil {
+			return nil, err
+		}
 
-func NewExporter() *Exporter {
-	return &Exporter{}
+		return NewExporter(opts...), nil
+	})
+}
+
+func (c Config) options() ([]Option, error) {
+	var opts []Option
+	switch c.Colors {
+	case "", "auto":
+	case "always", "never":
+		opts = append(opts, WithColors(c.Colors == "always"))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownColorMode, c.Colors)
+	}
+
+	tmpl, err := PresetTemplate(PresetCompact)
+	switch {
+	case c.Template != "":
+		tmpl, err = ParseTemplate(c.Template)
+	case c.Preset != "":
+		tmpl, err = PresetTemplate(c.Preset)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, WithTemplate(tmpl)), nil
 }
 
 // Export implements exporter.Exporter.
 func (e *Exporter) Export(statement sql.Statement) (err error) {
-	fmt.Printf("%s:%d [%s] [%s]\n", statement.File.Path, statement.LineNum, statement.File.Type, statement.Content)
-	return nil
+	return e.ExportBatch([]sql.Statement{statement})
 }
 
 // ExportBatch implements exporter.Exporter.
+//
+// Write errors, such as a broken pipe, are returned and the unwritten output is discarded.
 func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	var buf bytes.Buffer
 	for _, statement := range statements {
-		err = errors.Join(err, e.Export(statement))
+		err = e.template.Execute(&buf, statement)
+		if err != nil {
+			return fmt.Errorf("failed executing template for %s:%d: %w", statement.File.Path, statement.LineNum, err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, err = e.writer.Write(buf.Bytes())
+	if err != nil {
+		e.writer.Reset(e.out)
+		return fmt.Errorf("failed writing statements: %w", err)
+	}
+
+	return e.flush()
+}
+
+// Flush implements exporter.Flusher.
+func (e *Exporter) Flush(_ context.Context) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.flush()
+}
+
+func (e *Exporter) flush() error {
+	err := e.writer.Flush()
+	if err != nil {
+		e.writer.Reset(e.out)
+		return fmt.Errorf("failed writing statements: %w", err)
+	}
+
+	return nil
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
 	}
 
-	return err
+	return info.Mode()&os.ModeCharDevice != 0
 }
@@ -0,0 +1,132 @@
+package stdout_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/stdout"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const batchOutput = "test.sql:1 [mysql] [SELECT * FROM users]\ntest.sql:2 [mysql] [DELETE FROM users]\n"
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	statements := []sql.Statement{
+		{
+			File:    sql.File{Path: "test.sql", Type: sql.MySQL},
+			Content: "SELECT * FROM users",
+			LineNum: 1,
+		},
+		{
+			File:    sql.File{Path: "test.sql", Type: sql.MySQL},
+			Content: "DELETE FROM users",
+			LineNum: 2,
+		},
+	}
+
+	t.Run("Batch", func(t *testing.T) {
+		t.Parallel()
+
+		writer := &countingWriter{}
+		e := stdout.NewExporter(stdout.WithWriter(writer))
+
+		err := e.ExportBatch(statements)
+		if err != nil {
+			t.Fatalf("failed exporting batch: %v", err)
+		}
+
+		if writer.String() != batchOutput {
+			t.Fatalf("output does not match: expected = %q, got = %q", batchOutput, writer.String())
+		}
+
+		if writer.writes != 1 {
+			t.Fatalf("write count does not match: expected = %v, got = %v", 1, writer.writes)
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+
+		writer := &countingWriter{}
+		e := stdout.NewExporter(stdout.WithWriter(writer))
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				_ = e.ExportBatch(statements)
+			})
+		}
+
+		wg.Wait()
+
+		// Batches must not interleave.
+		expected := strings.Repeat(batchOutput, 10)
+		if writer.String() != expected {
+			t.Fatalf("output does not match: expected = %q, got = %q", expected, writer.String())
+		}
+	})
+
+	t.Run("WriteError", func(t *testing.T) {
+		t.Parallel()
+
+		writer := &countingWriter{err: syscall.EPIPE}
+		e := stdout.NewExporter(stdout.WithWriter(writer))
+
+		err := e.Export(statements[0])
+		if !errors.Is(err, syscall.EPIPE) {
+			t.Fatalf("expected broken pipe error: got = %v", err)
+		}
+
+		// The exporter recovers once the writer does.
+		writer.setError(nil)
+
+		err = e.Export(statements[1])
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		expected := "test.sql:2 [mysql] [DELETE FROM users]\n"
+		if writer.String() != expected {
+			t.Fatalf("output does not match: expected = %q, got = %q", expected, writer.String())
+		}
+	})
+}
+
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	err    error
+}
+
+func (w *countingWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *countingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.buf.String()
+}
+
+func (w *countingWriter) setError(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.err = err
+}
@@ -0,0 +1,183 @@
+package stdout
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+const templateName = "statement"
+
+var ErrUnknownPreset = errors.New("unknown template preset")
+
+// Preset represents a built-in output template.
+type Preset string
+
+const (
+	// PresetCompact prints a single line per statement.
+	PresetCompact Preset = "compact"
+	// PresetPretty prints the statement indented on multiple lines with syntax highlighting.
+	PresetPretty Preset = "pretty"
+	// PresetGitHub prints GitHub workflow annotations.
+	PresetGitHub Preset = "github"
+)
+
+var presets = map[Preset]string{
+	PresetCompact: "{{.File.Path}}:{{.LineNum}} [{{.File.Type}}] [{{.Content}}]\n",
+	PresetPretty: `{{color "cyan" .File.Path}}:{{color "yellow" (print .LineNum)}}` +
+		` {{color "magenta" (print .File.Type)}} {{color "gray" (print .Kind)}}` + "\n" +
+		"{{indent 4 (highlight .Content)}}\n\n",
+	PresetGitHub: "::notice file={{githubProperty .File.Path}},line={{.LineNum}}," +
+		"title={{githubProperty (print .File.Type)}} {{.Kind}} statement::{{githubData .Content}}\n",
+}
+
+var colors = map[string]string{
+	"bold":    "1",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"gray":    "90",
+}
+
+var keywords = map[string]struct{}{
+	"ADD": {}, "ALL": {}, "ALTER": {}, "AND": {}, "AS": {}, "ASC": {}, "BEGIN": {}, "BETWEEN": {}, "BY": {},
+	"CASE": {}, "COMMIT": {}, "CREATE": {}, "DEFAULT": {}, "DELETE": {}, "DESC": {}, "DISTINCT": {}, "DROP": {},
+	"ELSE": {}, "END": {}, "EXISTS": {}, "FROM": {}, "FULL": {}, "GRANT": {}, "GROUP": {}, "HAVING": {}, "IN": {},
+	"INDEX": {}, "INNER": {}, "INSERT": {}, "INTO": {}, "IS": {}, "JOIN": {}, "KEY": {}, "LEFT": {}, "LIKE": {},
+	"LIMIT": {}, "NOT": {}, "NULL": {}, "OFFSET": {}, "ON": {}, "OR": {}, "ORDER": {}, "OUTER": {}, "PRIMARY": {},
+	"REFERENCES": {}, "REVOKE": {}, "RIGHT": {}, "ROLLBACK": {}, "SELECT": {}, "SET": {}, "TABLE": {}, "THEN": {},
+	"TRUNCATE": {}, "UNION": {}, "UNIQUE": {}, "UPDATE": {}, "VALUES": {}, "VIEW": {}, "WHEN": {}, "WHERE": {},
+	"WITH": {},
+}
+
+// ParseTemplate parses the [text/template] the [Exporter] executes for each [sql.Statement].
+//
+// Apart from the standard functions, templates can use:
+//
+//	truncate N TEXT      shortens the text to N runes
+//	indent N TEXT        indents all lines of the text by N spaces
+//	oneline TEXT         collapses all whitespace of the text to single spaces
+//	color NAME TEXT      colors the text when the output supports colors
+//	highlight TEXT       highlights SQL keywords and strings when the output supports colors
+//	json VALUE           encodes the value as JSON
+//	githubData TEXT      escapes the text for a GitHub workflow command message
+//	githubProperty TEXT  escapes the text for a GitHub workflow command property
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New(templateName).Funcs(templateFuncs(false)).Parse(text)
+}
+
+// PresetTemplate returns the parsed template of the [Preset].
+func PresetTemplate(preset Preset) (*template.Template, error) {
+	text, ok := presets[preset]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPreset, preset)
+	}
+
+	return ParseTemplate(text)
+}
+
+func templateFuncs(colored bool) template.FuncMap {
+	color := func(name string, text string) string {
+		code, ok := colors[name]
+		if !colored || !ok {
+			return text
+		}
+
+		return "\x1b[" + code + "m" + text + "\x1b[0m"
+	}
+
+	return template.FuncMap{
+		"truncate": truncate,
+		"indent":   indent,
+		"oneline":  oneline,
+		"color":    color,
+		"highlight": func(text string) string {
+			return highlight(text, color)
+		},
+		"json":           jsonEncode,
+		"githubData":     githubData,
+		"githubProperty": githubProperty,
+	}
+}
+
+func truncate(n int, text string) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+
+	if n <= 1 {
+		return string(runes[:max(n, 0)])
+	}
+
+	return string(runes[:n-1]) + "…"
+}
+
+func indent(n int, text string) string {
+	prefix := strings.Repeat(" ", n)
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+func oneline(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func jsonEncode(value any) (string, error) {
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+func githubData(text string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(text)
+}
+
+func githubProperty(text string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(text)
+}
+
+// highlight colors SQL keywords and string literals.
+func highlight(text string, color func(name string, text string) string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+
+			end = min(end+1, len(runes))
+			builder.WriteString(color("green", string(runes[i:end])))
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+
+			word := string(runes[i:end])
+			if _, ok := keywords[strings.ToUpper(word)]; ok {
+				word = color("blue", word)
+			}
+
+			builder.WriteString(word)
+			i = end
+		default:
+			builder.WriteRune(r)
+			i++
+		}
+	}
+
+	return builder.String()
+}
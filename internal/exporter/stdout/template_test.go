@@ -0,0 +1,93 @@
+package stdout_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/stdout"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: "migrations/001,users.sql", Type: sql.PostgresType},
+		Content: "UPDATE users\nSET name = 'Jane'",
+		LineNum: 7,
+	}
+
+	testCases := []struct {
+		name     string
+		text     string
+		preset   stdout.Preset
+		expected string
+	}{
+		{
+			name:     "Compact",
+			preset:   stdout.PresetCompact,
+			expected: "migrations/001,users.sql:7 [postgres] [UPDATE users\nSET name = 'Jane']\n",
+		},
+		{
+			name:     "Pretty",
+			preset:   stdout.PresetPretty,
+			expected: "migrations/001,users.sql:7 postgres dml\n    UPDATE users\n    SET name = 'Jane'\n\n",
+		},
+		{
+			name:   "GitHub",
+			preset: stdout.PresetGitHub,
+			expected: "::notice file=migrations/001%2Cusers.sql,line=7,title=postgres dml statement::" +
+				"UPDATE users%0ASET name = 'Jane'\n",
+		},
+		{
+			name:     "Truncate",
+			text:     "{{truncate 8 (oneline .Content)}}",
+			expected: "UPDATE …",
+		},
+		{
+			name:     "JSON",
+			text:     "{{json .Content}}",
+			expected: `"UPDATE users\nSET name = 'Jane'"`,
+		},
+		{
+			name:     "Indent",
+			text:     "{{indent 2 .Content}}",
+			expected: "  UPDATE users\n  SET name = 'Jane'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl, err := stdout.ParseTemplate(tc.text)
+			if tc.preset != "" {
+				tmpl, err = stdout.PresetTemplate(tc.preset)
+			}
+
+			if err != nil {
+				t.Fatalf("failed parsing template: %v", err)
+			}
+
+			var builder strings.Builder
+			err = tmpl.Execute(&builder, statement)
+			if err != nil {
+				t.Fatalf("failed executing template: %v", err)
+			}
+
+			if builder.String() != tc.expected {
+				t.Fatalf("output does not match: expected = %q, got = %q", tc.expected, builder.String())
+			}
+		})
+	}
+
+	t.Run("UnknownPreset", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := stdout.PresetTemplate("unknown")
+		if !errors.Is(err, stdout.ErrUnknownPreset) {
+			t.Fatalf("expected unknown preset error: got = %v", err)
+		}
+	})
+}
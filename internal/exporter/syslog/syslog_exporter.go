@@ -0,0 +1,255 @@
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/course-go/sql-processor/internal/exporter"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+const (
+	defaultAddress = "/dev/log"
+	defaultAppName = "sql-processor"
+	dialTimeout    = 5 * time.Second
+	// structuredDataID uses the private enterprise number reserved for documentation by RFC 5612.
+	structuredDataID = "sql@32473"
+	msgID            = "statement"
+	version          = 1
+	nilValue         = "-"
+)
+
+var ErrUnknownNetwork = errors.New("unknown syslog network")
+
+var (
+	_ exporter.Exporter = &Exporter{}
+	_ exporter.Closer   = &Exporter{}
+)
+
+// Facility represents syslog facility.
+type Facility int
+
+const (
+	FacilityUser   Facility = 1
+	FacilityDaemon Facility = 3
+	FacilityLocal0 Facility = 16
+	FacilityLocal1 Facility = 17
+	FacilityLocal2 Facility = 18
+	FacilityLocal3 Facility = 19
+	FacilityLocal4 Facility = 20
+	FacilityLocal5 Facility = 21
+	FacilityLocal6 Facility = 22
+	FacilityLocal7 Facility = 23
+)
+
+// Severity represents syslog severity.
+type Severity int
+
+const (
+	SeverityEmergency Severity = iota
+	SeverityAlert
+	SeverityCritical
+	SeverityError
+	SeverityWarning
+	SeverityNotice
+	SeverityInfo
+	SeverityDebug
+)
+
+// Config represents [Exporter] configuration.
+type Config struct {
+	// Network is one of "unixgram", "unix", "udp" or "tcp". Defaults to "unixgram".
+	Network string
+	// Address is the socket path or host and port. Defaults to the local "/dev/log" socket.
+	Address string
+	// Facility defaults to [FacilityLocal0].
+	Facility Facility
+	// Severity defaults to [SeverityInfo].
+	Severity Severity
+	// Hostname defaults to the machine host name.
+	Hostname string
+	// AppName defaults to "sql-processor".
+	AppName string
+}
+
+// Exporter implements [exporter.Exporter] and exports given [sql.Statement]s to syslog.
+//
+// Messages follow RFC 5424 and carry the statement location in structured data.
+// Stream networks use the octet counting framing of RFC 6587, datagram networks
+// send one message per datagram. The connection is established lazily and
+// re-established after a failed write.
+type Exporter struct {
+	config Config
+	stream bool
+	procID string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewExporter creates a new [Exporter] from the given [Config].
+func NewExporter(config Config) (*Exporter, error) {
+	if config.Network == "" {
+		config.Network = "unixgram"
+	}
+
+	var stream bool
+	switch config.Network {
+	case "unixgram", "udp", "udp4", "udp6":
+	case "unix", "tcp", "tcp4", "tcp6":
+		stream = true
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, config.Network)
+	}
+
+	if config.Address == "" {
+		config.Address = defaultAddress
+	}
+
+	if config.Facility == 0 {
+		config.Facility = FacilityLocal0
+	}
+
+	if config.Severity == 0 {
+		config.Severity = SeverityInfo
+	}
+
+	if config.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = nilValue
+		}
+
+		config.Hostname = hostname
+	}
+
+	if config.AppName == "" {
+		config.AppName = defaultAppName
+	}
+
+	return &Exporter{
+		config: config,
+		stream: stream,
+		procID: strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+// Register registers the "syslog" exporter type configured by [Config].
+func Register(r *exporter.Registry) {
+	exporter.Register(r, "syslog", func(config Config) (exporter.Exporter, error) {
+		e, err := NewExporter(config)
+		if err != nil {
+			return nil, err
+		}
+
+		return e, nil
+	})
+}
+
+// Export implements exporter.Exporter.
+func (e *Exporter) Export(statement sql.Statement) (err error) {
+	return e.ExportBatch([]sql.Statement{statement})
+}
+
+// ExportBatch implements exporter.Exporter.
+func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, statement := range statements {
+		err = e.write(e.format(statement, time.Now()))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close implements exporter.Closer.
+func (e *Exporter) Close() (err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		return nil
+	}
+
+	err = e.conn.Close()
+	e.conn = nil
+	return err
+}
+
+func (e *Exporter) write(message string) error {
+	if e.conn == nil {
+		conn, err := net.DialTimeout(e.config.Network, e.config.Address, dialTimeout)
+		if err != nil {
+			return fmt.Errorf("failed connecting to syslog: %w", err)
+		}
+
+		e.conn = conn
+	}
+
+	if e.stream {
+		message = strconv.Itoa(len(message)) + " " + message
+	}
+
+	_, err := e.conn.Write([]byte(message))
+	if err != nil {
+		_ = e.conn.Close()
+		e.conn = nil
+		return fmt.Errorf("failed writing to syslog: %w", err)
+	}
+
+	return nil
+}
+
+// format formats the statement as RFC 5424 syslog message.
+func (e *Exporter) format(statement sql.Statement, now time.Time) string {
+	priority := int(e.config.Facility)*8 + int(e.config.Severity)
+	structuredData := fmt.Sprintf(`[%s file="%s" line="%d" dialect="%s" kind="%s"]`,
+		structuredDataID,
+		escapeParamValue(statement.File.Path),
+		statement.LineNum,
+		escapeParamValue(string(statement.File.Type)),
+		statement.Kind(),
+	)
+
+	return fmt.Sprintf("<%d>%d %s %s %s %s %s %s %s",
+		priority,
+		version,
+		now.Format("2006-01-02T15:04:05.000000Z07:00"),
+		header(e.config.Hostname),
+		header(e.config.AppName),
+		e.procID,
+		msgID,
+		structuredData,
+		statement.Content,
+	)
+}
+
+// header makes the value a valid header field consisting of printable ASCII.
+func header(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+
+		return r
+	}, value)
+	if value == "" {
+		return nilValue
+	}
+
+	return value
+}
+
+func escapeParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -0,0 +1,147 @@
+package syslog_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/exporter/syslog"
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+var messagePattern = regexp.MustCompile(
+	`^<134>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}\S+ test-host sql-processor \d+ statement ` +
+		`\[sql@32473 file="/var/sql/\\"users\\".sql" line="3" dialect="mysql" kind="query"\] SELECT \* FROM users$`,
+)
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	statement := sql.Statement{
+		File:    sql.File{Path: `/var/sql/"users".sql`, Type: sql.MySQL},
+		Content: "SELECT * FROM users",
+		LineNum: 3,
+	}
+
+	t.Run("UnknownNetwork", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := syslog.NewExporter(syslog.Config{Network: "carrier-pigeon"})
+		if !errors.Is(err, syslog.ErrUnknownNetwork) {
+			t.Fatalf("expected unknown network error: got = %v", err)
+		}
+	})
+
+	t.Run("UDP", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed listening: %v", err)
+		}
+
+		defer func() {
+			_ = listener.Close()
+		}()
+
+		e := newExporter(t, "udp", listener.LocalAddr().String())
+
+		err = e.Export(statement)
+		if err != nil {
+			t.Fatalf("failed exporting statement: %v", err)
+		}
+
+		datagram := make([]byte, 4096)
+		n, _, err := listener.ReadFrom(datagram)
+		if err != nil {
+			t.Fatalf("failed reading message: %v", err)
+		}
+
+		assertMessage(t, string(datagram[:n]))
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed listening: %v", err)
+		}
+
+		defer func() {
+			_ = listener.Close()
+		}()
+
+		e := newExporter(t, "tcp", listener.Addr().String())
+
+		err = e.ExportBatch([]sql.Statement{statement, statement})
+		if err != nil {
+			t.Fatalf("failed exporting batch: %v", err)
+		}
+
+		conn, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("failed accepting connection: %v", err)
+		}
+
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		// Messages are framed using octet counting.
+		reader := bufio.NewReader(conn)
+		for range 2 {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				t.Fatalf("failed reading message length: %v", err)
+			}
+
+			n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+			if err != nil {
+				t.Fatalf("invalid message length %q: %v", length, err)
+			}
+
+			message := make([]byte, n)
+			_, err = io.ReadFull(reader, message)
+			if err != nil {
+				t.Fatalf("failed reading message: %v", err)
+			}
+
+			assertMessage(t, string(message))
+		}
+	})
+}
+
+func newExporter(t *testing.T, network string, address string) *syslog.Exporter {
+	t.Helper()
+
+	e, err := syslog.NewExporter(syslog.Config{
+		Network:  network,
+		Address:  address,
+		Facility: syslog.FacilityLocal0,
+		Severity: syslog.SeverityInfo,
+		Hostname: "test-host",
+	})
+	if err != nil {
+		t.Fatalf("failed creating exporter: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = e.Close()
+	})
+
+	return e
+}
+
+func assertMessage(t *testing.T, message string) {
+	t.Helper()
+
+	if !messagePattern.MatchString(message) {
+		t.Fatalf("message does not match RFC 5424 format: %q", message)
+	}
+}
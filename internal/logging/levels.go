@@ -0,0 +1,192 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ComponentKey is the attribute key components tag their loggers with,
+// such as logger.With(logging.ComponentKey, "observer").
+const ComponentKey = "component"
+
+var ErrInvalidComponentLevel = errors.New("invalid component level")
+
+// Levels holds the minimum log level and its per-component overrides.
+// The levels can be changed at runtime and are safe for concurrent use.
+type Levels struct {
+	mu         sync.RWMutex
+	level      slog.Level
+	components map[string]slog.Level
+}
+
+// NewLevels creates new [Levels] with the default level and the per-component overrides.
+func NewLevels(level slog.Level, components map[string]slog.Level) *Levels {
+	l := &Levels{
+		level:      level,
+		components: make(map[string]slog.Level, len(components)),
+	}
+	maps.Copy(l.components, components)
+	return l
+}
+
+// Level returns the minimum level of the component. Components without an override use the default level.
+// The empty component returns the default level.
+func (l *Levels) Level(component string) slog.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	level, ok := l.components[component]
+	if !ok {
+		return l.level
+	}
+
+	return level
+}
+
+// Set sets the minimum level of the component. The empty component sets the default level.
+func (l *Levels) Set(component string, level slog.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if component == "" {
+		l.level = level
+		return
+	}
+
+	l.components[component] = level
+}
+
+// Reset removes the override of the component, so it uses the default level again.
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.components, component)
+}
+
+// Snapshot returns the default level and a copy of the per-component overrides.
+func (l *Levels) Snapshot() (level slog.Level, components map[string]slog.Level) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.level, maps.Clone(l.components)
+}
+
+// Handler wraps the handler so records are filtered by the level of the component of their logger.
+// The wrapped handler should not filter records by level itself.
+func (l *Levels) Handler(handler slog.Handler) slog.Handler {
+	return &levelHandler{handler: handler, levels: l}
+}
+
+// ServeHTTP serves the levels as JSON on GET and changes them on PUT.
+//
+// The PUT body is a JSON object with the "level" and optional "component" fields, e.g.
+// {"component": "observer", "level": "debug"}. An empty level resets the component override.
+func (l *Levels) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var request struct {
+			Component string `json:"component"`
+			Level     string `json:"level"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err == nil {
+			err = l.apply(request.Component, request.Level)
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	level, components := l.Snapshot()
+	response := struct {
+		Level      slog.Level            `json:"level"`
+		Components map[string]slog.Level `json:"components"`
+	}{level, components}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (l *Levels) apply(component, text string) error {
+	if text == "" {
+		if component == "" {
+			return fmt.Errorf("%w: default level cannot be reset", ErrInvalidComponentLevel)
+		}
+
+		l.Reset(component)
+		return nil
+	}
+
+	var level slog.Level
+	err := level.UnmarshalText([]byte(text))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidComponentLevel, err)
+	}
+
+	l.Set(component, level)
+	return nil
+}
+
+// ParseComponentLevel parses the "COMPONENT=LEVEL" override, such as "observer=debug".
+func ParseComponentLevel(input string) (component string, level slog.Level, err error) {
+	component, text, ok := strings.Cut(input, "=")
+	if !ok || component == "" {
+		return "", 0, fmt.Errorf("%w: %s, expected COMPONENT=LEVEL", ErrInvalidComponentLevel, input)
+	}
+
+	err = level.UnmarshalText([]byte(text))
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s: %w", ErrInvalidComponentLevel, input, err)
+	}
+
+	return component, level, nil
+}
+
+// levelHandler filters records by the level of the component its logger is tagged with.
+type levelHandler struct {
+	handler   slog.Handler
+	levels    *Levels
+	component string
+}
+
+// Enabled implements slog.Handler.
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component) && h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	for _, attr := range attrs {
+		if attr.Key == ComponentKey {
+			component = attr.Value.String()
+		}
+	}
+
+	return &levelHandler{handler: h.handler.WithAttrs(attrs), levels: h.levels, component: component}
+}
+
+// WithGroup implements slog.Handler.
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{handler: h.handler.WithGroup(name), levels: h.levels, component: h.component}
+}
@@ -0,0 +1,102 @@
+package logging_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/logging"
+)
+
+func TestLevels(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ComponentOverrides", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		levels := logging.NewLevels(slog.LevelWarn, map[string]slog.Level{"observer": slog.LevelDebug})
+		logger := slog.New(levels.Handler(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+		logger.With(logging.ComponentKey, "observer").Debug("observer debug")
+		logger.With(logging.ComponentKey, "processor").Info("processor info")
+		logger.WithGroup("request").Warn("default warn")
+
+		levels.Set("processor", slog.LevelInfo)
+		logger.With(logging.ComponentKey, "processor").Info("processor info after change")
+
+		levels.Reset("observer")
+		logger.With(logging.ComponentKey, "observer").Debug("observer debug after reset")
+
+		output := buffer.String()
+		for message, expected := range map[string]bool{
+			"observer debug":              true,
+			"processor info":              false,
+			"default warn":                true,
+			"processor info after change": true,
+			"observer debug after reset":  false,
+		} {
+			if strings.Contains(output, "msg=\""+message+"\"") != expected {
+				t.Errorf("%s: expected = %v, got = %v", message, expected, !expected)
+			}
+		}
+
+		if strings.Count(output, "\n") != 3 {
+			t.Fatalf("expected = %v, got = %v", 3, output)
+		}
+	})
+
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Parallel()
+
+		levels := logging.NewLevels(slog.LevelInfo, nil)
+
+		recorder := httptest.NewRecorder()
+		body := strings.NewReader(`{"component": "observer", "level": "debug"}`)
+		levels.ServeHTTP(recorder, httptest.NewRequest(http.MethodPut, "/loglevel", body))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected = %v, got = %v", http.StatusOK, recorder.Code)
+		}
+
+		expected := `{"level":"INFO","components":{"observer":"DEBUG"}}` + "\n"
+		if recorder.Body.String() != expected {
+			t.Fatalf("expected = %v, got = %v", expected, recorder.Body.String())
+		}
+
+		if levels.Level("observer") != slog.LevelDebug {
+			t.Fatalf("expected = %v, got = %v", slog.LevelDebug, levels.Level("observer"))
+		}
+
+		for _, request := range []*http.Request{
+			httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level": "loud"}`)),
+			httptest.NewRequest(http.MethodPut, "/loglevel", strings.NewReader(`{"level": ""}`)),
+			httptest.NewRequest(http.MethodPost, "/loglevel", nil),
+		} {
+			recorder := httptest.NewRecorder()
+			levels.ServeHTTP(recorder, request)
+			if recorder.Code == http.StatusOK {
+				t.Errorf("%s %s: expected failure", request.Method, request.URL)
+			}
+		}
+	})
+
+	t.Run("ParseComponentLevel", func(t *testing.T) {
+		t.Parallel()
+
+		component, level, err := logging.ParseComponentLevel("observer=debug")
+		if err != nil || component != "observer" || level != slog.LevelDebug {
+			t.Fatalf("unexpected component level: got = %v, %v, %v", component, level, err)
+		}
+
+		for _, input := range []string{"observer", "=debug", "observer=loud"} {
+			_, _, err := logging.ParseComponentLevel(input)
+			if !errors.Is(err, logging.ErrInvalidComponentLevel) {
+				t.Errorf("%s: expected = %v, got = %v", input, logging.ErrInvalidComponentLevel, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// TextFormat is the human-readable log format.
+	TextFormat = "text"
+	// JSONFormat is the JSON log format.
+	JSONFormat = "json"
+
+	fileDirectoryPermissions = 0o755
+	filePermissions          = 0o644
+)
+
+var ErrUnknownFormat = errors.New("unknown log format")
+
+// Config represents the logging configuration.
+type Config struct {
+	// Level is the default minimum level.
+	Level slog.Level
+	// Components are the per-component overrides of the minimum level.
+	Components map[string]slog.Level
+	// Format is either [TextFormat] or [JSONFormat].
+	Format string
+	// File is the file logs are appended to. Empty writes them to stderr.
+	File string
+}
+
+// Logger is a [slog.Logger] whose [Levels] can be changed at runtime.
+type Logger struct {
+	*slog.Logger
+
+	// Levels are the levels of the logger.
+	Levels *Levels
+	file   *os.File
+}
+
+// New creates a new [Logger] of the configuration.
+// The logger has to be closed when it writes to a file.
+func New(config Config) (*Logger, error) {
+	if config.Format != TextFormat && config.Format != JSONFormat {
+		return nil, fmt.Errorf("%w: %s, expected %s or %s", ErrUnknownFormat, config.Format, TextFormat, JSONFormat)
+	}
+
+	l := &Logger{Levels: NewLevels(config.Level, config.Components)}
+	var w io.Writer = os.Stderr
+	if config.File != "" {
+		err := os.MkdirAll(filepath.Dir(config.File), fileDirectoryPermissions)
+		if err != nil {
+			return nil, fmt.Errorf("failed creating log directory: %w", err)
+		}
+
+		l.file, err = os.OpenFile(config.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, filePermissions)
+		if err != nil {
+			return nil, fmt.Errorf("failed opening log file: %w", err)
+		}
+
+		w = l.file
+	}
+
+	// The levels filter the records, so the handler passes all of them.
+	options := &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if config.Format == JSONFormat {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	l.Logger = slog.New(l.Levels.Handler(handler))
+	return l, nil
+}
+
+// Close closes the log file, if any.
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
@@ -0,0 +1,62 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/logging"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "logs", "sql-processor.log")
+		logger, err := logging.New(logging.Config{
+			Level:      slog.LevelInfo,
+			Components: map[string]slog.Level{"observer": slog.LevelDebug},
+			Format:     logging.JSONFormat,
+			File:       path,
+		})
+		if err != nil {
+			t.Fatalf("failed creating logger: %v", err)
+		}
+
+		logger.With(logging.ComponentKey, "observer").Debug("observed new file")
+		logger.Debug("filtered")
+		err = logger.Close()
+		if err != nil {
+			t.Fatalf("failed closing logger: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed reading log file: %v", err)
+		}
+
+		var record map[string]any
+		err = json.Unmarshal(data, &record)
+		if err != nil {
+			t.Fatalf("expected a single JSON record: got = %s", data)
+		}
+
+		if record["msg"] != "observed new file" || record[logging.ComponentKey] != "observer" {
+			t.Fatalf("unexpected record: got = %v", record)
+		}
+	})
+
+	t.Run("UnknownFormat", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := logging.New(logging.Config{Format: "xml"})
+		if !errors.Is(err, logging.ErrUnknownFormat) {
+			t.Fatalf("expected = %v, got = %v", logging.ErrUnknownFormat, err)
+		}
+	})
+}
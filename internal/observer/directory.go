@@ -0,0 +1,151 @@
+package observer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+var (
+	ErrInvalidGlobPattern  = errors.New("invalid glob pattern")
+	ErrUnknownDisposition  = errors.New("unknown disposition")
+	ErrNoDispositionTarget = errors.New("no disposition target directory")
+)
+
+// Directory represents a directory observed by the [Observer].
+type Directory struct {
+	// Path is the observed directory.
+	Path string
+	// Type is the [sql.Type] of the files in the directory.
+	Type sql.Type
+	// Recursive also observes subdirectories, including the ones created later.
+	Recursive bool
+	// Include passes only files whose name matches any of the glob patterns. Empty passes all files.
+	Include []string
+	// Exclude skips files whose name matches any of the glob patterns.
+	Exclude []string
+	// Disposition is applied to the files of the directory once they are processed.
+	Disposition Disposition
+}
+
+// Validate checks the [Directory] glob patterns. It does not check the path exists.
+func (d Directory) Validate() error {
+	var errs error
+	for _, pattern := range slices.Concat(d.Include, d.Exclude) {
+		_, err := filepath.Match(pattern, "")
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("%w %s: %w", ErrInvalidGlobPattern, pattern, err))
+		}
+	}
+
+	return errs
+}
+
+// Matches reports whether the file of the given path passes the Include and Exclude patterns.
+// The patterns are matched against the file name.
+func (d Directory) Matches(path string) bool {
+	name := filepath.Base(path)
+	if len(d.Include) > 0 && !matchesAny(d.Include, name) {
+		return false
+	}
+
+	return !matchesAny(d.Exclude, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		matched, _ := filepath.Match(pattern, name)
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DispositionAction represents what happens to a file once it is processed.
+type DispositionAction string
+
+const (
+	// KeepAction leaves the file in place.
+	KeepAction DispositionAction = "keep"
+	// DeleteAction removes the file.
+	DeleteAction DispositionAction = "delete"
+	// MoveAction moves the file to the target directory.
+	MoveAction DispositionAction = "move"
+)
+
+// Disposition represents what happens to a file once it is processed. The zero value keeps the file.
+type Disposition struct {
+	Action DispositionAction
+	// Target is the directory files are moved to by [MoveAction].
+	Target string
+}
+
+// ParseDisposition parses the "keep", "delete" or "move:DIRECTORY" disposition.
+func ParseDisposition(input string) (d Disposition, err error) {
+	action, target, _ := strings.Cut(input, ":")
+	d = Disposition{Action: DispositionAction(action), Target: target}
+	switch d.Action {
+	case KeepAction, DeleteAction:
+		if target != "" {
+			return Disposition{}, fmt.Errorf("%w: %s", ErrUnknownDisposition, input)
+		}
+	case MoveAction:
+		if target == "" {
+			return Disposition{}, fmt.Errorf("%w: %s", ErrNoDispositionTarget, input)
+		}
+
+		d.Target = filepath.Clean(target)
+	default:
+		return Disposition{}, fmt.Errorf("%w: %s", ErrUnknownDisposition, input)
+	}
+
+	return d, nil
+}
+
+// String returns the textual representation parsed by [ParseDisposition].
+func (d Disposition) String() string {
+	switch d.Action {
+	case "":
+		return string(KeepAction)
+	case MoveAction:
+		return string(d.Action) + ":" + d.Target
+	default:
+		return string(d.Action)
+	}
+}
+
+// Apply applies the [Disposition] to the file of the given path.
+func (d Disposition) Apply(path string) error {
+	switch d.Action {
+	case "", KeepAction:
+		return nil
+	case DeleteAction:
+		err := os.Remove(path)
+		if err != nil {
+			return fmt.Errorf("failed deleting file: %w", err)
+		}
+
+		return nil
+	case MoveAction:
+		err := os.MkdirAll(d.Target, directoryPermissions)
+		if err != nil {
+			return fmt.Errorf("failed creating target directory: %w", err)
+		}
+
+		err = os.Rename(path, filepath.Join(d.Target, filepath.Base(path)))
+		if err != nil {
+			return fmt.Errorf("failed moving file: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownDisposition, d.Action)
+	}
+}
@@ -0,0 +1,130 @@
+package observer_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/observer"
+)
+
+func TestDirectory(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Matches", func(t *testing.T) {
+		t.Parallel()
+
+		directory := observer.Directory{
+			Include: []string{"*.sql", "*.ddl"},
+			Exclude: []string{"*_test.sql"},
+		}
+		matches := map[string]bool{
+			"/migrations/001_users.sql":      true,
+			"/migrations/002_tables.ddl":     true,
+			"/migrations/001_users_test.sql": false,
+			"/migrations/README.md":          false,
+		}
+		for path, expected := range matches {
+			if got := directory.Matches(path); got != expected {
+				t.Errorf("%s: expected = %v, got = %v", path, expected, got)
+			}
+		}
+
+		if !(observer.Directory{}).Matches("/anything") {
+			t.Errorf("expected directory without patterns to match all files")
+		}
+	})
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		t.Parallel()
+
+		err := observer.Directory{Exclude: []string{"[a-"}}.Validate()
+		if !errors.Is(err, observer.ErrInvalidGlobPattern) {
+			t.Fatalf("expected = %v, got = %v", observer.ErrInvalidGlobPattern, err)
+		}
+	})
+}
+
+func TestDisposition(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Parse", func(t *testing.T) {
+		t.Parallel()
+
+		valid := map[string]observer.Disposition{
+			"keep":          {Action: observer.KeepAction},
+			"delete":        {Action: observer.DeleteAction},
+			"move:/archive": {Action: observer.MoveAction, Target: "/archive"},
+		}
+		for input, expected := range valid {
+			got, err := observer.ParseDisposition(input)
+			if err != nil || got != expected {
+				t.Errorf("%s: expected = %v, got = %v (%v)", input, expected, got, err)
+			}
+
+			if got.String() != input {
+				t.Errorf("expected = %v, got = %v", input, got.String())
+			}
+		}
+
+		invalid := map[string]error{
+			"shred":       observer.ErrUnknownDisposition,
+			"delete:/tmp": observer.ErrUnknownDisposition,
+			"move":        observer.ErrNoDispositionTarget,
+		}
+		for input, expected := range invalid {
+			_, err := observer.ParseDisposition(input)
+			if !errors.Is(err, expected) {
+				t.Errorf("%s: expected = %v, got = %v", input, expected, err)
+			}
+		}
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		deleted := createFile(t, directory, "deleted.sql")
+		moved := createFile(t, directory, "moved.sql")
+		kept := createFile(t, directory, "kept.sql")
+		target := filepath.Join(directory, "archive")
+
+		dispositions := map[string]observer.Disposition{
+			deleted: {Action: observer.DeleteAction},
+			moved:   {Action: observer.MoveAction, Target: target},
+			kept:    {},
+		}
+		for path, disposition := range dispositions {
+			err := disposition.Apply(path)
+			if err != nil {
+				t.Fatalf("failed applying %v disposition: %v", disposition, err)
+			}
+		}
+
+		for path, exists := range map[string]bool{
+			deleted:                              false,
+			moved:                                false,
+			kept:                                 true,
+			filepath.Join(target, "moved.sql"):   true,
+			filepath.Join(target, "deleted.sql"): false,
+		} {
+			_, err := os.Stat(path)
+			if (err == nil) != exists {
+				t.Errorf("%s: expected = %v, got = %v", path, exists, err == nil)
+			}
+		}
+	})
+}
+
+func createFile(t *testing.T, directory string, name string) string {
+	t.Helper()
+
+	path := filepath.Join(directory, name)
+	err := os.WriteFile(path, []byte("SELECT 1;\n"), 0o600)
+	if err != nil {
+		t.Fatalf("failed creating file: %v", err)
+	}
+
+	return path
+}
@@ -0,0 +1,49 @@
+package observer
+
+import (
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the Prometheus metrics of the [Observer].
+// A nil [*Metrics] records nothing.
+type Metrics struct {
+	filesObserved *prometheus.CounterVec
+	pendingFiles  prometheus.Gauge
+}
+
+// NewMetrics creates the [Observer] metrics and registers them with the registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		filesObserved: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "observer",
+			Name:      "files_observed_total",
+			Help:      "Number of new files passed for processing.",
+		}, []string{"dialect", "directory"}),
+		pendingFiles: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "sql_processor",
+			Subsystem: "observer",
+			Name:      "pending_files",
+			Help:      "Number of new files waiting to stop being written to.",
+		}),
+	}
+	registerer.MustRegister(m.filesObserved, m.pendingFiles)
+	return m
+}
+
+func (m *Metrics) observed(file sql.File, directory Directory) {
+	if m == nil {
+		return
+	}
+
+	m.filesObserved.WithLabelValues(string(file.Type), directory.Path).Inc()
+}
+
+func (m *Metrics) pending(count int) {
+	if m == nil {
+		return
+	}
+
+	m.pendingFiles.Set(float64(count))
+}
@@ -3,46 +3,332 @@ package observer
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/tracing"
 	"github.com/fsnotify/fsnotify"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
-const directivePartCount = 2
+const (
+	directivePartCount   = 2
+	directoryPermissions = 0o755
+	// settleDelay is the time a new file has to stay unmodified before it gets passed for processing.
+	settleDelay = 100 * time.Millisecond
+	tracerName  = "github.com/course-go/sql-processor/internal/observer"
+)
 
 var (
 	ErrNoDirectoryDirectivesProvided = errors.New("no directory paths provided")
 	ErrInvalidDirectoryDirective     = errors.New("directory directive has invalid format")
 )
 
+// Option configures the [Observer].
+type Option func(o *Observer)
+
+// WithMetrics records the [Observer] metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *Observer) {
+		o.metrics = metrics
+	}
+}
+
+// WithTracerProvider starts a trace for each observed file using the provider.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(o *Observer) {
+		o.tracer = provider.Tracer(tracerName)
+	}
+}
+
 // Observer observes given filesystem directories for new files.
 // When it notices such file it creates a [sql.File] and passes it for processing.
 type Observer struct {
-	logger         *slog.Logger
-	watcher        *fsnotify.Watcher
-	fileCh         chan<- sql.File
-	directoryTypes map[string]sql.Type
+	logger  *slog.Logger
+	watcher *fsnotify.Watcher
+	fileCh  chan<- sql.File
+	// directories maps each watched directory to the [Directory] it belongs to.
+	// Subdirectories of recursive directories map to their root.
+	// It is guarded by mu as subdirectories get added while running.
+	directories map[string]Directory
+	mu          *sync.RWMutex
+	// pendingCount is the number of new files waiting to stop being written to.
+	pendingCount *atomic.Int64
+	metrics      *Metrics
+	tracer       trace.Tracer
 }
 
 // New creates a new [Observer].
 //
 // The directives parameter represents a directory directives in the "[directory]:[sql.Type]" format.
 // For example, the following is a valid directory directive: "/var/sql/postgres:postgres".
-func New(logger *slog.Logger, directives []string, fileCh chan<- sql.File) (o Observer, err error) {
-	// TODO: Implement.
+func New(logger *slog.Logger, directives []string, fileCh chan<- sql.File, opts ...Option) (o Observer, err error) {
+	directoryTypes, err := ParseDirectives(directives)
+	if err != nil {
+		return Observer{}, err
+	}
+
+	directories := make([]Directory, 0, len(directoryTypes))
+	for path, sqlType := range directoryTypes {
+		directories = append(directories, Directory{Path: path, Type: sqlType})
+	}
+
+	return NewFromDirectories(logger, directories, fileCh, opts...)
+}
+
+// NewFromDirectories creates a new [Observer] of the given [Directory]s.
+func NewFromDirectories(
+	logger *slog.Logger,
+	directories []Directory,
+	fileCh chan<- sql.File,
+	opts ...Option,
+) (o Observer, err error) {
+	if len(directories) == 0 {
+		return Observer{}, ErrNoDirectoryDirectivesProvided
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return Observer{}, fmt.Errorf("failed creating filesystem watcher: %w", err)
+	}
+
+	o = Observer{
+		logger:       logger.With("component", "observer"),
+		watcher:      watcher,
+		fileCh:       fileCh,
+		directories:  make(map[string]Directory, len(directories)),
+		mu:           &sync.RWMutex{},
+		pendingCount: &atomic.Int64{},
+		tracer:       noop.NewTracerProvider().Tracer(tracerName),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, directory := range directories {
+		directory.Path = filepath.Clean(directory.Path)
+		err = directory.Validate()
+		if err == nil {
+			_, err = o.watch(directory.Path, directory)
+		}
+
+		if err != nil {
+			_ = watcher.Close()
+			return Observer{}, fmt.Errorf("failed watching directory %s: %w", directory.Path, err)
+		}
+	}
 
-	return Observer{}, nil
+	return o, nil
+}
+
+// ParseDirectives parses directory directives into a map of directories and their [sql.Type]s.
+func ParseDirectives(directives []string) (directoryTypes map[string]sql.Type, err error) {
+	if len(directives) == 0 {
+		return nil, ErrNoDirectoryDirectivesProvided
+	}
+
+	directoryTypes = make(map[string]sql.Type, len(directives))
+	for _, directive := range directives {
+		directory, sqlType, err := ParseDirective(directive)
+		if err != nil {
+			return nil, err
+		}
+
+		directoryTypes[directory] = sqlType
+	}
+
+	return directoryTypes, nil
+}
+
+// ParseDirective parses a single "[path]:[sql.Type]" directive into its cleaned path and [sql.Type].
+func ParseDirective(directive string) (path string, sqlType sql.Type, err error) {
+	parts := strings.Split(directive, ":")
+	if len(parts) != directivePartCount || parts[0] == "" {
+		return "", "", fmt.Errorf("%w: %s", ErrInvalidDirectoryDirective, directive)
+	}
+
+	sqlType, err = sql.ParseType(parts[1])
+	if err != nil {
+		return "", "", fmt.Errorf("failed parsing directive %s: %w", directive, err)
+	}
+
+	return filepath.Clean(parts[0]), sqlType, nil
 }
 
 // Run starts the [Observer].
+//
+// New files are passed for processing once they stop being written to.
+// It returns when the context is done or the [Observer] gets closed.
 func (o *Observer) Run(ctx context.Context) {
-	// TODO: Implement.
+	pending := make(map[string]time.Time)
+	ticker := time.NewTicker(settleDelay / 2) //nolint: mnd
+	defer ticker.Stop()
+	defer o.setPending(0)
+
+	for {
+		o.setPending(len(pending))
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-o.watcher.Events:
+			if !ok {
+				return
+			}
+
+			_, created := pending[event.Name]
+			if event.Has(fsnotify.Create) && o.watchCreated(event.Name, pending) {
+				continue
+			}
+
+			if event.Has(fsnotify.Create) || (created && event.Has(fsnotify.Write)) {
+				pending[event.Name] = time.Now()
+			}
+		case err, ok := <-o.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			o.logger.Error("failed watching directories", "error", err)
+		case now := <-ticker.C:
+			for path, modified := range pending {
+				if now.Sub(modified) < settleDelay {
+					continue
+				}
+
+				delete(pending, path)
+				o.observe(ctx, path)
+			}
+		}
+	}
+}
+
+// Pending returns the number of new files waiting to stop being written to before they are passed.
+func (o *Observer) Pending() int {
+	return int(o.pendingCount.Load())
+}
+
+func (o *Observer) setPending(count int) {
+	o.pendingCount.Store(int64(count))
+	o.metrics.pending(count)
 }
 
 // Close closes the [Observer].
 func (o *Observer) Close() error {
-	// TODO: Implement.
+	return o.watcher.Close()
+}
+
+// Directory returns the observed [Directory] the file of the given path belongs to.
+func (o *Observer) Directory(path string) (directory Directory, ok bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	directory, ok = o.directories[filepath.Dir(path)]
+	return directory, ok
+}
+
+// watch watches the path belonging to the directory and, when the directory is recursive, all its subdirectories.
+// It returns the files already present in the subdirectories.
+func (o *Observer) watch(path string, directory Directory) (files []string, err error) {
+	if !directory.Recursive {
+		o.addDirectory(path, directory)
+		return nil, o.watcher.Add(path)
+	}
+
+	err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+
+		o.addDirectory(path, directory)
+		return o.watcher.Add(path)
+	})
+
+	return files, err
+}
+
+func (o *Observer) addDirectory(path string, directory Directory) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.directories[path] = directory
+}
+
+// watchCreated starts watching the created subdirectory of a recursive directory.
+// Files created in it before the watch started become pending. It reports whether the path was a directory.
+func (o *Observer) watchCreated(path string, pending map[string]time.Time) bool {
+	directory, ok := o.Directory(path)
+	if !ok || !directory.Recursive {
+		return false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	files, err := o.watch(path, directory)
+	if err != nil {
+		o.logger.Error("failed watching subdirectory", "path", path, "error", err)
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		pending[file] = now
+	}
+
+	return true
+}
+
+func (o *Observer) observe(ctx context.Context, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		o.logger.Warn("created file disappeared", "path", path, "error", err)
+		return
+	}
+
+	directory, ok := o.Directory(path)
+	if info.IsDir() || !ok || !directory.Matches(path) {
+		return
+	}
+
+	// Each file starts its own trace the processing and exporting spans belong to.
+	spanCtx, span := o.tracer.Start(ctx, "observe file",
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("file.path", path),
+			attribute.String("sql.dialect", string(directory.Type)),
+			attribute.String("observer.directory", directory.Path),
+		),
+	)
+	defer span.End()
+
+	file := sql.File{
+		Path:  path,
+		Type:  directory.Type,
+		Trace: tracing.TraceParent(spanCtx),
+	}
 
-	return nil
+	o.logger.Debug("observed new file", "path", file.Path, "type", file.Type)
+	select {
+	case o.fileCh <- file:
+		o.metrics.observed(file, directory)
+	case <-ctx.Done():
+		span.SetStatus(codes.Error, "file not passed for processing")
+	}
 }
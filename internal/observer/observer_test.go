@@ -4,9 +4,11 @@ import (
 	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -14,6 +16,11 @@ import (
 	"github.com/course-go/sql-processor/internal/observer"
 	"github.com/course-go/sql-processor/internal/sql"
 	"github.com/course-go/sql-processor/internal/test/testlogger"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestObserver(t *testing.T) { //nolint: cyclop, gocognit, maintidx
@@ -237,6 +244,194 @@ func TestObserver(t *testing.T) { //nolint: cyclop, gocognit, maintidx
 			}
 		}
 	})
+
+	t.Run("RecursiveDirectory", func(t *testing.T) {
+		t.Parallel()
+
+		fileCh := make(chan sql.File, 4)
+		logger, _ := testlogger.NewTestErrorLogger()
+
+		directory := t.TempDir()
+		existing := filepath.Join(directory, "existing")
+		err := os.Mkdir(existing, 0o700)
+		if err != nil {
+			t.Fatalf("failed to create file directory: %v", err)
+		}
+
+		directories := []observer.Directory{{
+			Path:      directory,
+			Type:      sql.SQLite,
+			Recursive: true,
+			Include:   []string{"*.sql"},
+			Exclude:   []string{"skip*"},
+		}}
+		o, err := observer.NewFromDirectories(logger, directories, fileCh)
+		if err != nil {
+			t.Fatalf("failed to create observer: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		go o.Run(ctx)
+
+		defer func() {
+			err := o.Close()
+			if err != nil {
+				t.Fatalf("failed to close observer: %v", err)
+			}
+		}()
+
+		created := filepath.Join(directory, "created", "nested")
+		err = os.MkdirAll(created, 0o700)
+		if err != nil {
+			t.Fatalf("failed to create file directory: %v", err)
+		}
+
+		files := []sql.File{{Path: "test.sql", Type: sql.SQLite}, {Path: "skip.sql"}, {Path: "notes.txt"}}
+		createFiles(t, directory, files)
+		createFiles(t, existing, files)
+		createFiles(t, created, files)
+
+		expected := []sql.File{
+			{Path: filepath.Join(created, "test.sql"), Type: sql.SQLite},
+			{Path: filepath.Join(existing, "test.sql"), Type: sql.SQLite},
+			{Path: filepath.Join(directory, "test.sql"), Type: sql.SQLite},
+		}
+
+		var observed []sql.File
+		timeout := time.After(5 * time.Second)
+		for len(observed) < len(expected) {
+			select {
+			case file := <-fileCh:
+				observed = append(observed, file)
+			case <-timeout:
+				t.Fatalf("observed file count does not match: expected = %v, got = %v", expected, observed)
+			}
+		}
+
+		slices.SortFunc(observed, func(a, b sql.File) int {
+			return cmp.Compare(a.Path, b.Path)
+		})
+
+		if !slices.Equal(observed, expected) {
+			t.Fatalf("expected = %v, got = %v", expected, observed)
+		}
+
+		root, ok := o.Directory(expected[0].Path)
+		if !ok || root.Path != directory {
+			t.Fatalf("expected = %v, got = %v", directory, root.Path)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	files := []sql.File{{Path: "a.sql", Type: sql.MySQL}, {Path: "b.sql", Type: sql.MySQL}}
+	fileCh := make(chan sql.File, len(files))
+	logger, loggerWriter := testlogger.NewTestErrorLogger()
+	registry := prometheus.NewRegistry()
+
+	directory := t.TempDir()
+	o, err := observer.NewFromDirectories(logger, []observer.Directory{{Path: directory, Type: sql.MySQL}}, fileCh,
+		observer.WithMetrics(observer.NewMetrics(registry)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create observer: %v", err)
+	}
+
+	defer func() {
+		_ = o.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		o.Run(ctx)
+	}()
+
+	createFiles(t, directory, files)
+	for range files {
+		select {
+		case <-fileCh:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for observed files")
+		}
+	}
+
+	cancel()
+	<-done
+
+	loggerWriter.AssertWrites(t, 0)
+	expected := fmt.Sprintf(`
+# HELP sql_processor_observer_files_observed_total Number of new files passed for processing.
+# TYPE sql_processor_observer_files_observed_total counter
+sql_processor_observer_files_observed_total{dialect="mysql",directory=%q} 2
+# HELP sql_processor_observer_pending_files Number of new files waiting to stop being written to.
+# TYPE sql_processor_observer_pending_files gauge
+sql_processor_observer_pending_files 0
+`, directory)
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	fileCh := make(chan sql.File, 1)
+	logger, loggerWriter := testlogger.NewTestErrorLogger()
+	recorder := tracetest.NewSpanRecorder()
+
+	directory := t.TempDir()
+	o, err := observer.NewFromDirectories(logger, []observer.Directory{{Path: directory, Type: sql.SQLite}}, fileCh,
+		observer.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))),
+	)
+	if err != nil {
+		t.Fatalf("failed to create observer: %v", err)
+	}
+
+	defer func() {
+		_ = o.Close()
+	}()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		o.Run(ctx)
+	}()
+
+	createFiles(t, directory, []sql.File{{Path: "a.sql", Type: sql.SQLite}})
+	var file sql.File
+	select {
+	case file = <-fileCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for observed file")
+	}
+
+	cancel()
+	<-done
+
+	loggerWriter.AssertWrites(t, 0)
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected = %v, got = %v", 1, len(spans))
+	}
+
+	// Each observed file starts a new trace carried by the file.
+	if spans[0].Name() != "observe file" || spans[0].Parent().IsValid() {
+		t.Fatalf("unexpected span: got = %v, parent = %v", spans[0].Name(), spans[0].Parent())
+	}
+
+	if !tracing.SpanContext(file.Trace).Equal(spans[0].SpanContext().WithRemote(true)) {
+		t.Fatalf("expected = %v, got = %v", spans[0].SpanContext(), file.Trace)
+	}
 }
 
 func createFiles(t *testing.T, directory string, files []sql.File) {
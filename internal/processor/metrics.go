@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"path/filepath"
+
+	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics represents the Prometheus metrics of the [Processor].
+// A nil [*Metrics] records nothing.
+type Metrics struct {
+	filesProcessed *prometheus.CounterVec
+	filesFailed    *prometheus.CounterVec
+	statements     *prometheus.CounterVec
+	parseDuration  *prometheus.HistogramVec
+	fileSize       *prometheus.HistogramVec
+}
+
+// NewMetrics creates the [Processor] metrics and registers them with the registerer.
+func NewMetrics(registerer prometheus.Registerer) *Metrics {
+	labels := []string{"dialect", "directory"}
+	m := &Metrics{
+		filesProcessed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "processor",
+			Name:      "files_processed_total",
+			Help:      "Number of processed files, including the failed ones.",
+		}, labels),
+		filesFailed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "processor",
+			Name:      "files_failed_total",
+			Help:      "Number of files that failed to process.",
+		}, labels),
+		statements: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "sql_processor",
+			Subsystem: "processor",
+			Name:      "statements_total",
+			Help:      "Number of statements parsed from the files.",
+		}, labels),
+		parseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "sql_processor",
+			Subsystem: "processor",
+			Name:      "parse_duration_seconds",
+			Help:      "Time of reading and parsing a file, including passing its statements down.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"dialect"}),
+		fileSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "sql_processor",
+			Subsystem: "processor",
+			Name:      "file_size_bytes",
+			Help:      "Number of bytes read from a file.",
+			Buckets:   prometheus.ExponentialBuckets(256, 4, 9), //nolint: mnd
+		}, []string{"dialect"}),
+	}
+	registerer.MustRegister(m.filesProcessed, m.filesFailed, m.statements, m.parseDuration, m.fileSize)
+	return m
+}
+
+func (m *Metrics) processed(result Result, size int64) {
+	if m == nil {
+		return
+	}
+
+	dialect, directory := string(result.File.Type), filepath.Dir(result.File.Path)
+	if result.File.Path == sql.StdinPath {
+		directory = sql.StdinPath
+	}
+
+	m.filesProcessed.WithLabelValues(dialect, directory).Inc()
+	m.statements.WithLabelValues(dialect, directory).Add(float64(result.StatementCount))
+	m.parseDuration.WithLabelValues(dialect).Observe(result.Duration.Seconds())
+	m.fileSize.WithLabelValues(dialect).Observe(float64(size))
+	if result.Err != nil {
+		m.filesFailed.WithLabelValues(dialect, directory).Inc()
+	}
+}
@@ -1,29 +1,348 @@
 package processor
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/course-go/sql-processor/internal/sql"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+const (
+	commentPrefix      = "--"
+	statementDelimiter = ";"
+	bufferSize         = 64 << 10
+	maxLineSize        = 16 << 20
+	tracerName         = "github.com/course-go/sql-processor/internal/processor"
+)
+
+// Option configures the [Processor].
+type Option func(p *Processor)
+
+// WithWorkers sets the number of files processed concurrently. Defaults to 1.
+// Statements of a single file always keep their order.
+func WithWorkers(workers int) Option {
+	return func(p *Processor) {
+		p.workers = max(workers, 1)
+	}
+}
+
+// WithStdin sets the reader of the file with [sql.StdinPath]. Defaults to [os.Stdin].
+func WithStdin(r io.Reader) Option {
+	return func(p *Processor) {
+		p.stdin = r
+	}
+}
+
+// WithTracerProvider traces reading and parsing of each file using the provider.
+// The spans belong to the trace of the file, if any. Otherwise, they start a new trace
+// the statements of the file carry.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(p *Processor) {
+		p.tracer = provider.Tracer(tracerName)
+	}
+}
+
+// WithMetrics records the [Processor] metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(p *Processor) {
+		p.metrics = metrics
+	}
+}
+
+// WithResultHandler calls the handler with the [Result] of each processed file.
+// The handler is called from the processing goroutines, so it has to be safe for concurrent use.
+func WithResultHandler(handler func(result Result)) Option {
+	return func(p *Processor) {
+		p.resultHandler = handler
+	}
+}
+
+// Result represents the outcome of processing a single [sql.File].
+type Result struct {
+	File           sql.File
+	StatementCount int
+	// Kinds counts the statements passed down by their [sql.Kind].
+	Kinds    map[sql.Kind]int
+	Duration time.Duration
+	Err      error
+}
+
+// Stats represents statistics of the [Processor].
+//
+// Counters are cumulative since the [Processor] creation.
+type Stats struct {
+	ProcessedCount uint64
+	FailedCount    uint64
+	StatementCount uint64
+	// InFlightCount is the number of files being processed.
+	InFlightCount int64
+}
+
 // Processor is a component that receives given [sql.File] and processes them to [sql.Statement]s.
 // It reads the given files and parses the statements from them.
 type Processor struct {
 	logger      *slog.Logger
 	fileCh      <-chan sql.File
 	statementCh chan<- sql.Statement
+	workers     int
+	stdin       io.Reader
+
+	resultHandler func(result Result)
+	metrics       *Metrics
+	tracer        trace.Tracer
+	counters      *counters
+	stopCh        chan struct{}
+	stopOnce      *sync.Once
 }
 
-func New(logger *slog.Logger, fileCh <-chan sql.File, statementCh chan<- sql.Statement) Processor {
-	return Processor{
+type counters struct {
+	processed  atomic.Uint64
+	failed     atomic.Uint64
+	statements atomic.Uint64
+	inFlight   atomic.Int64
+}
+
+func New(logger *slog.Logger, fileCh <-chan sql.File, statementCh chan<- sql.Statement, opts ...Option) Processor {
+	p := Processor{
 		logger:      logger.With("component", "processor"),
 		fileCh:      fileCh,
 		statementCh: statementCh,
+		workers:     1,
+		stdin:       os.Stdin,
+		tracer:      noop.NewTracerProvider().Tracer(tracerName),
+		counters:    &counters{},
+		stopCh:      make(chan struct{}),
+		stopOnce:    &sync.Once{},
 	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p
 }
 
 // Run runs the [Processor].
+//
+// It returns when the context is done, the file channel gets closed
+// and all the received files are processed, or [Processor.Stop] is called
+// and the files being processed are finished.
 func (p *Processor) Run(ctx context.Context) {
-	// TODO: Implement.
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Go(func() { p.work(ctx) })
+	}
+
+	wg.Wait()
+}
+
+// Stop stops the running [Processor] from receiving further files.
+// The files being processed are finished, unless the context of [Processor.Run] is done.
+// Files left in the file channel are not received.
+func (p *Processor) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+}
+
+// Stats returns the [Processor] statistics.
+func (p *Processor) Stats() Stats {
+	return Stats{
+		ProcessedCount: p.counters.processed.Load(),
+		FailedCount:    p.counters.failed.Load(),
+		StatementCount: p.counters.statements.Load(),
+		InFlightCount:  p.counters.inFlight.Load(),
+	}
+}
+
+// work processes files one by one until the context is done, the file channel gets closed
+// or the [Processor] is stopped.
+func (p *Processor) work(ctx context.Context) {
+	for {
+		// Stopping takes precedence over files ready in the channel.
+		select {
+		case <-p.stopCh:
+			return
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.stopCh:
+			return
+		case file, ok := <-p.fileCh:
+			if !ok {
+				return
+			}
+
+			start := time.Now()
+			p.counters.inFlight.Add(1)
+			spanCtx, span := p.startSpan(ctx, &file)
+			kinds := make(map[sql.Kind]int)
+			statements, size, err := p.process(spanCtx, file, kinds)
+			endSpan(span, statements, size, err)
+			p.counters.inFlight.Add(-1)
+			p.counters.processed.Add(1)
+			p.counters.statements.Add(uint64(statements))
+			if err != nil {
+				p.counters.failed.Add(1)
+				p.logger.Error("failed processing file", "path", file.Path, "error", err)
+			}
+
+			if err == nil {
+				p.logger.Debug("processed file", "path", file.Path, "statements", statements)
+			}
+
+			result := Result{
+				File:           file,
+				StatementCount: statements,
+				Kinds:          kinds,
+				Duration:       time.Since(start),
+				Err:            err,
+			}
+			p.metrics.processed(result, size)
+			if p.resultHandler != nil {
+				p.resultHandler(result)
+			}
+		}
+	}
+}
+
+// startSpan starts the span of processing the file in the trace of the file.
+// Files without a trace get the trace of the span so their statements carry it.
+func (p *Processor) startSpan(ctx context.Context, file *sql.File) (context.Context, trace.Span) {
+	ctx, span := p.tracer.Start(tracing.ContextWithTraceParent(ctx, file.Trace), "parse file", trace.WithAttributes(
+		attribute.String("file.path", file.Path),
+		attribute.String("sql.dialect", string(file.Type)),
+	))
+	if file.Trace == "" {
+		file.Trace = tracing.TraceParent(ctx)
+	}
+
+	return ctx, span
+}
+
+func endSpan(span trace.Span, statements int, size int64, err error) {
+	span.SetAttributes(
+		attribute.Int("sql.statements", statements),
+		attribute.Int64("file.size", size),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// process opens the file and parses its statements.
+// It returns the number of statements passed down and the number of bytes read.
+// The statements passed down are counted by their kind in kinds.
+func (p *Processor) process(
+	ctx context.Context,
+	file sql.File,
+	kinds map[sql.Kind]int,
+) (statements int, size int64, err error) {
+	r := p.stdin
+	if file.Path != sql.StdinPath {
+		f, err := os.Open(file.Path)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed opening file: %w", err)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		r = f
+	}
+
+	counter := &countingReader{r: r}
+	statements, err = p.parse(ctx, file, counter, kinds)
+	return statements, counter.n, err
+}
+
+// parse parses the statements of the file read from r and passes them down.
+//
+// Statements start on a new line, end with a semicolon and may span multiple lines.
+// Lines starting with "--" are comments.
+func (p *Processor) parse(
+	ctx context.Context,
+	file sql.File,
+	r io.Reader,
+	kinds map[sql.Kind]int,
+) (statements int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufferSize), maxLineSize)
+
+	var lines []string
+	lineNum, statementLineNum := 0, 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, commentPrefix) {
+			continue
+		}
+
+		if len(lines) == 0 {
+			statementLineNum = lineNum
+		}
+
+		content, complete := strings.CutSuffix(line, statementDelimiter)
+		lines = append(lines, content)
+		if !complete {
+			continue
+		}
+
+		statement := sql.Statement{
+			File:    file,
+			Content: strings.Join(lines, "\n"),
+			LineNum: statementLineNum,
+		}
+		lines = nil
+
+		select {
+		case p.statementCh <- statement:
+			statements++
+			kinds[statement.Kind()]++
+		case <-ctx.Done():
+			return statements, ctx.Err()
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return statements, fmt.Errorf("failed reading file: %w", err)
+	}
+
+	if len(lines) != 0 {
+		p.logger.Warn("file ends with an unterminated statement", "path", file.Path, "line", statementLineNum)
+	}
+
+	return statements, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
 }
@@ -2,8 +2,13 @@ package processor_test
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"testing/synctest"
 	"time"
@@ -11,6 +16,11 @@ import (
 	"github.com/course-go/sql-processor/internal/processor"
 	"github.com/course-go/sql-processor/internal/sql"
 	"github.com/course-go/sql-processor/internal/test/testlogger"
+	"github.com/course-go/sql-processor/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 const filePermissions = 0o755
@@ -333,6 +343,180 @@ func TestRun(t *testing.T) { //nolint: cyclop, gocognit, maintidx
 			}
 		})
 	})
+	t.Run("Workers", func(t *testing.T) {
+		t.Parallel()
+
+		logger, loggerWriter := testlogger.NewTestErrorLogger()
+		fileCh := make(chan sql.File, 3)
+		statementCh := make(chan sql.Statement, 16)
+
+		p := processor.New(logger, fileCh, statementCh, processor.WithWorkers(3))
+
+		directory := t.TempDir()
+		for _, name := range []string{"a.sql", "b.sql", "c.sql"} {
+			path := filepath.Join(directory, name)
+			err := os.WriteFile(path, []byte("SELECT 1;\nSELECT 2;\nSELECT 3;\n"), filePermissions)
+			if err != nil {
+				t.Fatalf("failed writing file: %v", err)
+			}
+
+			fileCh <- sql.File{Path: path, Type: sql.SQLite}
+		}
+
+		close(fileCh)
+		p.Run(t.Context())
+		close(statementCh)
+
+		lineNums := make(map[string][]int)
+		for statement := range statementCh {
+			lineNums[statement.File.Path] = append(lineNums[statement.File.Path], statement.LineNum)
+		}
+
+		loggerWriter.AssertWrites(t, 0)
+		for path, got := range lineNums {
+			if !slices.Equal(got, []int{1, 2, 3}) {
+				t.Errorf("statements of %s out of order: expected = %v, got = %v", path, []int{1, 2, 3}, got)
+			}
+		}
+
+		if len(lineNums) != 3 {
+			t.Fatalf("unexpected file count: expected = %v, got = %v", 3, len(lineNums))
+		}
+	})
+
+	t.Run("ResultsAndStats", func(t *testing.T) {
+		t.Parallel()
+
+		logger, loggerWriter := testlogger.NewTestErrorLogger()
+		fileCh := make(chan sql.File, 2)
+		statementCh := make(chan sql.Statement, 16)
+
+		var (
+			mu      sync.Mutex
+			results []processor.Result
+		)
+		p := processor.New(logger, fileCh, statementCh, processor.WithResultHandler(func(result processor.Result) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			results = append(results, result)
+		}))
+
+		directory := t.TempDir()
+		path := filepath.Join(directory, "a.sql")
+		err := os.WriteFile(path, []byte("SELECT 1;\nSELECT 2;\n"), filePermissions)
+		if err != nil {
+			t.Fatalf("failed writing file: %v", err)
+		}
+
+		fileCh <- sql.File{Path: path, Type: sql.SQLite}
+		fileCh <- sql.File{Path: filepath.Join(directory, "nonexistent.sql"), Type: sql.SQLite}
+		close(fileCh)
+		p.Run(t.Context())
+
+		loggerWriter.AssertWrites(t, 1)
+		expected := processor.Stats{ProcessedCount: 2, FailedCount: 1, StatementCount: 2}
+		if p.Stats() != expected {
+			t.Fatalf("expected = %+v, got = %+v", expected, p.Stats())
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("expected = %v, got = %v", 2, len(results))
+		}
+
+		if results[0].StatementCount != 2 || results[0].Err != nil || results[1].Err == nil {
+			t.Fatalf("unexpected results: got = %+v", results)
+		}
+
+		if results[0].Kinds[sql.QueryKind] != 2 || len(results[0].Kinds) != 1 {
+			t.Fatalf("expected = %v, got = %v", map[sql.Kind]int{sql.QueryKind: 2}, results[0].Kinds)
+		}
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		t.Parallel()
+
+		logger, loggerWriter := testlogger.NewTestErrorLogger()
+		fileCh := make(chan sql.File, 1)
+		statementCh := make(chan sql.Statement, 4)
+
+		stdin := strings.NewReader("SELECT 1;\n-- comment\nSELECT *\nFROM users;\n")
+		p := processor.New(logger, fileCh, statementCh, processor.WithStdin(stdin))
+
+		file := sql.File{Path: sql.StdinPath, Type: sql.PostgresType}
+		fileCh <- file
+		close(fileCh)
+		p.Run(t.Context())
+		close(statementCh)
+
+		expected := []sql.Statement{
+			{File: file, Content: "SELECT 1", LineNum: 1},
+			{File: file, Content: "SELECT *\nFROM users", LineNum: 3},
+		}
+		var got []sql.Statement
+		for statement := range statementCh {
+			got = append(got, statement)
+		}
+
+		loggerWriter.AssertWrites(t, 0)
+		if !slices.Equal(got, expected) {
+			t.Fatalf("expected = %v, got = %v", expected, got)
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		t.Parallel()
+
+		logger, loggerWriter := testlogger.NewTestErrorLogger()
+		fileCh := make(chan sql.File, 2)
+		statementCh := make(chan sql.Statement, 4)
+
+		stdin, stdinWriter := io.Pipe()
+		p := processor.New(logger, fileCh, statementCh, processor.WithStdin(stdin))
+
+		stdinFile := sql.File{Path: sql.StdinPath, Type: sql.SQLite}
+		pendingFile := sql.File{Path: filepath.Join(t.TempDir(), "pending.sql"), Type: sql.SQLite}
+		fileCh <- stdinFile
+		fileCh <- pendingFile
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			p.Run(t.Context())
+		}()
+
+		_, err := io.WriteString(stdinWriter, "SELECT 1;\n")
+		if err != nil {
+			t.Fatalf("failed writing stdin: %v", err)
+		}
+
+		<-statementCh
+		p.Stop()
+		p.Stop()
+
+		_, err = io.WriteString(stdinWriter, "SELECT 2;\n")
+		if err != nil {
+			t.Fatalf("failed writing stdin: %v", err)
+		}
+
+		stdinWriter.Close()
+		<-done
+
+		loggerWriter.AssertWrites(t, 0)
+		if statement := <-statementCh; statement.Content != "SELECT 2" {
+			t.Errorf("expected = %v, got = %v", "SELECT 2", statement.Content)
+		}
+
+		if file := <-fileCh; file != pendingFile {
+			t.Errorf("expected = %v, got = %v", pendingFile, file)
+		}
+
+		expected := processor.Stats{ProcessedCount: 1, StatementCount: 2}
+		if p.Stats() != expected {
+			t.Fatalf("expected = %+v, got = %+v", expected, p.Stats())
+		}
+	})
 }
 
 func copyFile(t *testing.T, directory string, file string) string {
@@ -354,3 +538,108 @@ func copyFile(t *testing.T, directory string, file string) string {
 
 	return path
 }
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	logger, loggerWriter := testlogger.NewTestErrorLogger()
+	fileCh := make(chan sql.File, 2)
+	statementCh := make(chan sql.Statement, 16)
+	registry := prometheus.NewRegistry()
+	p := processor.New(logger, fileCh, statementCh, processor.WithMetrics(processor.NewMetrics(registry)))
+
+	directory := t.TempDir()
+	path := filepath.Join(directory, "a.sql")
+	err := os.WriteFile(path, []byte("SELECT 1;\nSELECT 2;\n"), filePermissions)
+	if err != nil {
+		t.Fatalf("failed writing file: %v", err)
+	}
+
+	fileCh <- sql.File{Path: path, Type: sql.SQLite}
+	fileCh <- sql.File{Path: filepath.Join(directory, "nonexistent.sql"), Type: sql.SQLite}
+	close(fileCh)
+	p.Run(t.Context())
+
+	loggerWriter.AssertWrites(t, 1)
+	labels := fmt.Sprintf(`{dialect="sqlite",directory=%q}`, directory)
+	expected := `
+# HELP sql_processor_processor_files_failed_total Number of files that failed to process.
+# TYPE sql_processor_processor_files_failed_total counter
+sql_processor_processor_files_failed_total` + labels + ` 1
+# HELP sql_processor_processor_files_processed_total Number of processed files, including the failed ones.
+# TYPE sql_processor_processor_files_processed_total counter
+sql_processor_processor_files_processed_total` + labels + ` 2
+# HELP sql_processor_processor_statements_total Number of statements parsed from the files.
+# TYPE sql_processor_processor_statements_total counter
+sql_processor_processor_statements_total` + labels + ` 2
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"sql_processor_processor_files_processed_total",
+		"sql_processor_processor_files_failed_total",
+		"sql_processor_processor_statements_total",
+	)
+	if err != nil {
+		t.Fatalf("unexpected metrics: %v", err)
+	}
+
+	count, err := testutil.GatherAndCount(registry, "sql_processor_processor_file_size_bytes")
+	if err != nil || count != 1 {
+		t.Fatalf("expected = %v, got = %v (%v)", 1, count, err)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	logger, loggerWriter := testlogger.NewTestErrorLogger()
+	fileCh := make(chan sql.File, 2)
+	statementCh := make(chan sql.Statement, 16)
+	p := processor.New(logger, fileCh, statementCh,
+		processor.WithTracerProvider(provider),
+		processor.WithStdin(strings.NewReader("SELECT 2;\n")),
+	)
+
+	path := filepath.Join(t.TempDir(), "a.sql")
+	err := os.WriteFile(path, []byte("SELECT 1;\n"), filePermissions)
+	if err != nil {
+		t.Fatalf("failed writing file: %v", err)
+	}
+
+	ctx, fileSpan := provider.Tracer("test").Start(t.Context(), "observe file")
+	fileSpan.End()
+
+	observed := sql.File{Path: path, Type: sql.SQLite, Trace: tracing.TraceParent(ctx)}
+	fileCh <- observed
+	fileCh <- sql.File{Path: sql.StdinPath, Type: sql.SQLite}
+	close(fileCh)
+	p.Run(t.Context())
+	close(statementCh)
+
+	loggerWriter.AssertWrites(t, 0)
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected = %v, got = %v", 3, len(spans))
+	}
+
+	// The observed file is parsed in its trace.
+	if spans[1].Name() != "parse file" || spans[1].Parent().SpanID() != fileSpan.SpanContext().SpanID() {
+		t.Fatalf("unexpected span: got = %v, parent = %v", spans[1].Name(), spans[1].Parent())
+	}
+
+	// The file without a trace starts a new one.
+	if spans[2].Parent().IsValid() || spans[2].SpanContext().TraceID() == fileSpan.SpanContext().TraceID() {
+		t.Fatalf("expected new trace: got = %v", spans[2].SpanContext())
+	}
+
+	statement := <-statementCh
+	if statement.File != observed {
+		t.Fatalf("expected = %v, got = %v", observed, statement.File)
+	}
+
+	statement = <-statementCh
+	if tracing.SpanContext(statement.File.Trace).SpanID() != spans[2].SpanContext().SpanID() {
+		t.Fatalf("expected = %v, got = %v", spans[2].SpanContext().SpanID(), statement.File.Trace)
+	}
+}
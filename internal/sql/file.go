@@ -1,7 +1,13 @@
 package sql
 
+// StdinPath is the path of the synthetic [File] read from the standard input.
+const StdinPath = "<stdin>"
+
 // File represents SQL file.
 type File struct {
-	Path string
-	Type Type
+	Path string `json:"path"`
+	Type Type   `json:"type"`
+	// Trace is the W3C Trace Context traceparent of the trace the file is processed in, if any.
+	// It is not encoded so the statements keep their wire format.
+	Trace string `json:"-"`
 }
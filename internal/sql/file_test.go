@@ -0,0 +1,19 @@
+package sql_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+func TestFileTraceNotEncoded(t *testing.T) {
+	t.Parallel()
+
+	trace := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	file := sql.File{Path: "test.sql", Type: sql.SQLite, Trace: trace}
+	bytes, err := json.Marshal(file)
+	if err != nil || string(bytes) != `{"path":"test.sql","type":"sqlite"}` {
+		t.Fatalf("unexpected file encoding: got = %s (%v)", bytes, err)
+	}
+}
@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+var ErrUnknownKind = errors.New("unknown statement kind")
+
+// Kind represents SQL statement kind.
+type Kind string
+
+const (
+	// QueryKind represents statements reading data like SELECT.
+	QueryKind Kind = "query"
+	// DMLKind represents data manipulation statements like INSERT or UPDATE.
+	DMLKind Kind = "dml"
+	// DDLKind represents data definition statements like CREATE or DROP.
+	DDLKind Kind = "ddl"
+	// DCLKind represents data control statements like GRANT.
+	DCLKind Kind = "dcl"
+	// TCLKind represents transaction control statements like COMMIT.
+	TCLKind Kind = "tcl"
+	// OtherKind represents all other statements.
+	OtherKind Kind = "other"
+)
+
+var keywordKinds = map[string]Kind{
+	"SELECT":    QueryKind,
+	"WITH":      QueryKind,
+	"VALUES":    QueryKind,
+	"TABLE":     QueryKind,
+	"SHOW":      QueryKind,
+	"EXPLAIN":   QueryKind,
+	"DESCRIBE":  QueryKind,
+	"INSERT":    DMLKind,
+	"UPDATE":    DMLKind,
+	"DELETE":    DMLKind,
+	"MERGE":     DMLKind,
+	"REPLACE":   DMLKind,
+	"UPSERT":    DMLKind,
+	"COPY":      DMLKind,
+	"CREATE":    DDLKind,
+	"ALTER":     DDLKind,
+	"DROP":      DDLKind,
+	"TRUNCATE":  DDLKind,
+	"RENAME":    DDLKind,
+	"COMMENT":   DDLKind,
+	"GRANT":     DCLKind,
+	"REVOKE":    DCLKind,
+	"BEGIN":     TCLKind,
+	"START":     TCLKind,
+	"COMMIT":    TCLKind,
+	"ROLLBACK":  TCLKind,
+	"SAVEPOINT": TCLKind,
+	"RELEASE":   TCLKind,
+}
+
+func ParseKind(input string) (k Kind, err error) {
+	switch kind := Kind(input); kind {
+	case QueryKind, DMLKind, DDLKind, DCLKind, TCLKind, OtherKind:
+		return kind, nil
+	default:
+		return "", ErrUnknownKind
+	}
+}
+
+// Kind returns the [Kind] of the statement based on its first keyword.
+func (s Statement) Kind() Kind {
+	keyword, _, _ := strings.Cut(strings.TrimLeft(s.Content, "( \t\n"), " ")
+	keyword = strings.TrimRightFunc(keyword, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	kind, ok := keywordKinds[strings.ToUpper(keyword)]
+	if !ok {
+		return OtherKind
+	}
+
+	return kind
+}
@@ -0,0 +1,52 @@
+package sql_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/sql"
+)
+
+func TestStatementKind(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		content string
+		kind    sql.Kind
+	}{
+		{content: "SELECT * FROM users", kind: sql.QueryKind},
+		{content: "(SELECT 1) UNION (SELECT 2)", kind: sql.QueryKind},
+		{content: "insert into users VALUES (1)", kind: sql.DMLKind},
+		{content: "UPDATE users\nSET name = 'Jane'", kind: sql.DMLKind},
+		{content: "CREATE TABLE users (id INT)", kind: sql.DDLKind},
+		{content: "GRANT SELECT ON users TO reader", kind: sql.DCLKind},
+		{content: "COMMIT", kind: sql.TCLKind},
+		{content: "VACUUM", kind: sql.OtherKind},
+		{content: "", kind: sql.OtherKind},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.content, func(t *testing.T) {
+			t.Parallel()
+
+			kind := sql.Statement{Content: tc.content}.Kind()
+			if kind != tc.kind {
+				t.Fatalf("kinds do not match: expected = %v, got = %v", tc.kind, kind)
+			}
+		})
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	t.Parallel()
+
+	kind, err := sql.ParseKind("ddl")
+	if err != nil || kind != sql.DDLKind {
+		t.Fatalf("failed parsing valid kind: kind = %v, err = %v", kind, err)
+	}
+
+	_, err = sql.ParseKind("unknown")
+	if !errors.Is(err, sql.ErrUnknownKind) {
+		t.Fatal("expected unknown kind error")
+	}
+}
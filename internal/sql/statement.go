@@ -2,7 +2,7 @@ package sql
 
 // Statement represents SQL statement in SQL file.
 type Statement struct {
-	File    File
-	Content string
-	LineNum int
+	File    File   `json:"file"`
+	Content string `json:"content"`
+	LineNum int    `json:"lineNum"`
 }
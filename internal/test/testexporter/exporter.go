@@ -14,6 +14,9 @@ import (
 type Exporter struct {
 	mu         sync.Mutex
 	statements []sql.Statement
+	batches    [][]sql.Statement
+	unblockCh  chan struct{}
+	err        error
 }
 
 func New() *Exporter {
@@ -31,6 +34,23 @@ func (e *Exporter) Export(statement sql.Statement) (err error) {
 
 // ExportBatch implements Exporter.
 func (e *Exporter) ExportBatch(statements []sql.Statement) (err error) {
+	e.mu.Lock()
+	unblockCh := e.unblockCh
+	failErr := e.err
+	e.mu.Unlock()
+
+	if unblockCh != nil {
+		<-unblockCh
+	}
+
+	if failErr != nil {
+		return failErr
+	}
+
+	e.mu.Lock()
+	e.batches = append(e.batches, statements)
+	e.mu.Unlock()
+
 	for _, statement := range statements {
 		err = errors.Join(err, e.Export(statement))
 	}
@@ -44,3 +64,48 @@ func (e *Exporter) Statements() []sql.Statement {
 
 	return e.statements
 }
+
+func (e *Exporter) Batches() [][]sql.Statement {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.batches
+}
+
+// Block makes batch exports wait until [Exporter.Unblock] is called.
+func (e *Exporter) Block() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unblockCh = make(chan struct{})
+}
+
+// Unblock releases batch exports blocked by [Exporter.Block].
+func (e *Exporter) Unblock() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.unblockCh != nil {
+		close(e.unblockCh)
+		e.unblockCh = nil
+	}
+}
+
+// Step releases a single batch export blocked by [Exporter.Block]. It waits for the export to be blocked.
+func (e *Exporter) Step() {
+	e.mu.Lock()
+	unblockCh := e.unblockCh
+	e.mu.Unlock()
+
+	if unblockCh != nil {
+		unblockCh <- struct{}{}
+	}
+}
+
+// Fail makes batch exports fail with the given error. Nil error restores exporting.
+func (e *Exporter) Fail(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.err = err
+}
@@ -36,6 +36,10 @@ func TestExporter(t *testing.T) {
 		t.Fatalf("failed exporting batch: %v", err)
 	}
 
+	if len(e.Batches()) != 1 {
+		t.Fatalf("unexpected batch count: expected = %v, got = %v", 1, len(e.Batches()))
+	}
+
 	if len(statements) != len(e.Statements()) {
 		t.Fatalf("statement lengths do not match: expected = %v, got = %v", len(statements), len(e.Statements()))
 	}
@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const traceParentHeader = "traceparent"
+
+// TraceParent returns the W3C Trace Context traceparent header value of the span in the context.
+// It is empty when the context has no valid span.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// ContextWithTraceParent returns a copy of the parent context carrying the traceparent as the remote span.
+// The parent context is returned as is when the traceparent is empty.
+func ContextWithTraceParent(parent context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return parent
+	}
+
+	carrier := propagation.MapCarrier{traceParentHeader: traceParent}
+	return propagation.TraceContext{}.Extract(parent, carrier)
+}
+
+// SpanContext returns the span context of the traceparent.
+// It is invalid when the traceparent is empty or malformed.
+func SpanContext(traceParent string) trace.SpanContext {
+	return trace.SpanContextFromContext(ContextWithTraceParent(context.Background(), traceParent))
+}
@@ -0,0 +1,50 @@
+package tracing_test
+
+import (
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceParent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		t.Parallel()
+
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		})
+		traceParent := tracing.TraceParent(trace.ContextWithSpanContext(t.Context(), spanContext))
+
+		expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+		if traceParent != expected {
+			t.Fatalf("expected = %v, got = %v", expected, traceParent)
+		}
+
+		if !tracing.SpanContext(traceParent).Equal(spanContext.WithRemote(true)) {
+			t.Fatalf("expected = %v, got = %v", spanContext, tracing.SpanContext(traceParent))
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t.Parallel()
+
+		if traceParent := tracing.TraceParent(t.Context()); traceParent != "" {
+			t.Fatalf("expected empty trace parent: got = %v", traceParent)
+		}
+
+		if tracing.SpanContext("").IsValid() || tracing.SpanContext("malformed").IsValid() {
+			t.Fatalf("expected invalid span context")
+		}
+
+		if ctx := t.Context(); tracing.ContextWithTraceParent(ctx, "") != ctx {
+			t.Fatalf("expected parent context")
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var ErrInvalidEndpoint = errors.New("invalid OTLP endpoint")
+
+// ParseEndpoint parses the URL of an OTLP/gRPC endpoint, such as http://localhost:4317.
+// The http scheme disables transport security.
+func ParseEndpoint(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidEndpoint, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %s, expected http://HOST:PORT or https://HOST:PORT", ErrInvalidEndpoint, endpoint)
+	}
+
+	return endpoint, nil
+}
+
+// Provider is a [trace.TracerProvider] exporting the spans over OTLP/gRPC.
+type Provider struct {
+	trace.TracerProvider
+
+	sdk *sdktrace.TracerProvider
+}
+
+// New creates a new [Provider] exporting the spans of the service to the endpoint.
+// Nothing is traced without an endpoint. The provider has to be shut down to export the remaining spans.
+//
+// The exporter is further configured by the standard OTEL_EXPORTER_OTLP_* environment variables
+// and the resource by OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES.
+func New(ctx context.Context, endpoint string, service string) (*Provider, error) {
+	if endpoint == "" {
+		return &Provider{TracerProvider: noop.NewTracerProvider()}, nil
+	}
+
+	endpoint, err := ParseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed creating OTLP exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", service)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating resource: %w", err)
+	}
+
+	sdk := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	return &Provider{TracerProvider: sdk, sdk: sdk}, nil
+}
+
+// Shutdown exports the remaining spans and stops the [Provider].
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.sdk == nil {
+		return nil
+	}
+
+	err := p.sdk.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("failed shutting down tracer provider: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package tracing_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/course-go/sql-processor/internal/tracing"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		endpoint string
+		valid    bool
+	}{
+		{endpoint: "http://localhost:4317", valid: true},
+		{endpoint: "https://collector.example.com", valid: true},
+		{endpoint: "localhost:4317"},
+		{endpoint: "grpc://localhost:4317"},
+		{endpoint: "http://"},
+		{endpoint: "http://local host"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.endpoint, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tracing.ParseEndpoint(tc.endpoint)
+			if tc.valid && err != nil {
+				t.Fatalf("failed parsing valid endpoint: %v", err)
+			}
+
+			if !tc.valid && !errors.Is(err, tracing.ErrInvalidEndpoint) {
+				t.Fatalf("expected = %v, got = %v", tracing.ErrInvalidEndpoint, err)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoEndpoint", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := tracing.New(t.Context(), "", "sql-processor")
+		if err != nil {
+			t.Fatalf("failed creating provider: %v", err)
+		}
+
+		_, span := provider.Tracer("test").Start(t.Context(), "test")
+		span.End()
+		if span.SpanContext().IsValid() {
+			t.Fatalf("expected no trace without endpoint")
+		}
+
+		err = provider.Shutdown(t.Context())
+		if err != nil {
+			t.Fatalf("failed shutting down provider: %v", err)
+		}
+	})
+
+	t.Run("Endpoint", func(t *testing.T) {
+		t.Parallel()
+
+		provider, err := tracing.New(t.Context(), "http://127.0.0.1:4317", "sql-processor")
+		if err != nil {
+			t.Fatalf("failed creating provider: %v", err)
+		}
+
+		_, span := provider.Tracer("test").Start(t.Context(), "test")
+		if !span.SpanContext().IsValid() {
+			t.Fatalf("expected traced span with endpoint")
+		}
+
+		// The unfinished span is not exported.
+		err = provider.Shutdown(t.Context())
+		if err != nil {
+			t.Fatalf("failed shutting down provider: %v", err)
+		}
+	})
+
+	t.Run("InvalidEndpoint", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := tracing.New(t.Context(), "localhost:4317", "sql-processor")
+		if !errors.Is(err, tracing.ErrInvalidEndpoint) {
+			t.Fatalf("expected = %v, got = %v", tracing.ErrInvalidEndpoint, err)
+		}
+	})
+}